@@ -2,7 +2,11 @@ package backend
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/keyvault/azsecrets"
 )
 
@@ -19,7 +23,11 @@ func NewAKV(client *azsecrets.Client) *AKV {
 func (b *AKV) Get(ctx context.Context, _ *Profile, key string) ([]byte, error) {
 	resp, err := b.client.GetSecret(ctx, key, latestKeyVersion, nil)
 	if err != nil {
-		return nil, err
+		var respErr *azcore.ResponseError
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%s %w, %v", key, ErrKeyNotFound, err)
+		}
+		return nil, akvError(err)
 	}
 	return []byte(*resp.Value), nil
 }
@@ -31,16 +39,26 @@ func (b *AKV) List(ctx context.Context, _ *Profile) ([]Key, error) {
 	for pager.More() {
 		page, err := pager.NextPage(ctx)
 		if err != nil {
-			return nil, err
+			return nil, akvError(err)
 		}
 
 		for _, v := range page.Value {
-			keys = append(keys, Key{
+			key := Key{
 				Name:      v.ID.Name(),
 				CreatedAt: *v.Attributes.Created,
-				Info:      "creator: <Unknown>", // no owner
+				Info:      "creator: <Unknown>", // no owner, unless info is tagged
 				Owner:     "<Unknown>",
-			})
+			}
+			if v.ContentType != nil {
+				key.ContentType = *v.ContentType
+			}
+			if v.Attributes.Expires != nil {
+				key.ExpiresAt = *v.Attributes.Expires
+			}
+			if info, ok := v.Tags[infoMetadata]; ok && info != nil {
+				key.Info = *info
+			}
+			keys = append(keys, key)
 		}
 	}
 	return keys, nil
@@ -48,13 +66,20 @@ func (b *AKV) List(ctx context.Context, _ *Profile) ([]Key, error) {
 
 func (b *AKV) CheckExists(ctx context.Context, _ *Profile, key string) (bool, error) {
 	_, err := b.client.GetSecret(ctx, key, latestKeyVersion, nil)
-	return err == nil, err
+	return err == nil, akvError(err)
 }
 
 func (b *AKV) Put(ctx context.Context, _ *Profile, key, value string, overwrite bool) error {
-	_, err := b.client.SetSecret(ctx, key, azsecrets.SetSecretParameters{Value: &value}, nil)
+	params := azsecrets.SetSecretParameters{Value: &value}
+	if contentType := ContentTypeFromContext(ctx); len(contentType) > 0 {
+		params.ContentType = &contentType
+	}
+	if info := InfoFromContext(ctx); len(info) > 0 {
+		params.Tags = map[string]*string{infoMetadata: &info}
+	}
+	_, err := b.client.SetSecret(ctx, key, params, nil)
 	if err != nil {
-		return err
+		return akvError(err)
 	}
 	return nil
 }
@@ -62,7 +87,7 @@ func (b *AKV) Put(ctx context.Context, _ *Profile, key, value string, overwrite
 func (b *AKV) Delete(ctx context.Context, _ *Profile, key string) error {
 	_, err := b.client.DeleteSecret(ctx, key, nil)
 	if err != nil {
-		return err
+		return akvError(err)
 	}
 	return nil
 }
@@ -74,3 +99,12 @@ func (b *AKV) SetParameter(key string, value interface{}) {
 func (b *AKV) Close() error {
 	return nil
 }
+
+// akvError wraps the error in ErrPermissionDenied if the vault responded with 401 or 403.
+func akvError(err error) error {
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) && (respErr.StatusCode == http.StatusUnauthorized || respErr.StatusCode == http.StatusForbidden) {
+		return permissionDenied(err)
+	}
+	return err
+}
@@ -0,0 +1,277 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// awsKMSAPI is the part of the AWS KMS client used by AWSKMSStore.
+type awsKMSAPI interface {
+	GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// awsS3API is the part of the AWS S3 client used by AWSKMSStore.
+type awsS3API interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+}
+
+// AWSKMSStore implements Backend by envelope encryption with an AWS KMS key, storing each encrypted value
+// as an object in an S3 bucket. It mirrors the KMS backend which uses Google Cloud KMS and Storage.
+type AWSKMSStore struct {
+	kms    awsKMSAPI
+	s3     awsS3API
+	keyID  string
+	bucket string
+	prefix string
+}
+
+// awsKMSEnvelope is the content of an object: the data key encrypted by KMS and the value encrypted by the data key.
+type awsKMSEnvelope struct {
+	// DataKey is the ciphertext blob of the data key
+	DataKey []byte `json:"dataKey"`
+	// Nonce is used with the data key to encrypt the value using AES-256-GCM
+	Nonce []byte `json:"nonce"`
+	// Value is the encrypted value
+	Value []byte `json:"value"`
+}
+
+// awsKMSContextKey is the name of the encryption context entry that binds a data key to the name of its secret.
+const awsKMSContextKey = "kiya:key"
+
+// NewAWSKMSStore returns a new AWSKMSStore using the KMS key (cryptoKey), the bucket and the region (location) of the profile.
+// Keys are namespaced under the projectID of the profile.
+func NewAWSKMSStore(ctx context.Context, p *Profile) (*AWSKMSStore, error) {
+	if len(p.CryptoKey) == 0 || len(p.Bucket) == 0 {
+		return nil, errors.New("cryptoKey and bucket are required in profile for awskms")
+	}
+	httpClient, err := NewHTTPClient(p)
+	if err != nil {
+		return nil, err
+	}
+	options := []func(*config.LoadOptions) error{}
+	if httpClient != nil {
+		options = append(options, config.WithHTTPClient(httpClient))
+	}
+	if len(p.Location) > 0 {
+		options = append(options, config.WithRegion(p.Location))
+	}
+	// Load the Shared AWS Configuration (~/.aws/config)
+	cfg, err := config.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	return newAWSKMSStore(kms.NewFromConfig(cfg), s3.NewFromConfig(cfg), p), nil
+}
+
+func newAWSKMSStore(kmsClient awsKMSAPI, s3Client awsS3API, p *Profile) *AWSKMSStore {
+	return &AWSKMSStore{
+		kms:    kmsClient,
+		s3:     s3Client,
+		keyID:  p.CryptoKey,
+		bucket: p.Bucket,
+		prefix: etcdPrefix(p.ProjectID),
+	}
+}
+
+// awsKMSAuthErrorCodes are the AWS error codes of KMS and S3 requests that are not authenticated or not allowed.
+var awsKMSAuthErrorCodes = map[string]bool{
+	"AccessDenied":                true,
+	"AccessDeniedException":       true,
+	"UnrecognizedClientException": true,
+	"InvalidAccessKeyId":          true,
+	"SignatureDoesNotMatch":       true,
+	"ExpiredToken":                true,
+	"ExpiredTokenException":       true,
+	"InvalidSignatureException":   true,
+}
+
+// awsKMSError wraps the error in ErrPermissionDenied if AWS refused the credentials or the operation.
+func awsKMSError(err error) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && awsKMSAuthErrorCodes[apiErr.ErrorCode()] {
+		return permissionDenied(err)
+	}
+	return err
+}
+
+// encryptionContext binds the data key to the key of the secret such that an object cannot be decrypted under another name.
+func encryptionContext(key string) map[string]string {
+	return map[string]string{awsKMSContextKey: key}
+}
+
+func (a *AWSKMSStore) Get(ctx context.Context, _ *Profile, key string) ([]byte, error) {
+	output, err := a.s3.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(a.bucket), Key: aws.String(a.prefix + key)})
+	if err != nil {
+		var noSuchKey *s3types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, fmt.Errorf("%s %w", key, ErrKeyNotFound)
+		}
+		return nil, fmt.Errorf("failed to get secret from S3, %w", awsKMSError(err))
+	}
+	defer output.Body.Close()
+	var envelope awsKMSEnvelope
+	if err := json.NewDecoder(output.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("failed to read encrypted value of %s, %w", key, err)
+	}
+	decrypted, err := a.kms.Decrypt(ctx, &kms.DecryptInput{
+		CiphertextBlob:    envelope.DataKey,
+		EncryptionContext: encryptionContext(key),
+		KeyId:             aws.String(a.keyID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key with KMS, %w", awsKMSError(err))
+	}
+	defer Zero(decrypted.Plaintext)
+	aead, err := newAESGCM(decrypted.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+	value, err := aead.Open(nil, envelope.Nonce, envelope.Value, []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("message authentication failed")
+	}
+	return value, nil
+}
+
+func (a *AWSKMSStore) List(ctx context.Context, _ *Profile) ([]Key, error) {
+	paginator := s3.NewListObjectsV2Paginator(a.s3, &s3.ListObjectsV2Input{
+		Bucket:     aws.String(a.bucket),
+		Prefix:     aws.String(a.prefix),
+		FetchOwner: true,
+	})
+	var keys []Key
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list secrets from S3, %w", awsKMSError(err))
+		}
+		for _, each := range page.Contents {
+			owner := "<Unknown>"
+			if each.Owner != nil && each.Owner.DisplayName != nil {
+				owner = *each.Owner.DisplayName
+			}
+			key := Key{
+				Name:  strings.TrimPrefix(aws.ToString(each.Key), a.prefix),
+				Info:  fmt.Sprintf("creator: %s", owner),
+				Owner: owner,
+			}
+			if each.LastModified != nil {
+				key.CreatedAt = *each.LastModified
+			}
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (a *AWSKMSStore) CheckExists(ctx context.Context, _ *Profile, key string) (bool, error) {
+	_, err := a.s3.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(a.bucket), Key: aws.String(a.prefix + key)})
+	if err != nil {
+		var notFound *s3types.NotFound
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, awsKMSError(err)
+	}
+	return true, nil
+}
+
+// Put encrypts the value with a new data key generated by KMS and uploads it with the encrypted data key;
+// without overwrite it fails if the key already exists.
+func (a *AWSKMSStore) Put(ctx context.Context, p *Profile, key, value string, overwrite bool) error {
+	if !overwrite {
+		exists, err := a.CheckExists(ctx, p, key)
+		if err != nil {
+			return fmt.Errorf("failed to put secret in S3, %w", err)
+		}
+		if exists {
+			return fmt.Errorf("%s %w", key, ErrKeyExists)
+		}
+	}
+	dataKey, err := a.kms.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:             aws.String(a.keyID),
+		KeySpec:           kmstypes.DataKeySpecAes256,
+		EncryptionContext: encryptionContext(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate data key with KMS, %w", awsKMSError(err))
+	}
+	defer Zero(dataKey.Plaintext)
+	aead, err := newAESGCM(dataKey.Plaintext)
+	if err != nil {
+		return err
+	}
+	nonce := makeNonce(aead.NonceSize())
+	data, err := json.Marshal(awsKMSEnvelope{
+		DataKey: dataKey.CiphertextBlob,
+		Nonce:   nonce,
+		Value:   aead.Seal(nil, nonce, []byte(value), []byte(key)),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = a.s3.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(a.bucket),
+		Key:         aws.String(a.prefix + key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+		// the object holds the encrypted value so content type and info of the secret are stored as metadata
+		Metadata: metadataFromContext(ctx),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put secret in S3, %w", awsKMSError(err))
+	}
+	return nil
+}
+
+func (a *AWSKMSStore) Delete(ctx context.Context, p *Profile, key string) error {
+	// deleting a missing object succeeds in S3 so check first
+	exists, err := a.CheckExists(ctx, p, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete secret from S3, %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("%s %w", key, ErrKeyNotFound)
+	}
+	_, err = a.s3.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(a.bucket), Key: aws.String(a.prefix + key)})
+	if err != nil {
+		return fmt.Errorf("failed to delete secret from S3, %w", awsKMSError(err))
+	}
+	return nil
+}
+
+// Close is not effective for this backend
+func (a *AWSKMSStore) Close() error {
+	return nil
+}
+
+func (a *AWSKMSStore) SetParameter(key string, value interface{}) {
+	// noop
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key, %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,166 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// fakeKMS wraps data keys by reversing them, remembering the encryption context of each.
+type fakeKMS struct {
+	contexts map[string]map[string]string
+	err      error
+}
+
+func reversed(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
+
+func (f *fakeKMS) GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	plain := makeNonce(32)
+	blob := reversed(plain)
+	f.contexts[string(blob)] = params.EncryptionContext
+	return &kms.GenerateDataKeyOutput{Plaintext: plain, CiphertextBlob: blob}, nil
+}
+
+func (f *fakeKMS) Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	if !reflect.DeepEqual(f.contexts[string(params.CiphertextBlob)], params.EncryptionContext) {
+		return nil, &smithy.GenericAPIError{Code: "InvalidCiphertextException"}
+	}
+	return &kms.DecryptOutput{Plaintext: reversed(params.CiphertextBlob)}, nil
+}
+
+// fakeS3 keeps objects in memory.
+type fakeS3 struct {
+	objects  map[string][]byte
+	metadata map[string]map[string]string
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	data, ok := f.objects[*params.Key]
+	if !ok {
+		return nil, &s3types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	data, _ := io.ReadAll(params.Body)
+	f.objects[*params.Key] = data
+	f.metadata[*params.Key] = params.Metadata
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	if _, ok := f.objects[*params.Key]; !ok {
+		return nil, &s3types.NotFound{}
+	}
+	return &s3.HeadObjectOutput{}, nil
+}
+
+func (f *fakeS3) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	delete(f.objects, *params.Key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (f *fakeS3) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	var names []string
+	for each := range f.objects {
+		if strings.HasPrefix(each, aws.ToString(params.Prefix)) {
+			names = append(names, each)
+		}
+	}
+	sort.Strings(names)
+	output := &s3.ListObjectsV2Output{}
+	for _, each := range names {
+		output.Contents = append(output.Contents, s3types.Object{Key: aws.String(each)})
+	}
+	return output, nil
+}
+
+func newFakeAWSKMSStore() (*AWSKMSStore, *fakeKMS, *fakeS3) {
+	k := &fakeKMS{contexts: map[string]map[string]string{}}
+	s := &fakeS3{objects: map[string][]byte{}, metadata: map[string]map[string]string{}}
+	return newAWSKMSStore(k, s, &Profile{ProjectID: "team", CryptoKey: "arn:aws:kms:eu-west-1:1:key/1", Bucket: "secrets"}), k, s
+}
+
+func TestAWSKMSStore(t *testing.T) {
+	ctx := WithContentType(context.Background(), "text/plain")
+	p := &Profile{}
+	a, _, s := newFakeAWSKMSStore()
+
+	if err := a.Put(ctx, p, "db/password", "secret", false); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(s.objects["team/db/password"], []byte("secret")) {
+		t.Error("value is stored unencrypted")
+	}
+	if got := s.metadata["team/db/password"][contentTypeMetadata]; got != "text/plain" {
+		t.Errorf("got content type [%s]", got)
+	}
+	if err := a.Put(ctx, p, "db/password", "other", false); !errors.Is(err, ErrKeyExists) {
+		t.Errorf("expected ErrKeyExists, got %v", err)
+	}
+	if err := a.Put(ctx, p, "db/password", "other", true); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := a.Get(ctx, p, "db/password"); err != nil || string(value) != "other" {
+		t.Errorf("got %s %v want other", value, err)
+	}
+	if _, err := a.Get(ctx, p, "missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	keys, err := a.List(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].Name != "db/password" {
+		t.Errorf("got %v want db/password", keys)
+	}
+	if err := a.Delete(ctx, p, "db/password"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Delete(ctx, p, "db/password"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestAWSKMSStoreObjectBoundToKey(t *testing.T) {
+	ctx := context.Background()
+	p := &Profile{}
+	a, _, s := newFakeAWSKMSStore()
+	if err := a.Put(ctx, p, "a", "secret", false); err != nil {
+		t.Fatal(err)
+	}
+	// an object copied to another name must not decrypt
+	s.objects["team/b"] = s.objects["team/a"]
+	if _, err := a.Get(ctx, p, "b"); err == nil {
+		t.Error("expected error for an object copied to another key")
+	}
+}
+
+func TestAWSKMSStorePermissionDenied(t *testing.T) {
+	a, k, _ := newFakeAWSKMSStore()
+	k.err = &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized"}
+	if err := a.Put(context.Background(), &Profile{}, "a", "secret", true); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("expected ErrPermissionDenied, got %v", err)
+	}
+}
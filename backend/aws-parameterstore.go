@@ -2,25 +2,38 @@ package backend
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go"
 )
 
 // AWSParameterStore implements Backend for AWS Parameter Store service.
 type AWSParameterStore struct {
 	client   *ssm.Client
 	kmsKeyID string
+	// noDecrypt, if true, makes Get return the encrypted value of a SecureString
+	noDecrypt bool
 }
 
 // NewAWSParameterStore returns a new AWSParameterStore with an initialized AWS SSM client.
 func NewAWSParameterStore(ctx context.Context, p *Profile) (*AWSParameterStore, error) {
+	httpClient, err := NewHTTPClient(p)
+	if err != nil {
+		return nil, err
+	}
+	options := []func(*config.LoadOptions) error{}
+	if httpClient != nil {
+		options = append(options, config.WithHTTPClient(httpClient))
+	}
 	// Load the Shared AWS Configuration (~/.aws/config)
-	cfg, err := config.LoadDefaultConfig(ctx)
+	cfg, err := config.LoadDefaultConfig(ctx, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -29,15 +42,19 @@ func NewAWSParameterStore(ctx context.Context, p *Profile) (*AWSParameterStore,
 		kmsKeyID: p.CryptoKey}, nil
 }
 
-// Get returns the decrypted value for a parameter by key.
+// Get returns the decrypted value for a parameter by key, or the encrypted value if decryption is disabled.
 func (s *AWSParameterStore) Get(ctx context.Context, p *Profile, key string) ([]byte, error) {
 	input := &ssm.GetParameterInput{
 		Name:           aws.String(key),
-		WithDecryption: aws.Bool(true),
+		WithDecryption: aws.Bool(!s.noDecrypt),
 	}
 	output, err := s.client.GetParameter(ctx, input)
 	if err != nil {
-		return []byte{}, err
+		var notFound *types.ParameterNotFound
+		if errors.As(err, &notFound) {
+			return []byte{}, fmt.Errorf("%s %w, %v", key, ErrKeyNotFound, err)
+		}
+		return []byte{}, ssmError(err)
 	}
 
 	return []byte(*output.Parameter.Value), nil
@@ -53,7 +70,7 @@ func (s *AWSParameterStore) List(ctx context.Context, p *Profile) (list []Key, e
 	for {
 		output, err := s.client.GetParametersByPath(ctx, input)
 		if err != nil {
-			return []Key{}, err
+			return []Key{}, ssmError(err)
 		}
 		for _, each := range output.Parameters {
 			list = append(list, Key{
@@ -79,45 +96,146 @@ func (s *AWSParameterStore) CheckExists(ctx context.Context, p *Profile, key str
 		WithDecryption: aws.Bool(false), // No decryption is needed
 	}
 	_, err := s.client.GetParameter(ctx, input)
-	// other error?
 	if err != nil {
-		return false, err
+		// a missing parameter is not an error, other errors such as access denied are
+		var notFound *types.ParameterNotFound
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, ssmError(err)
 	}
 	return true, nil
+}
 
+// Maximum value sizes, in bytes, of the AWS Parameter Store tiers.
+const (
+	ssmStandardTierMaxSize = 4 * 1024
+	ssmAdvancedTierMaxSize = 8 * 1024
+)
+
+// checkTier returns the tier to use for the value or an error if the tier is unknown or the value is too large.
+func checkTier(tier string, value string) (types.ParameterTier, error) {
+	maxSize := ssmAdvancedTierMaxSize
+	switch types.ParameterTier(tier) {
+	case "", types.ParameterTierStandard:
+		tier = string(types.ParameterTierStandard)
+		maxSize = ssmStandardTierMaxSize
+	case types.ParameterTierAdvanced, types.ParameterTierIntelligentTiering:
+	default:
+		return "", fmt.Errorf("unknown SSM tier [%s], use Standard, Advanced or Intelligent-Tiering", tier)
+	}
+	if len(value) > maxSize {
+		hint := ""
+		if maxSize == ssmStandardTierMaxSize {
+			hint = ", set ssmTier to Advanced or Intelligent-Tiering in the profile for values up to 8KB"
+		}
+		return "", fmt.Errorf("value of %d bytes exceeds the maximum of %d bytes for the %s tier%s", len(value), maxSize, tier, hint)
+	}
+	return types.ParameterTier(tier), nil
 }
 
 // Put write the parameter and its value using encryption ;either the default key or the one specified in the profile.
 func (s *AWSParameterStore) Put(ctx context.Context, p *Profile, key, value string, overwrite bool) error {
+	tier, err := checkTier(p.SSMTier, value)
+	if err != nil {
+		return err
+	}
 	input := &ssm.PutParameterInput{
 		Name:      aws.String(key),
 		Value:     aws.String(value),
 		Overwrite: aws.Bool(overwrite),
 		DataType:  aws.String("text"),
 		Type:      types.ParameterTypeSecureString,
+		Tier:      tier,
 	}
 	if !overwrite {
-		input.Description = aws.String(fmt.Sprintf("created by %s using kiya", os.Getenv("USER")))
 		input.Tags = []types.Tag{{Key: aws.String("creator"), Value: aws.String(os.Getenv("USER"))}}
 	}
+	// info and content type are stored in the description, which is otherwise kept when overwriting
+	existing := ""
+	if overwrite && len(InfoFromContext(ctx)) == 0 && len(ContentTypeFromContext(ctx)) > 0 {
+		if existing, err = s.description(ctx, key); err != nil {
+			return err
+		}
+	}
+	input.Description = ssmDescription(ctx, existing, overwrite)
 	// only if CryptoKey is set in the Profile then we set the KeyId
 	// which overrides the default key associated with the AWS account
 	if p.CryptoKey != "" {
 		input.KeyId = aws.String(s.kmsKeyID)
 	}
-	_, err := s.client.PutParameter(ctx, input)
+	_, err = s.client.PutParameter(ctx, input)
 	if err != nil {
-		return err
+		return ssmError(err)
 	}
 	return nil
 }
 
+// description returns the description of the parameter, empty if it has none or does not exist.
+func (s *AWSParameterStore) description(ctx context.Context, key string) (string, error) {
+	output, err := s.client.DescribeParameters(ctx, &ssm.DescribeParametersInput{
+		ParameterFilters: []types.ParameterStringFilter{{
+			Key:    aws.String("Name"),
+			Option: aws.String("Equals"),
+			Values: []string{key},
+		}},
+	})
+	if err != nil {
+		return "", ssmError(err)
+	}
+	if len(output.Parameters) == 0 || output.Parameters[0].Description == nil {
+		return "", nil
+	}
+	return *output.Parameters[0].Description, nil
+}
+
+// ssmDescription returns the description to put with a parameter, nil to keep the existing one.
+// Info given explicitly replaces the description; a content type replaces the one in the existing description.
+func ssmDescription(ctx context.Context, existing string, overwrite bool) *string {
+	description := InfoFromContext(ctx)
+	if len(description) == 0 {
+		if overwrite {
+			description, _, _ = strings.Cut(existing, ", "+contentTypeMetadata+": ")
+		} else {
+			description = fmt.Sprintf("created by %s using kiya", os.Getenv("USER"))
+		}
+	}
+	contentType := ContentTypeFromContext(ctx)
+	if len(contentType) == 0 {
+		if len(description) == 0 {
+			return nil
+		}
+		return aws.String(description)
+	}
+	if len(description) == 0 {
+		description = fmt.Sprintf("updated by %s using kiya", os.Getenv("USER"))
+	}
+	return aws.String(fmt.Sprintf("%s, %s: %s", description, contentTypeMetadata, contentType))
+}
+
 // Delete removes the parameter by its key
 func (s *AWSParameterStore) Delete(ctx context.Context, p *Profile, key string) error {
 	input := &ssm.DeleteParameterInput{
 		Name: aws.String(key),
 	}
 	_, err := s.client.DeleteParameter(ctx, input)
+	return ssmError(err)
+}
+
+// ssmAuthErrorCodes are the AWS error codes of requests that are not authenticated or not allowed.
+var ssmAuthErrorCodes = map[string]bool{
+	"AccessDeniedException":       true,
+	"UnrecognizedClientException": true,
+	"ExpiredTokenException":       true,
+	"InvalidSignatureException":   true,
+}
+
+// ssmError wraps the error in ErrPermissionDenied if AWS refused the credentials or the operation.
+func ssmError(err error) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && ssmAuthErrorCodes[apiErr.ErrorCode()] {
+		return permissionDenied(err)
+	}
 	return err
 }
 
@@ -127,6 +245,12 @@ func (s *AWSParameterStore) Close() error {
 	return nil
 }
 
+// SetParameter accepts noDecrypt to get the encrypted form of SecureString values.
+// Such values can only be stored again in a parameter encrypted with the same KMS key.
 func (s *AWSParameterStore) SetParameter(key string, value interface{}) {
-	// noop
+	if key == "noDecrypt" {
+		if val, ok := value.(bool); ok {
+			s.noDecrypt = val
+		}
+	}
 }
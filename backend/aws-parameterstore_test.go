@@ -0,0 +1,112 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestCheckTier(t *testing.T) {
+	large := strings.Repeat("x", 5000)
+	if tier, err := checkTier("", "small"); err != nil || tier != types.ParameterTierStandard {
+		t.Errorf("Expected: Standard, got: %s %v", tier, err)
+	}
+	if _, err := checkTier("Standard", large); err == nil {
+		t.Error("Expected error for value exceeding the Standard tier")
+	}
+	if tier, err := checkTier("Intelligent-Tiering", large); err != nil || tier != types.ParameterTierIntelligentTiering {
+		t.Errorf("Expected: Intelligent-Tiering, got: %s %v", tier, err)
+	}
+	if _, err := checkTier("Advanced", strings.Repeat("x", 9000)); err == nil {
+		t.Error("Expected error for value exceeding the Advanced tier")
+	}
+	if _, err := checkTier("Premium", "small"); err == nil {
+		t.Error("Expected error for unknown tier")
+	}
+}
+
+func TestSetParameterNoDecrypt(t *testing.T) {
+	s := &AWSParameterStore{}
+	NewKeyEncodingBackend(s, "_").SetParameter("noDecrypt", true)
+	if !s.noDecrypt {
+		t.Error("Expected noDecrypt to be set through the decorator")
+	}
+}
+
+// cannedSSM answers every request with the same status and JSON body.
+type cannedSSM struct {
+	status int
+	body   string
+}
+
+func (c cannedSSM) Do(r *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: c.status,
+		Header:     http.Header{"Content-Type": []string{"application/x-amz-json-1.1"}},
+		Body:       io.NopCloser(strings.NewReader(c.body)),
+		Request:    r,
+	}, nil
+}
+
+func newCannedParameterStore(status int, body string) *AWSParameterStore {
+	client := ssm.New(ssm.Options{
+		Region:      "eu-west-1",
+		Credentials: aws.AnonymousCredentials{},
+		HTTPClient:  cannedSSM{status: status, body: body},
+	})
+	return &AWSParameterStore{client: client}
+}
+
+func TestCheckExistsFound(t *testing.T) {
+	s := newCannedParameterStore(200, `{"Parameter":{"Name":"a","Type":"SecureString","Value":"x"}}`)
+	exists, err := s.CheckExists(context.Background(), &Profile{}, "a")
+	if err != nil || !exists {
+		t.Errorf("Expected: true <nil>, got: %v %v", exists, err)
+	}
+}
+
+func TestCheckExistsNotFound(t *testing.T) {
+	s := newCannedParameterStore(400, `{"__type":"ParameterNotFound","message":"not found"}`)
+	exists, err := s.CheckExists(context.Background(), &Profile{}, "a")
+	if err != nil || exists {
+		t.Errorf("Expected: false <nil>, got: %v %v", exists, err)
+	}
+}
+
+func TestCheckExistsAccessDenied(t *testing.T) {
+	s := newCannedParameterStore(400, `{"__type":"AccessDeniedException","message":"not authorized"}`)
+	exists, err := s.CheckExists(context.Background(), &Profile{}, "a")
+	if !errors.Is(err, ErrPermissionDenied) || exists {
+		t.Errorf("Expected: false with ErrPermissionDenied, got: %v %v", exists, err)
+	}
+}
+
+func TestSSMDescription(t *testing.T) {
+	t.Setenv("USER", "alice")
+	ctx := context.Background()
+	for _, each := range []struct {
+		ctx       context.Context
+		existing  string
+		overwrite bool
+		want      string // empty means the description is kept
+	}{
+		{ctx, "", false, "created by alice using kiya"},
+		{ctx, "", true, ""},
+		{ctx, "owned by team A", true, "owned by team A"},
+		{WithInfo(ctx, "rotated"), "owned by team A", true, "rotated"},
+		{WithContentType(ctx, "application/json"), "owned by team A, content-type: text/plain", true, "owned by team A, content-type: application/json"},
+		{WithContentType(ctx, "application/json"), "", true, "updated by alice using kiya, content-type: application/json"},
+	} {
+		got := ssmDescription(each.ctx, each.existing, each.overwrite)
+		if (got == nil) != (len(each.want) == 0) || (got != nil && *got != each.want) {
+			t.Errorf("%q %v Expected: %q, got: %v", each.existing, each.overwrite, each.want, aws.ToString(got))
+		}
+	}
+}
@@ -2,9 +2,28 @@ package backend
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 )
 
+// ErrKeyNotFound is returned (wrapped) by a Backend if a key does not exist.
+var ErrKeyNotFound = errors.New("not found")
+
+// ErrKeyExists is returned (wrapped) by a Backend if a key must be new but already exists.
+var ErrKeyExists = errors.New("already exists")
+
+// ErrPermissionDenied is returned (wrapped) by a Backend if the credentials do not allow an operation.
+var ErrPermissionDenied = errors.New("permission denied")
+
+// permissionDenied wraps the error of a Backend in ErrPermissionDenied.
+func permissionDenied(err error) error {
+	return fmt.Errorf("%w, %v", ErrPermissionDenied, err)
+}
+
+// ErrNotSupported is returned if an optional operation is not supported by a Backend.
+var ErrNotSupported = errors.New("not supported by this backend")
+
 type Backend interface {
 	Get(ctx context.Context, p *Profile, key string) ([]byte, error)
 	List(ctx context.Context, p *Profile) ([]Key, error)
@@ -15,11 +34,118 @@ type Backend interface {
 	Close() error
 }
 
+// Version describes a single version of a secret.
+type Version struct {
+	ID        string
+	CreatedAt time.Time
+	// State is the state of the version, e.g. ENABLED, DISABLED or DESTROYED (gsm)
+	State string
+}
+
+// VersionedBackend is implemented by backends that keep multiple versions of a secret.
+type VersionedBackend interface {
+	// DeleteVersion destroys a single version of a secret, leaving the other versions intact.
+	DeleteVersion(ctx context.Context, p *Profile, key, version string) error
+	// ListVersions returns the versions of a secret, the latest first.
+	ListVersions(ctx context.Context, p *Profile, key string) ([]Version, error)
+	// GetVersion returns the value of a single version of a secret.
+	GetVersion(ctx context.Context, p *Profile, key, version string) ([]byte, error)
+}
+
+// DeleteVersion destroys a single version of a secret if the Backend supports versions, otherwise it returns ErrNotSupported.
+func DeleteVersion(ctx context.Context, b Backend, p *Profile, key, version string) error {
+	if versioned, ok := b.(VersionedBackend); ok {
+		return versioned.DeleteVersion(ctx, p, key, version)
+	}
+	return ErrNotSupported
+}
+
+// ListVersions returns the versions of a secret if the Backend supports versions, otherwise it returns ErrNotSupported.
+func ListVersions(ctx context.Context, b Backend, p *Profile, key string) ([]Version, error) {
+	if versioned, ok := b.(VersionedBackend); ok {
+		return versioned.ListVersions(ctx, p, key)
+	}
+	return nil, ErrNotSupported
+}
+
+// GetVersion returns the value of a single version of a secret if the Backend supports versions, otherwise it returns ErrNotSupported.
+func GetVersion(ctx context.Context, b Backend, p *Profile, key, version string) ([]byte, error) {
+	if versioned, ok := b.(VersionedBackend); ok {
+		return versioned.GetVersion(ctx, p, key, version)
+	}
+	return nil, ErrNotSupported
+}
+
+// BatchBackend is implemented by backends that can store multiple values at once.
+type BatchBackend interface {
+	// PutBatch stores all values; either all values are stored or none.
+	PutBatch(ctx context.Context, p *Profile, values map[string]string, overwrite bool) error
+}
+
+// PutBatch stores all values using the batch operation of the Backend if available, otherwise using Put for each value.
+func PutBatch(ctx context.Context, b Backend, p *Profile, values map[string]string, overwrite bool) error {
+	if batch, ok := b.(BatchBackend); ok {
+		return batch.PutBatch(ctx, p, values, overwrite)
+	}
+	return putEach(ctx, b, p, values, overwrite)
+}
+
+// putEach stores the values one by one and stops at the first error.
+func putEach(ctx context.Context, b Backend, p *Profile, values map[string]string, overwrite bool) error {
+	for key, value := range values {
+		if err := b.Put(ctx, p, key, value, overwrite); err != nil {
+			return fmt.Errorf("put %s failed, %w", key, err)
+		}
+	}
+	return nil
+}
+
+// AccessBinding is a role granted to members on a secret.
+type AccessBinding struct {
+	Role    string
+	Members []string
+}
+
+// AccessInspector is implemented by backends that can tell who has access to a secret.
+type AccessInspector interface {
+	// WhoCan returns the roles, and their members, that grant access to the secret.
+	WhoCan(ctx context.Context, p *Profile, key string) ([]AccessBinding, error)
+}
+
+// WhoCan returns the access bindings of a secret if the Backend supports it, otherwise it returns ErrNotSupported.
+func WhoCan(ctx context.Context, b Backend, p *Profile, key string) ([]AccessBinding, error) {
+	if inspector, ok := b.(AccessInspector); ok {
+		return inspector.WhoCan(ctx, p, key)
+	}
+	return nil, ErrNotSupported
+}
+
+// SupportsBatch returns whether the innermost Backend implements BatchBackend.
+func SupportsBatch(b Backend) bool {
+	_, ok := Unwrap(b).(BatchBackend)
+	return ok
+}
+
+// Unwrap returns the innermost Backend of a chain of decorators.
+func Unwrap(b Backend) Backend {
+	for {
+		wrapper, ok := b.(interface{ Unwrap() Backend })
+		if !ok {
+			return b
+		}
+		b = wrapper.Unwrap()
+	}
+}
+
 type Key struct {
 	Name      string
 	CreatedAt time.Time
 	Owner     string
 	Info      string
+	// ContentType describes the value, e.g. application/json, empty if unknown
+	ContentType string
+	// ExpiresAt is when the secret expires, zero if it does not or if the backend has no expiry (gsm, akv)
+	ExpiresAt time.Time
 }
 
 // Profile describes a single profile in a .kiya configuration
@@ -33,4 +159,49 @@ type Profile struct {
 	Bucket      string
 	VaultUrl    string
 	SecretRunes []rune
+	// BackupExcludes are patterns of keys that are never backed up
+	BackupExcludes []string
+	// SSMTier is the AWS Parameter Store tier: Standard (default), Advanced or Intelligent-Tiering
+	SSMTier string
+	// KeySeparator, if set, replaces each slash in a key before it is passed to the backend
+	KeySeparator string
+	// RateLimit is the maximum number of backend requests per second, unlimited if zero
+	RateLimit float64
+	// Retry, if set, describes how failed backend requests are retried
+	Retry *Retry
+	// Endpoints are the addresses of the etcd cluster or, its first only, of the Consul agent
+	Endpoints []string
+	// CACertFile and CACertPath (a directory of .pem or .crt files) add CA certificates to trust, e.g. of a private CA
+	CACertFile string
+	CACertPath string
+	// CertFile and KeyFile are the client certificate used to connect to etcd or Consul
+	CertFile string
+	KeyFile  string
+	// TLSSkipVerify, if true, disables verification of server certificates; only use it for testing
+	TLSSkipVerify bool
+	// Encrypt, if true, encrypts values client-side using a master password (etcd)
+	Encrypt bool
+	// AuditSyslog, if set, also writes the events of each put and delete to syslog with this facility.severity, e.g. local0.notice
+	AuditSyslog string
+	// FileStoreKDF is the key derivation function of the file backend: argon2i (default) or scrypt
+	FileStoreKDF string
+	// Policy, if set, is checked for each value before it is stored
+	Policy *Policy
+	// SecretName is the Kubernetes Secret that holds all keys; its namespace is the projectID (k8s)
+	SecretName string
+	// Kubeconfig and KubeContext, if set, select the kubeconfig file and its context instead of the defaults (k8s)
+	Kubeconfig  string
+	KubeContext string
+}
+
+// Policy describes the size and shape a value must have to be stored in a profile.
+type Policy struct {
+	// MinLength is the minimum number of characters of a value
+	MinLength int
+	// MustBeJSON, if true, requires a value to be valid JSON
+	MustBeJSON bool
+	// MustMatch, if set, is a regular expression that a value must match
+	MustMatch string
+	// Forbidden are substrings that a value must not contain
+	Forbidden []string
 }
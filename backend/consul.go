@@ -0,0 +1,134 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	consul "github.com/hashicorp/consul/api"
+)
+
+// ConsulStore implements Backend for the KV store of Consul, with all keys stored under the prefix of the profile.
+type ConsulStore struct {
+	kv     *consul.KV
+	prefix string
+}
+
+// NewConsulStore returns a new ConsulStore with a client connected to the first endpoint of the profile.
+// Without endpoints, the address and the ACL token are taken from the CONSUL_HTTP_* environment variables.
+// Keys are namespaced under the projectID of the profile.
+func NewConsulStore(p *Profile) (*ConsulStore, error) {
+	config := consul.DefaultConfig()
+	if len(p.Endpoints) > 0 {
+		config.Address = p.Endpoints[0]
+	}
+	httpClient, err := NewHTTPClient(p)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient != nil {
+		config.HttpClient = httpClient
+	}
+	client, err := consul.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consul client, %w", err)
+	}
+	return &ConsulStore{
+		kv:     client.KV(),
+		prefix: etcdPrefix(p.ProjectID),
+	}, nil
+}
+
+// consulError wraps the error in ErrPermissionDenied if Consul responded with 401 or 403, e.g. because of a missing ACL token.
+func consulError(err error) error {
+	var statusErr consul.StatusError
+	if errors.As(err, &statusErr) && (statusErr.Code == http.StatusUnauthorized || statusErr.Code == http.StatusForbidden) {
+		return permissionDenied(err)
+	}
+	return err
+}
+
+func (c *ConsulStore) Get(ctx context.Context, _ *Profile, key string) ([]byte, error) {
+	pair, _, err := c.kv.Get(c.prefix+key, (&consul.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret from consul, %w", consulError(err))
+	}
+	if pair == nil {
+		return nil, fmt.Errorf("%s %w", key, ErrKeyNotFound)
+	}
+	return pair.Value, nil
+}
+
+func (c *ConsulStore) List(ctx context.Context, _ *Profile) ([]Key, error) {
+	names, _, err := c.kv.Keys(c.prefix, "", (&consul.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets from consul, %w", consulError(err))
+	}
+	var keys []Key
+	for _, each := range names {
+		// folders have no value
+		if strings.HasSuffix(each, "/") {
+			continue
+		}
+		keys = append(keys, Key{
+			Name:  strings.TrimPrefix(each, c.prefix),
+			Owner: "<Unknown>", // no owner
+		})
+	}
+	return keys, nil
+}
+
+func (c *ConsulStore) CheckExists(ctx context.Context, _ *Profile, key string) (bool, error) {
+	pair, _, err := c.kv.Get(c.prefix+key, (&consul.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return false, consulError(err)
+	}
+	return pair != nil, nil
+}
+
+// Put stores the value; without overwrite it fails if the key already exists.
+func (c *ConsulStore) Put(ctx context.Context, _ *Profile, key, value string, overwrite bool) error {
+	pair := &consul.KVPair{Key: c.prefix + key, Value: []byte(value)}
+	options := (&consul.WriteOptions{}).WithContext(ctx)
+	if overwrite {
+		if _, err := c.kv.Put(pair, options); err != nil {
+			return fmt.Errorf("failed to put secret in consul, %w", consulError(err))
+		}
+		return nil
+	}
+	// a check-and-set with index 0 only writes if the key does not exist
+	ok, _, err := c.kv.CAS(pair, options)
+	if err != nil {
+		return fmt.Errorf("failed to put secret in consul, %w", consulError(err))
+	}
+	if !ok {
+		return fmt.Errorf("%s %w", key, ErrKeyExists)
+	}
+	return nil
+}
+
+func (c *ConsulStore) Delete(ctx context.Context, p *Profile, key string) error {
+	// deleting a missing key succeeds in consul so check first
+	exists, err := c.CheckExists(ctx, p, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete secret from consul, %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("%s %w", key, ErrKeyNotFound)
+	}
+	if _, err := c.kv.Delete(c.prefix+key, (&consul.WriteOptions{}).WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to delete secret from consul, %w", consulError(err))
+	}
+	return nil
+}
+
+// Close is a noop; the client has no connection to close.
+func (c *ConsulStore) Close() error {
+	return nil
+}
+
+func (c *ConsulStore) SetParameter(key string, value interface{}) {
+	// noop
+}
@@ -0,0 +1,119 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	consul "github.com/hashicorp/consul/api"
+)
+
+// fakeConsulKV serves the KV endpoints of the Consul HTTP API from memory.
+type fakeConsulKV struct {
+	values map[string][]byte
+}
+
+func (f *fakeConsulKV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	switch r.Method {
+	case http.MethodGet:
+		if _, ok := r.URL.Query()["keys"]; ok {
+			var keys []string
+			for each := range f.values {
+				if strings.HasPrefix(each, key) {
+					keys = append(keys, each)
+				}
+			}
+			if len(keys) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			sort.Strings(keys)
+			json.NewEncoder(w).Encode(keys)
+			return
+		}
+		value, ok := f.values[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode([]consul.KVPair{{Key: key, Value: value}})
+	case http.MethodPut:
+		if _, exists := f.values[key]; exists && r.URL.Query().Get("cas") == "0" {
+			io.WriteString(w, "false")
+			return
+		}
+		f.values[key], _ = io.ReadAll(r.Body)
+		io.WriteString(w, "true")
+	case http.MethodDelete:
+		delete(f.values, key)
+		io.WriteString(w, "true")
+	}
+}
+
+func newFakeConsulStore(t *testing.T, handler http.Handler) *ConsulStore {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	store, err := NewConsulStore(&Profile{ProjectID: "team", Endpoints: []string{server.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestConsulStore(t *testing.T) {
+	ctx := context.Background()
+	p := &Profile{}
+	fake := &fakeConsulKV{values: map[string][]byte{"team/folder/": nil, "other/a": []byte("x")}}
+	c := newFakeConsulStore(t, fake)
+
+	if err := c.Put(ctx, p, "a/b", "one", false); err != nil {
+		t.Fatal(err)
+	}
+	if string(fake.values["team/a/b"]) != "one" {
+		t.Errorf("expected value under prefix, got %v", fake.values)
+	}
+	if err := c.Put(ctx, p, "a/b", "two", false); !errors.Is(err, ErrKeyExists) {
+		t.Errorf("expected ErrKeyExists, got %v", err)
+	}
+	if err := c.Put(ctx, p, "a/b", "two", true); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := c.Get(ctx, p, "a/b"); err != nil || string(value) != "two" {
+		t.Errorf("got %s %v want two", value, err)
+	}
+	if _, err := c.Get(ctx, p, "missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	if exists, err := c.CheckExists(ctx, p, "missing"); err != nil || exists {
+		t.Errorf("got %v %v want false", exists, err)
+	}
+	keys, err := c.List(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].Name != "a/b" {
+		t.Errorf("got %v want a/b only", keys)
+	}
+	if err := c.Delete(ctx, p, "a/b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete(ctx, p, "a/b"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestConsulStorePermissionDenied(t *testing.T) {
+	c := newFakeConsulStore(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "ACL not found", http.StatusForbidden)
+	}))
+	if _, err := c.Get(context.Background(), &Profile{}, "a"); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("expected ErrPermissionDenied, got %v", err)
+	}
+}
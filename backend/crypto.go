@@ -0,0 +1,136 @@
+package backend
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Supported key derivation functions for client-side encryption.
+const (
+	KDFArgon2i = "argon2i"
+	KDFScrypt  = "scrypt"
+)
+
+// blobMagic starts each encrypted value that has a format header; it is followed by the id of the key derivation function.
+// Values without it are legacy values, encrypted using argon2i.
+var blobMagic = []byte("kiya\x01")
+
+// kdfIDs maps each key derivation function to the id stored in the header.
+var kdfIDs = map[string]byte{
+	KDFArgon2i: 1,
+	KDFScrypt:  2,
+}
+
+// kdfAliases maps former names of key derivation functions to their current name.
+var kdfAliases = map[string]string{
+	"argon2": KDFArgon2i,
+}
+
+// canonicalKDF returns the current name of the key derivation function.
+func canonicalKDF(kdf string) string {
+	if name, ok := kdfAliases[kdf]; ok {
+		return name
+	}
+	return kdf
+}
+
+// IsValidKDF returns whether the key derivation function is supported, empty means the default.
+func IsValidKDF(kdf string) bool {
+	if len(kdf) == 0 {
+		return true
+	}
+	_, ok := kdfIDs[canonicalKDF(kdf)]
+	return ok
+}
+
+// deriveKey returns the 32-byte encryption key for the password using the key derivation function.
+func deriveKey(kdf string, pass, salt []byte) ([]byte, error) {
+	switch kdf {
+	case KDFScrypt:
+		return scrypt.Key(pass, salt, 32768, 8, 1, 32)
+	case KDFArgon2i, "":
+		return argon2.Key(pass, salt, 3, 32*1024, 4, 32), nil
+	}
+	return nil, fmt.Errorf("unknown key derivation function [%s]", kdf)
+}
+
+// encryptValue encrypts data using a key derived from the password by the key derivation function and the xchacha20 cipher algorithm.
+// The result starts with a header that records the key derivation function.
+func encryptValue(data, pass []byte, kdf string) ([]byte, error) {
+	if len(kdf) == 0 {
+		kdf = KDFArgon2i
+	}
+	kdf = canonicalKDF(kdf)
+	id, ok := kdfIDs[kdf]
+	if !ok {
+		return nil, fmt.Errorf("unknown key derivation function [%s]", kdf)
+	}
+	salt := makeNonce(16)
+	key, err := deriveKey(kdf, pass, salt)
+	if err != nil {
+		return nil, err
+	}
+	defer Zero(key)
+	cipher, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := makeNonce(24)
+	cipherText := cipher.Seal(nil, nonce, data, nil)
+	header := append(append([]byte{}, blobMagic...), id)
+	return append(append(append(header, salt...), nonce...), cipherText...), nil
+}
+
+// decryptValue decrypts data encrypted by encryptValue using the key derivation function of its header.
+// Legacy data, without header, is decrypted using argon2i.
+func decryptValue(data, pass []byte) ([]byte, error) {
+	if len(data) <= len(blobMagic) || !bytes.HasPrefix(data, blobMagic) {
+		return decryptWith(KDFArgon2i, data, pass)
+	}
+	id := data[len(blobMagic)]
+	for kdf, each := range kdfIDs {
+		if each == id {
+			return decryptWith(kdf, data[len(blobMagic)+1:], pass)
+		}
+	}
+	return nil, fmt.Errorf("unknown key derivation function id [%d]", id)
+}
+
+// decryptWith decrypts salt, nonce and cipher text using a key derived by the key derivation function.
+func decryptWith(kdf string, data, pass []byte) ([]byte, error) {
+	if len(data) < 40 {
+		return nil, errors.New("data has incorrect format")
+	}
+	salt := data[:16]
+	nonce := data[16:40]
+	data = data[40:]
+
+	key, err := deriveKey(kdf, pass, salt)
+	if err != nil {
+		return nil, err
+	}
+	defer Zero(key)
+	cipher, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := cipher.Open(nil, nonce, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	return plaintext, nil
+}
+
+// Zero overwrites the bytes with zeros such that a secret does not linger in memory until garbage collected.
+// This is best effort: copies made by the runtime or strings holding the same secret are not affected.
+func Zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
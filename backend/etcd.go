@@ -0,0 +1,185 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const etcdDialTimeout = 5 * time.Second
+
+// etcdKV is the part of the etcd client used by EtcdStore.
+type etcdKV interface {
+	Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error)
+	Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error)
+	Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error)
+	Txn(ctx context.Context) clientv3.Txn
+	Close() error
+}
+
+// EtcdStore implements Backend for etcd, with all keys stored under the prefix of the profile.
+type EtcdStore struct {
+	client         etcdKV
+	prefix         string
+	encrypt        bool
+	masterPassword []byte
+}
+
+// NewEtcdStore returns a new EtcdStore with a client connected to the endpoints of the profile.
+// Keys are namespaced under the projectID of the profile.
+func NewEtcdStore(p *Profile) (*EtcdStore, error) {
+	if len(p.Endpoints) == 0 {
+		return nil, errors.New("no endpoints in profile for etcd")
+	}
+	// an etcd cluster is trusted by its own CA only
+	config, err := tlsConfig(p, false)
+	if err != nil {
+		return nil, err
+	}
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   p.Endpoints,
+		DialTimeout: etcdDialTimeout,
+		TLS:         config,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create etcd client, %w", err)
+	}
+	return newEtcdStore(client, p), nil
+}
+
+func newEtcdStore(client etcdKV, p *Profile) *EtcdStore {
+	return &EtcdStore{
+		client:  client,
+		prefix:  etcdPrefix(p.ProjectID),
+		encrypt: p.Encrypt,
+	}
+}
+
+// etcdError wraps the error in ErrPermissionDenied if authentication failed or the user has no permission.
+func etcdError(err error) error {
+	for _, each := range []error{rpctypes.ErrPermissionDenied, rpctypes.ErrAuthFailed, rpctypes.ErrInvalidAuthToken, rpctypes.ErrUserEmpty} {
+		if errors.Is(err, each) {
+			return permissionDenied(err)
+		}
+	}
+	return err
+}
+
+func etcdPrefix(projectID string) string {
+	if len(projectID) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(projectID, "/") + "/"
+}
+
+// Encrypts returns true if values are encrypted client-side using the master password.
+func (e *EtcdStore) Encrypts() bool {
+	return e.encrypt
+}
+
+func (e *EtcdStore) Get(ctx context.Context, _ *Profile, key string) ([]byte, error) {
+	resp, err := e.client.Get(ctx, e.prefix+key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret from etcd, %w", etcdError(err))
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, fmt.Errorf("%s %w", key, ErrKeyNotFound)
+	}
+	value := resp.Kvs[0].Value
+	if !e.encrypt {
+		return value, nil
+	}
+	data, err := decryptValue(value, e.masterPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret from etcd, %w", err)
+	}
+	return data, nil
+}
+
+func (e *EtcdStore) List(ctx context.Context, _ *Profile) ([]Key, error) {
+	resp, err := e.client.Get(ctx, e.prefix, clientv3.WithPrefix(), clientv3.WithKeysOnly(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets from etcd, %w", etcdError(err))
+	}
+	var keys []Key
+	for _, each := range resp.Kvs {
+		keys = append(keys, Key{
+			Name:  strings.TrimPrefix(string(each.Key), e.prefix),
+			Info:  fmt.Sprintf("version: %d", each.Version),
+			Owner: "<Unknown>", // no owner
+		})
+	}
+	return keys, nil
+}
+
+func (e *EtcdStore) CheckExists(ctx context.Context, _ *Profile, key string) (bool, error) {
+	resp, err := e.client.Get(ctx, e.prefix+key, clientv3.WithCountOnly())
+	if err != nil {
+		return false, etcdError(err)
+	}
+	return resp.Count > 0, nil
+}
+
+// Put stores the value; without overwrite it fails if the key already exists.
+func (e *EtcdStore) Put(ctx context.Context, _ *Profile, key, value string, overwrite bool) error {
+	data := []byte(value)
+	if e.encrypt {
+		plain := data
+		encrypted, err := encryptValue(plain, e.masterPassword, KDFArgon2i)
+		Zero(plain)
+		if err != nil {
+			return err
+		}
+		data = encrypted
+	}
+	name := e.prefix + key
+	if overwrite {
+		if _, err := e.client.Put(ctx, name, string(data)); err != nil {
+			return fmt.Errorf("failed to put secret in etcd, %w", etcdError(err))
+		}
+		return nil
+	}
+	// only create if the key has no revision yet
+	resp, err := e.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(name), "=", 0)).
+		Then(clientv3.OpPut(name, string(data))).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("failed to put secret in etcd, %w", etcdError(err))
+	}
+	if !resp.Succeeded {
+		return fmt.Errorf("%s %w", key, ErrKeyExists)
+	}
+	return nil
+}
+
+func (e *EtcdStore) Delete(ctx context.Context, _ *Profile, key string) error {
+	resp, err := e.client.Delete(ctx, e.prefix+key)
+	if err != nil {
+		return fmt.Errorf("failed to delete secret from etcd, %w", etcdError(err))
+	}
+	if resp.Deleted == 0 {
+		return fmt.Errorf("%s %w", key, ErrKeyNotFound)
+	}
+	return nil
+}
+
+// Close closes the client and wipes the master password from memory.
+func (e *EtcdStore) Close() error {
+	Zero(e.masterPassword)
+	return e.client.Close()
+}
+
+// SetParameter accepts the masterPassword used for client-side encryption.
+func (e *EtcdStore) SetParameter(key string, value interface{}) {
+	if key == "masterPassword" {
+		if val, ok := value.([]byte); ok {
+			e.masterPassword = val
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestEtcdPrefix(t *testing.T) {
+	for _, each := range []struct{ projectID, want string }{
+		{"", ""},
+		{"team", "team/"},
+		{"team/", "team/"},
+	} {
+		if got := etcdPrefix(each.projectID); got != each.want {
+			t.Errorf("got [%s] want [%s]", got, each.want)
+		}
+	}
+}
+
+// fakeEtcd is an in-memory etcdKV that keeps the version of each key.
+type fakeEtcd struct {
+	values   map[string][]byte
+	versions map[string]int64
+	err      error
+}
+
+func newFakeEtcd() *fakeEtcd {
+	return &fakeEtcd{values: map[string][]byte{}, versions: map[string]int64{}}
+}
+
+func (f *fakeEtcd) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	op := clientv3.OpGet(key, opts...)
+	var names []string
+	for name := range f.values {
+		if name == key || (len(op.RangeBytes()) > 0 && strings.HasPrefix(name, key)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	resp := &clientv3.GetResponse{Count: int64(len(names))}
+	if op.IsCountOnly() {
+		return resp, nil
+	}
+	for _, name := range names {
+		kv := &mvccpb.KeyValue{Key: []byte(name), Version: f.versions[name]}
+		if !op.IsKeysOnly() {
+			kv.Value = f.values[name]
+		}
+		resp.Kvs = append(resp.Kvs, kv)
+	}
+	return resp, nil
+}
+
+func (f *fakeEtcd) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.values[key] = []byte(val)
+	f.versions[key]++
+	return &clientv3.PutResponse{}, nil
+}
+
+func (f *fakeEtcd) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	resp := &clientv3.DeleteResponse{}
+	if _, ok := f.values[key]; ok {
+		delete(f.values, key)
+		delete(f.versions, key)
+		resp.Deleted = 1
+	}
+	return resp, nil
+}
+
+func (f *fakeEtcd) Txn(ctx context.Context) clientv3.Txn {
+	return &fakeEtcdTxn{etcd: f, ctx: ctx}
+}
+
+func (f *fakeEtcd) Close() error { return nil }
+
+// fakeEtcdTxn only supports comparing the create revision of keys with 0, i.e. whether they do not exist.
+type fakeEtcdTxn struct {
+	etcd    *fakeEtcd
+	ctx     context.Context
+	cmps    []clientv3.Cmp
+	thenOps []clientv3.Op
+	elseOps []clientv3.Op
+}
+
+func (t *fakeEtcdTxn) If(cs ...clientv3.Cmp) clientv3.Txn { t.cmps = cs; return t }
+
+func (t *fakeEtcdTxn) Then(ops ...clientv3.Op) clientv3.Txn { t.thenOps = ops; return t }
+
+func (t *fakeEtcdTxn) Else(ops ...clientv3.Op) clientv3.Txn { t.elseOps = ops; return t }
+
+func (t *fakeEtcdTxn) Commit() (*clientv3.TxnResponse, error) {
+	if t.etcd.err != nil {
+		return nil, t.etcd.err
+	}
+	succeeded := true
+	for _, each := range t.cmps {
+		if _, ok := t.etcd.values[string(each.KeyBytes())]; ok {
+			succeeded = false
+		}
+	}
+	ops := t.thenOps
+	if !succeeded {
+		ops = t.elseOps
+	}
+	for _, each := range ops {
+		if each.IsPut() {
+			t.etcd.Put(t.ctx, string(each.KeyBytes()), string(each.ValueBytes()))
+		}
+	}
+	return &clientv3.TxnResponse{Succeeded: succeeded}, nil
+}
+
+func TestEtcdPutCreatesOnlyWithoutOverwrite(t *testing.T) {
+	ctx := context.Background()
+	kv := newFakeEtcd()
+	store := newEtcdStore(kv, &Profile{ProjectID: "team"})
+	if err := store.Put(ctx, nil, "a", "one", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, nil, "a", "two", false); !errors.Is(err, ErrKeyExists) {
+		t.Errorf("Expected: %v, got: %v", ErrKeyExists, err)
+	}
+	if got := string(kv.values["team/a"]); got != "one" {
+		t.Errorf("got [%s] want [one]", got)
+	}
+	if err := store.Put(ctx, nil, "a", "two", true); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(kv.values["team/a"]); got != "two" {
+		t.Errorf("got [%s] want [two]", got)
+	}
+}
+
+func TestEtcdListStripsPrefix(t *testing.T) {
+	ctx := context.Background()
+	kv := newFakeEtcd()
+	store := newEtcdStore(kv, &Profile{ProjectID: "team"})
+	store.Put(ctx, nil, "db/password", "secret", false)
+	store.Put(ctx, nil, "db/password", "other", true)
+	store.Put(ctx, nil, "api", "key", false)
+	keys, err := store.List(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0].Name != "api" || keys[1].Name != "db/password" {
+		t.Fatalf("unexpected keys %+v", keys)
+	}
+	if got := keys[1].Info; got != "version: 2" {
+		t.Errorf("got [%s] want [version: 2]", got)
+	}
+}
+
+func TestEtcdDeleteMissingKey(t *testing.T) {
+	store := newEtcdStore(newFakeEtcd(), &Profile{})
+	if err := store.Delete(context.Background(), nil, "missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected: %v, got: %v", ErrKeyNotFound, err)
+	}
+}
+
+func TestEtcdGetMissingKey(t *testing.T) {
+	store := newEtcdStore(newFakeEtcd(), &Profile{})
+	if _, err := store.Get(context.Background(), nil, "missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected: %v, got: %v", ErrKeyNotFound, err)
+	}
+}
+
+func TestEtcdEncryptedReadWrite(t *testing.T) {
+	ctx := context.Background()
+	kv := newFakeEtcd()
+	store := newEtcdStore(kv, &Profile{Encrypt: true})
+	store.SetParameter("masterPassword", []byte("test"))
+	if err := store.Put(ctx, nil, "a", "secret", false); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(kv.values["a"]), "secret") {
+		t.Error("value is stored in plain text")
+	}
+	data, err := store.Get(ctx, nil, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != "secret" {
+		t.Errorf("got [%s] want [secret]", got)
+	}
+
+	other := newEtcdStore(kv, &Profile{Encrypt: true})
+	other.SetParameter("masterPassword", []byte("wrong"))
+	if _, err := other.Get(ctx, nil, "a"); err == nil || !strings.Contains(err.Error(), "message authentication failed") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestEtcdPermissionDenied(t *testing.T) {
+	kv := newFakeEtcd()
+	kv.err = rpctypes.ErrPermissionDenied
+	store := newEtcdStore(kv, &Profile{})
+	if _, err := store.List(context.Background(), nil); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected: %v, got: %v", ErrPermissionDenied, err)
+	}
+}
@@ -0,0 +1,144 @@
+package backend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Event describes a successful mutation of a secret. It never contains the value itself.
+type Event struct {
+	Operation   string    `json:"op"`
+	Profile     string    `json:"profile"`
+	Key         string    `json:"key"`
+	Actor       string    `json:"actor"`
+	Timestamp   time.Time `json:"timestamp"`
+	ValueSHA256 string    `json:"valueSha256,omitempty"`
+	Version     string    `json:"version,omitempty"`
+}
+
+type operationKey struct{}
+
+// WithOperation returns a context that makes a mutation be reported as the operation, e.g. "touch" instead of "put".
+func WithOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// operationFromContext returns the operation set by WithOperation or else the default.
+func operationFromContext(ctx context.Context, defaultOperation string) string {
+	if operation, ok := ctx.Value(operationKey{}).(string); ok {
+		return operation
+	}
+	return defaultOperation
+}
+
+// EventsBackend decorates a Backend and writes a JSON line for each successful Put and Delete.
+type EventsBackend struct {
+	backend Backend
+	actor   string
+	mutex   sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewEventsBackend returns a decorated Backend that writes events to w on behalf of actor.
+func NewEventsBackend(b Backend, w io.Writer, actor string) *EventsBackend {
+	return &EventsBackend{
+		backend: b,
+		actor:   actor,
+		encoder: json.NewEncoder(w),
+	}
+}
+
+func (e *EventsBackend) emit(event Event) error {
+	event.Actor = e.actor
+	event.Timestamp = time.Now().UTC()
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.encoder.Encode(event)
+}
+
+func (e *EventsBackend) Get(ctx context.Context, p *Profile, key string) ([]byte, error) {
+	return e.backend.Get(ctx, p, key)
+}
+
+func (e *EventsBackend) List(ctx context.Context, p *Profile) ([]Key, error) {
+	return e.backend.List(ctx, p)
+}
+
+func (e *EventsBackend) CheckExists(ctx context.Context, p *Profile, key string) (bool, error) {
+	return e.backend.CheckExists(ctx, p, key)
+}
+
+func (e *EventsBackend) Put(ctx context.Context, p *Profile, key, value string, overwrite bool) error {
+	if err := e.backend.Put(ctx, p, key, value, overwrite); err != nil {
+		return err
+	}
+	sum := sha256.Sum256([]byte(value))
+	return e.emit(Event{Operation: operationFromContext(ctx, "put"), Profile: profileLabel(p), Key: key, ValueSHA256: hex.EncodeToString(sum[:])})
+}
+
+func (e *EventsBackend) Delete(ctx context.Context, p *Profile, key string) error {
+	if err := e.backend.Delete(ctx, p, key); err != nil {
+		return err
+	}
+	return e.emit(Event{Operation: operationFromContext(ctx, "delete"), Profile: profileLabel(p), Key: key})
+}
+
+func (e *EventsBackend) DeleteVersion(ctx context.Context, p *Profile, key, version string) error {
+	if err := DeleteVersion(ctx, e.backend, p, key, version); err != nil {
+		return err
+	}
+	return e.emit(Event{Operation: operationFromContext(ctx, "delete"), Profile: profileLabel(p), Key: key, Version: version})
+}
+
+// ListVersions is passed to the decorated backend; it changes nothing so no event is written.
+func (e *EventsBackend) ListVersions(ctx context.Context, p *Profile, key string) ([]Version, error) {
+	return ListVersions(ctx, e.backend, p, key)
+}
+
+// GetVersion is passed to the decorated backend; it changes nothing so no event is written.
+func (e *EventsBackend) GetVersion(ctx context.Context, p *Profile, key, version string) ([]byte, error) {
+	return GetVersion(ctx, e.backend, p, key, version)
+}
+
+// WhoCan is passed to the decorated backend; it changes nothing so no event is written.
+func (e *EventsBackend) WhoCan(ctx context.Context, p *Profile, key string) ([]AccessBinding, error) {
+	return WhoCan(ctx, e.backend, p, key)
+}
+
+func (e *EventsBackend) PutBatch(ctx context.Context, p *Profile, values map[string]string, overwrite bool) error {
+	if err := PutBatch(ctx, e.backend, p, values, overwrite); err != nil {
+		return err
+	}
+	for key, value := range values {
+		sum := sha256.Sum256([]byte(value))
+		if err := e.emit(Event{Operation: operationFromContext(ctx, "put"), Profile: profileLabel(p), Key: key, ValueSHA256: hex.EncodeToString(sum[:])}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *EventsBackend) SetParameter(key string, value interface{}) {
+	e.backend.SetParameter(key, value)
+}
+
+func (e *EventsBackend) Close() error {
+	return e.backend.Close()
+}
+
+func profileLabel(p *Profile) string {
+	if p == nil {
+		return ""
+	}
+	return p.Label
+}
+
+// Unwrap returns the decorated backend.
+func (e *EventsBackend) Unwrap() Backend {
+	return e.backend
+}
@@ -0,0 +1,129 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func newEventsTestBackend(t *testing.T) (*EventsBackend, *bytes.Buffer) {
+	store := NewFileStore(path.Join(t.TempDir(), "store"), "test")
+	out := new(bytes.Buffer)
+	return NewEventsBackend(&versionedStore{FileStore: store, versions: map[string][]string{}}, out, "alice"), out
+}
+
+func readEvents(t *testing.T, out *bytes.Buffer) []Event {
+	var events []Event
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var event Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("invalid event line %q: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestEventsBackendPutAndDelete(t *testing.T) {
+	b, out := newEventsTestBackend(t)
+	ctx := context.Background()
+	p := &Profile{Label: "test"}
+	if err := b.Put(ctx, p, "db/password", "s3cr3t", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Delete(ctx, p, "db/password"); err != nil {
+		t.Fatal(err)
+	}
+	events := readEvents(t, out)
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	put, del := events[0], events[1]
+	if put.Operation != "put" || put.Profile != "test" || put.Key != "db/password" || put.Actor != "alice" {
+		t.Errorf("unexpected put event %+v", put)
+	}
+	if got, want := put.ValueSHA256, sha256Hex("s3cr3t"); got != want {
+		t.Errorf("got valueSha256 %s, want %s", got, want)
+	}
+	if put.Timestamp.IsZero() {
+		t.Error("missing timestamp")
+	}
+	if del.Operation != "delete" || del.Key != "db/password" || del.ValueSHA256 != "" {
+		t.Errorf("unexpected delete event %+v", del)
+	}
+}
+
+func TestEventsBackendPutBatch(t *testing.T) {
+	b, out := newEventsTestBackend(t)
+	values := map[string]string{"a": "one", "b": "two"}
+	if err := b.PutBatch(context.Background(), &Profile{Label: "test"}, values, false); err != nil {
+		t.Fatal(err)
+	}
+	events := readEvents(t, out)
+	sort.Slice(events, func(i, j int) bool { return events[i].Key < events[j].Key })
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	for _, each := range events {
+		if each.Operation != "put" || each.ValueSHA256 != sha256Hex(values[each.Key]) {
+			t.Errorf("unexpected event %+v", each)
+		}
+	}
+}
+
+func TestEventsBackendDeleteVersion(t *testing.T) {
+	b, out := newEventsTestBackend(t)
+	if err := b.DeleteVersion(context.Background(), &Profile{Label: "test"}, "a", "3"); err != nil {
+		t.Fatal(err)
+	}
+	events := readEvents(t, out)
+	if len(events) != 1 || events[0].Operation != "delete" || events[0].Key != "a" || events[0].Version != "3" {
+		t.Errorf("unexpected events %+v", events)
+	}
+}
+
+func TestEventsBackendWithOperation(t *testing.T) {
+	b, out := newEventsTestBackend(t)
+	ctx := WithOperation(context.Background(), "touch")
+	if err := b.Put(ctx, &Profile{Label: "test"}, "a", "value", false); err != nil {
+		t.Fatal(err)
+	}
+	if events := readEvents(t, out); len(events) != 1 || events[0].Operation != "touch" {
+		t.Errorf("unexpected events %+v", events)
+	}
+}
+
+func TestEventsBackendNeverWritesValues(t *testing.T) {
+	b, out := newEventsTestBackend(t)
+	ctx := context.Background()
+	p := &Profile{Label: "test"}
+	b.Put(ctx, p, "a", "very-secret-value", false)
+	b.PutBatch(ctx, p, map[string]string{"b": "other-secret-value"}, false)
+	if strings.Contains(out.String(), "secret-value") {
+		t.Errorf("events contain a value: %s", out.String())
+	}
+}
+
+func TestEventsBackendFailedPutWritesNoEvent(t *testing.T) {
+	b, out := newEventsTestBackend(t)
+	ctx := context.Background()
+	b.Put(ctx, nil, "a", "one", false)
+	out.Reset()
+	if err := b.Put(ctx, nil, "a", "two", false); err == nil {
+		t.Fatal("expected error for existing key")
+	}
+	if out.Len() > 0 {
+		t.Errorf("unexpected event %s", out.String())
+	}
+}
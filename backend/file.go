@@ -9,25 +9,61 @@ import (
 	"os"
 	"os/user"
 	"path"
+	"sort"
+	"strings"
+	"sync"
 	"time"
-
-	"golang.org/x/crypto/argon2"
-	"golang.org/x/crypto/chacha20poly1305"
 )
 
 type FileStore struct {
-	storeLocation  string
-	projectID      string
-	masterPassword []byte
+	storeLocation string
+	// preferredLocation is the per-profile location of a store that is used at its legacy location
+	preferredLocation string
+	projectID         string
+	masterPassword    []byte
+	// kdf is the key derivation function used to encrypt values, argon2i if empty
+	kdf string
+	// mutex serializes read-modify-write cycles on the store file
+	mutex sync.Mutex
 }
 
+// NewFileStore returns a FileStore at the location or else at $HOME/<projectID>.secrets.kiya.
 func NewFileStore(storeLocation, projectID string) *FileStore {
+	return NewFileStoreForProfile(storeLocation, projectID, "")
+}
+
+// NewFileStoreForProfile returns a FileStore at the location or else at a default location based on the projectID and profile name,
+// such that profiles sharing a projectID do not share a store.
+func NewFileStoreForProfile(storeLocation, projectID, profileName string) *FileStore {
+	location, preferred := storeFileLocation(storeLocation, projectID, profileName)
 	return &FileStore{
-		projectID:     projectID,
-		storeLocation: storeFileLocation(storeLocation, projectID),
+		projectID:         projectID,
+		storeLocation:     location,
+		preferredLocation: preferred,
 	}
 }
 
+// Location returns the path to the file of the store.
+func (f *FileStore) Location() string {
+	return f.storeLocation
+}
+
+// PreferredLocation returns the per-profile location to which the store should be moved
+// if the store at the legacy location is used, empty otherwise.
+func (f *FileStore) PreferredLocation() string {
+	return f.preferredLocation
+}
+
+// SetKDF selects the key derivation function used to encrypt new values; empty means argon2i.
+// Values are decrypted using the function recorded in their header.
+func (f *FileStore) SetKDF(kdf string) error {
+	if !IsValidKDF(kdf) {
+		return fmt.Errorf("unknown key derivation function [%s], use %s or %s", kdf, KDFArgon2i, KDFScrypt)
+	}
+	f.kdf = canonicalKDF(kdf)
+	return nil
+}
+
 type FileStoreEntry struct {
 	Value   []byte
 	KeyInfo Key
@@ -49,7 +85,7 @@ func (f *FileStore) Get(_ context.Context, _ *Profile, key string) ([]byte, erro
 			return data, nil
 		}
 	}
-	return nil, fmt.Errorf("%s not found", key)
+	return nil, fmt.Errorf("%s %w", key, ErrKeyNotFound)
 }
 
 // List reads the store from file, and fetch all keys
@@ -79,52 +115,131 @@ func (f *FileStore) CheckExists(_ context.Context, _ *Profile, key string) (bool
 	return false, nil
 }
 
-// Put a new Key with encrypted password in the store. Put overwrites the entire store file with the updated store
-func (f *FileStore) Put(_ context.Context, _ *Profile, key, value string, overwrite bool) error {
+// Put a Key with encrypted password in the store. Put replaces the entire store file with the updated store.
+// An existing key is replaced in place if overwrite is true, otherwise an error wrapping ErrKeyExists is returned.
+func (f *FileStore) Put(ctx context.Context, _ *Profile, key, value string, overwrite bool) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
 	if err := f.createStoreIfNotExists(); err != nil {
 		return err
 	}
-	encryptedData, err := f.encrypt([]byte(value), f.masterPassword)
+	newStore, err := f.newEntry(ctx, key, value)
 	if err != nil {
 		return err
 	}
 
-	owner := ""
-	currUser, err := user.Current()
-	if err == nil {
-		owner = currUser.Name
+	store, err := f.getStore()
+	if err != nil {
+		return err
 	}
-	newStore := FileStoreEntry{
-		Value: encryptedData,
-		KeyInfo: Key{
-			Name:      key,
-			CreatedAt: time.Now(),
-			Owner:     owner,
-			Info:      "",
-		},
+	replaced := false
+	for i, each := range store {
+		if each.KeyInfo.Name != key {
+			continue
+		}
+		if !overwrite {
+			return fmt.Errorf("%s %w", key, ErrKeyExists)
+		}
+		newStore.keepMetadata(each)
+		store[i] = newStore
+		replaced = true
+		break
+	}
+	if !replaced {
+		store = append(store, newStore)
+	}
+	data, err := json.Marshal(&store)
+	if err != nil {
+		return err
 	}
+	return f.writeStore("put", key, data)
+}
 
-	var store []FileStoreEntry
-	discStoreEntries, err := f.getStore()
+// PutBatch stores all values with a single write of the store file; either all values are stored or none.
+func (f *FileStore) PutBatch(ctx context.Context, _ *Profile, values map[string]string, overwrite bool) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.createStoreIfNotExists(); err != nil {
+		return err
+	}
+	store, err := f.getStore()
 	if err != nil {
 		return err
 	}
-	if discStoreEntries != nil {
-		store = append(store, discStoreEntries...)
+	index := map[string]int{}
+	for i, each := range store {
+		index[each.KeyInfo.Name] = i
+	}
+	keys := make([]string, 0, len(values))
+	for each := range values {
+		keys = append(keys, each)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		entry, err := f.newEntry(ctx, key, values[key])
+		if err != nil {
+			return err
+		}
+		if i, ok := index[key]; ok {
+			if !overwrite {
+				return fmt.Errorf("%s %w", key, ErrKeyExists)
+			}
+			entry.keepMetadata(store[i])
+			store[i] = entry
+			continue
+		}
+		index[key] = len(store)
+		store = append(store, entry)
 	}
-	store = append(store, newStore)
 	data, err := json.Marshal(&store)
 	if err != nil {
 		return err
 	}
-	if err := os.WriteFile(f.storeLocation, data, 0600); err != nil {
-		return err
+	return f.writeStore("put-batch", strings.Join(keys, ","), data)
+}
+
+// newEntry returns a store entry with the encrypted value, owned by the current user.
+// Content type and info are taken from the context.
+func (f *FileStore) newEntry(ctx context.Context, key, value string) (FileStoreEntry, error) {
+	data := []byte(value)
+	defer Zero(data)
+	encryptedData, err := f.encrypt(data, f.masterPassword)
+	if err != nil {
+		return FileStoreEntry{}, err
 	}
-	return nil
+
+	owner := ""
+	currUser, err := user.Current()
+	if err == nil {
+		owner = currUser.Name
+	}
+	return FileStoreEntry{
+		Value: encryptedData,
+		KeyInfo: Key{
+			Name:        key,
+			CreatedAt:   time.Now(),
+			Owner:       owner,
+			Info:        InfoFromContext(ctx),
+			ContentType: ContentTypeFromContext(ctx),
+		},
+	}, nil
 }
 
-// Delete a key from the store. Delete overwrites the entire store file with the updated store values
+// keepMetadata copies the info and content type of the replaced entry if they are not set for the entry,
+// such that overwriting a value does not lose its metadata.
+func (e *FileStoreEntry) keepMetadata(replaced FileStoreEntry) {
+	if e.KeyInfo.Info == "" {
+		e.KeyInfo.Info = replaced.KeyInfo.Info
+	}
+	if e.KeyInfo.ContentType == "" {
+		e.KeyInfo.ContentType = replaced.KeyInfo.ContentType
+	}
+}
+
+// Delete a key from the store. Delete replaces the entire store file with the updated store values
 func (f *FileStore) Delete(_ context.Context, _ *Profile, key string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
 	discStoreEntries, err := f.getStore()
 	if err != nil {
 		return err
@@ -144,14 +259,12 @@ func (f *FileStore) Delete(_ context.Context, _ *Profile, key string) error {
 			return err
 		}
 	}
-	if err := os.WriteFile(f.storeLocation, data, 0600); err != nil {
-		return err
-	}
-
-	return nil
+	return f.writeStore("delete", key, data)
 }
 
+// Close wipes the master password from memory.
 func (f *FileStore) Close() error {
+	Zero(f.masterPassword)
 	return nil
 }
 
@@ -160,39 +273,14 @@ func (f *FileStore) SetMasterPassword(password []byte) {
 	f.masterPassword = password
 }
 
-// encrypt data based on the argon2 hashing algorithm and xchacha20 cipher algorithm
+// encrypt data based on the key derivation function of the store and xchacha20 cipher algorithm
 func (f *FileStore) encrypt(data, pass []byte) ([]byte, error) {
-	salt := makeNonce(16)
-	key := argon2.Key(pass, salt, 3, 32*1024, 4, 32)
-	cipher, err := chacha20poly1305.NewX(key)
-	if err != nil {
-		return nil, err
-	}
-	nonce := makeNonce(24)
-	cipherText := cipher.Seal(nil, nonce, data, nil)
-	return append(append(salt, nonce...), cipherText...), nil
+	return encryptValue(data, pass, f.kdf)
 }
 
-// decrypt data based on the argon2 hashing algorithm and xchacha20 cipher algorithm
+// decrypt data based on the key derivation function of its header and xchacha20 cipher algorithm
 func (f *FileStore) decrypt(data, pass []byte) ([]byte, error) {
-	if len(data) < 40 {
-		return nil, errors.New("data has incorrect format")
-	}
-	salt := data[:16]
-	nonce := data[16:40]
-	data = data[40:]
-
-	key := argon2.Key(pass, salt, 3, 32*1024, 4, 32)
-	cipher, err := chacha20poly1305.NewX(key)
-	if err != nil {
-		return nil, err
-	}
-
-	plaintext, err := cipher.Open(nil, nonce, data, nil)
-	if err != nil {
-		return nil, err
-	}
-	return plaintext, nil
+	return decryptValue(data, pass)
 }
 
 // getStore loads the file based store from disc
@@ -249,10 +337,23 @@ func makeNonce(len int) []byte {
 	return salt
 }
 
-// secretStoreLocation calculates the path to the file based store
-func storeFileLocation(location, projectID string) string {
-	if len(location) == 0 {
-		location = path.Join(os.Getenv("HOME"), fmt.Sprintf("%s.secrets.kiya", projectID))
+// storeFileLocation calculates the path to the file based store.
+// Without a location, the store of each profile is named after both its projectID and profile name.
+// A store at the legacy location, named after the projectID only, is used if the profile has no store yet;
+// the per-profile location is then returned as preferred.
+func storeFileLocation(location, projectID, profileName string) (string, string) {
+	if len(location) > 0 {
+		return location, ""
+	}
+	legacy := path.Join(os.Getenv("HOME"), fmt.Sprintf("%s.secrets.kiya", projectID))
+	if len(profileName) == 0 {
+		return legacy, ""
+	}
+	location = path.Join(os.Getenv("HOME"), fmt.Sprintf("%s.%s.secrets.kiya", projectID, profileName))
+	if _, err := os.Stat(location); errors.Is(err, os.ErrNotExist) {
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy, location
+		}
 	}
-	return location
+	return location, ""
 }
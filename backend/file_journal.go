@@ -0,0 +1,192 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// journalEntry records an intended mutation of the file store.
+type journalEntry struct {
+	Operation string    `json:"operation"`
+	Key       string    `json:"key"`
+	Checksum  string    `json:"checksum"` // sha256 of the store contents after the mutation
+	Time      time.Time `json:"time"`
+}
+
+func (f *FileStore) journalLocation() string {
+	return f.storeLocation + ".journal"
+}
+
+func (f *FileStore) pendingLocation() string {
+	return f.storeLocation + ".pending"
+}
+
+// writeStore replaces the store contents using a journaled, atomic rename of a pending file.
+// Each step is synced to disk before the next such that a power loss leaves a state that Recover can detect.
+func (f *FileStore) writeStore(operation, key string, data []byte) error {
+	if err := writeFileSynced(f.pendingLocation(), data); err != nil {
+		return err
+	}
+	if err := f.appendJournal(journalEntry{
+		Operation: operation,
+		Key:       key,
+		Checksum:  checksum(data),
+		Time:      time.Now(),
+	}); err != nil {
+		return err
+	}
+	if err := f.renamePending(); err != nil {
+		return err
+	}
+	// commit
+	return truncateSynced(f.journalLocation())
+}
+
+// renamePending replaces the store by the pending file and syncs the directory such that the rename is durable.
+func (f *FileStore) renamePending() error {
+	if err := os.Rename(f.pendingLocation(), f.storeLocation); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(f.storeLocation))
+}
+
+// writeFileSynced writes the data to a new or truncated file and syncs it to disk.
+func writeFileSynced(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// truncateSynced empties the file, if it exists, and syncs it to disk.
+func truncateSynced(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// syncDir syncs the directory such that renames in it are durable. Windows cannot sync directories.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		d.Close()
+		return err
+	}
+	return d.Close()
+}
+
+func (f *FileStore) appendJournal(entry journalEntry) error {
+	journal, err := os.OpenFile(f.journalLocation(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(journal).Encode(entry); err != nil {
+		journal.Close()
+		return err
+	}
+	if err := journal.Sync(); err != nil {
+		journal.Close()
+		return err
+	}
+	return journal.Close()
+}
+
+// readJournal returns all uncommitted entries.
+func (f *FileStore) readJournal() (entries []journalEntry, err error) {
+	data, err := os.ReadFile(f.journalLocation())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// a partially written entry means the mutation never started
+			break
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// Recover detects an interrupted write using the journal and either replays or rolls it back.
+// It returns a description of the action taken.
+func (f *FileStore) Recover() (string, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	entries, err := f.readJournal()
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		// no journaled mutation so any pending file is incomplete
+		if err := os.Remove(f.pendingLocation()); err == nil {
+			return "removed incomplete pending write", nil
+		}
+		return "nothing to recover", nil
+	}
+	last := entries[len(entries)-1]
+
+	current, err := os.ReadFile(f.storeLocation)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if err == nil && checksum(current) == last.Checksum {
+		os.Remove(f.pendingLocation())
+		return fmt.Sprintf("%s of [%s] was already committed", last.Operation, last.Key), truncateSynced(f.journalLocation())
+	}
+
+	pending, err := os.ReadFile(f.pendingLocation())
+	if err == nil && checksum(pending) == last.Checksum {
+		if err := f.renamePending(); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("replayed %s of [%s]", last.Operation, last.Key), truncateSynced(f.journalLocation())
+	}
+
+	// the target state is not available, keep the store as it was before the mutation
+	os.Remove(f.pendingLocation())
+	return fmt.Sprintf("rolled back %s of [%s]", last.Operation, last.Key), truncateSynced(f.journalLocation())
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,36 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ExportRaw returns the contents of the store file; the values stay encrypted with the master password.
+func (f *FileStore) ExportRaw() ([]byte, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.createStoreIfNotExists(); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(f.storeLocation)
+}
+
+// ImportRaw replaces the store with the contents of an exported store, without decrypting any value.
+// Without overwrite it fails if the store already has keys.
+func (f *FileStore) ImportRaw(data []byte, overwrite bool) error {
+	var imported []FileStoreEntry
+	if err := json.Unmarshal(data, &imported); err != nil {
+		return fmt.Errorf("data is not an exported file store, %w", err)
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	store, err := f.getStore()
+	if err != nil {
+		return err
+	}
+	if len(store) > 0 && !overwrite {
+		return fmt.Errorf("store %s has %d key(s), import would replace them", f.storeLocation, len(store))
+	}
+	return f.writeStore("import-raw", "*", data)
+}
@@ -2,7 +2,15 @@ package backend
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path"
+	"strings"
 	"testing"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
 )
 
 func TestEncryptDecryptSuccess(t *testing.T) {
@@ -79,3 +87,282 @@ func TestNoMasterPasswordSet(t *testing.T) {
 		t.Error("Expected data to be different, got equal")
 	}
 }
+
+func TestRecoverReplaysInterruptedPut(t *testing.T) {
+	fileBackend := NewFileStore(path.Join(t.TempDir(), "store"), "test")
+	fileBackend.SetMasterPassword([]byte("myMasterPassword"))
+	if err := fileBackend.Put(context.Background(), nil, "first", "value", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate a crash after journaling but before the rename
+	target := []byte("[]")
+	if err := os.WriteFile(fileBackend.pendingLocation(), target, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileBackend.appendJournal(journalEntry{Operation: "delete", Key: "first", Checksum: checksum(target)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := fileBackend.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := fileBackend.CheckExists(context.Background(), nil, "first"); exists {
+		t.Error("Expected interrupted delete to be replayed")
+	}
+}
+
+func TestRecoverRollsBackIncompletePut(t *testing.T) {
+	fileBackend := NewFileStore(path.Join(t.TempDir(), "store"), "test")
+	fileBackend.SetMasterPassword([]byte("myMasterPassword"))
+	if err := fileBackend.Put(context.Background(), nil, "first", "value", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate a crash while writing the pending file
+	if err := os.WriteFile(fileBackend.pendingLocation(), []byte("[{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileBackend.appendJournal(journalEntry{Operation: "put", Key: "second", Checksum: "unknown"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := fileBackend.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := fileBackend.CheckExists(context.Background(), nil, "first"); !exists {
+		t.Error("Expected store to be rolled back to its previous state")
+	}
+	if _, err := os.Stat(fileBackend.pendingLocation()); !errors.Is(err, os.ErrNotExist) {
+		t.Error("Expected pending file to be removed")
+	}
+}
+
+func TestGetMissingKeyIsNotFound(t *testing.T) {
+	fileBackend := NewFileStore(path.Join(t.TempDir(), "store"), "test")
+	_, err := fileBackend.Get(context.Background(), nil, "missing")
+	if !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected: %v, got: %v", ErrKeyNotFound, err)
+	}
+}
+
+func TestStoreFileLocationPerProfile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	perProfile := path.Join(home, "project.teamF3.secrets.kiya")
+	if got, preferred := storeFileLocation("", "project", "teamF3"); got != perProfile || preferred != "" {
+		t.Errorf("Expected: %s, got: %s %s", perProfile, got, preferred)
+	}
+	legacy := path.Join(home, "project.secrets.kiya")
+	if err := os.WriteFile(legacy, []byte(""), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store := NewFileStoreForProfile("", "project", "teamF3")
+	if got := store.Location(); got != legacy {
+		t.Errorf("Expected: %s, got: %s", legacy, got)
+	}
+	if got := store.PreferredLocation(); got != perProfile {
+		t.Errorf("Expected: %s, got: %s", perProfile, got)
+	}
+	if got, preferred := storeFileLocation("/tmp/my.kiya", "project", "teamF3"); got != "/tmp/my.kiya" || preferred != "" {
+		t.Errorf("Expected: /tmp/my.kiya, got: %s %s", got, preferred)
+	}
+}
+
+func TestPutBatchWritesAllOrNothing(t *testing.T) {
+	fileBackend := NewFileStore(path.Join(t.TempDir(), "store"), "test")
+	ctx := context.Background()
+	if err := fileBackend.PutBatch(ctx, nil, map[string]string{"a": "1", "b": "2"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileBackend.PutBatch(ctx, nil, map[string]string{"b": "3", "c": "4"}, false); err == nil {
+		t.Error("Expected error for existing key without overwrite")
+	}
+	if exists, _ := fileBackend.CheckExists(ctx, nil, "c"); exists {
+		t.Error("Expected no key of a failed batch to be stored")
+	}
+	if err := fileBackend.PutBatch(ctx, nil, map[string]string{"b": "3", "c": "4"}, true); err != nil {
+		t.Fatal(err)
+	}
+	keys, _ := fileBackend.List(ctx, nil)
+	if len(keys) != 3 {
+		t.Errorf("Expected: 3 keys, got: %d", len(keys))
+	}
+	value, _ := fileBackend.Get(ctx, nil, "b")
+	if string(value) != "3" {
+		t.Errorf("Expected: 3, got: %s", value)
+	}
+}
+
+func TestPutStoresContentType(t *testing.T) {
+	fileBackend := NewFileStore(path.Join(t.TempDir(), "store"), "test")
+	ctx := WithContentType(context.Background(), "application/json")
+	if err := fileBackend.Put(ctx, nil, "config", `{"a":1}`, false); err != nil {
+		t.Fatal(err)
+	}
+	keys, _ := fileBackend.List(ctx, nil)
+	if len(keys) != 1 || keys[0].ContentType != "application/json" {
+		t.Errorf("Expected content type application/json, got: %v", keys)
+	}
+}
+
+func TestCloseWipesMasterPassword(t *testing.T) {
+	fileBackend := NewFileStore(path.Join(t.TempDir(), "store"), "test")
+	password := []byte("secret")
+	fileBackend.SetParameter("masterPassword", password)
+	fileBackend.Close()
+	if string(password) != "\x00\x00\x00\x00\x00\x00" {
+		t.Errorf("Expected zeroed password, got: %v", password)
+	}
+}
+
+func TestEncryptDecryptScrypt(t *testing.T) {
+	fileBackend := NewFileStore("./", "test")
+	if err := fileBackend.SetKDF(KDFScrypt); err != nil {
+		t.Fatal(err)
+	}
+	encryptedData, err := fileBackend.encrypt([]byte("testdata"), []byte("myMasterPassword"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := encryptedData[len(blobMagic)], kdfIDs[KDFScrypt]; got != want {
+		t.Errorf("got kdf id %d want %d", got, want)
+	}
+	decryptedData, err := NewFileStore("./", "test").decrypt(encryptedData, []byte("myMasterPassword"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(decryptedData), "testdata"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestDecryptLegacyWithoutHeader(t *testing.T) {
+	pass := []byte("myMasterPassword")
+	salt := makeNonce(16)
+	cipher, err := chacha20poly1305.NewX(argon2.Key(pass, salt, 3, 32*1024, 4, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := makeNonce(24)
+	legacy := append(append(salt, nonce...), cipher.Seal(nil, nonce, []byte("testdata"), nil)...)
+
+	decryptedData, err := NewFileStore("./", "test").decrypt(legacy, pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(decryptedData), "testdata"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestSetFormerKDFName(t *testing.T) {
+	fileBackend := NewFileStore("./", "test")
+	if err := fileBackend.SetKDF("argon2"); err != nil {
+		t.Fatal(err)
+	}
+	encryptedData, err := fileBackend.encrypt([]byte("testdata"), []byte("myMasterPassword"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := encryptedData[len(blobMagic)], kdfIDs[KDFArgon2i]; got != want {
+		t.Errorf("got kdf id %d want %d", got, want)
+	}
+}
+
+func TestDecryptWithHeaderHasNoLegacyFallback(t *testing.T) {
+	encryptedData, err := encryptValue([]byte("testdata"), []byte("myMasterPassword"), KDFScrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an unknown id must not be decrypted as a legacy value
+	encryptedData[len(blobMagic)] = 99
+	if _, err := decryptValue(encryptedData, []byte("myMasterPassword")); err == nil || !strings.Contains(err.Error(), "unknown key derivation function id") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestSetUnknownKDF(t *testing.T) {
+	if err := NewFileStore("./", "test").SetKDF("md5"); err == nil {
+		t.Error("expected error for unknown kdf")
+	}
+}
+
+func TestExportImportRaw(t *testing.T) {
+	ctx := context.Background()
+	source := NewFileStore(path.Join(t.TempDir(), "source"), "test")
+	source.SetMasterPassword([]byte("test"))
+	source.Put(ctx, nil, "a", "secret", false)
+	raw, err := source.ExportRaw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte("secret")) {
+		t.Fatal("raw export contains a decrypted value")
+	}
+
+	target := NewFileStore(path.Join(t.TempDir(), "target"), "test")
+	if err := target.ImportRaw(raw, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := target.ImportRaw(raw, false); err == nil {
+		t.Error("expected error for import into a store with keys")
+	}
+	if err := target.ImportRaw([]byte("not a store"), true); err == nil {
+		t.Error("expected error for invalid data")
+	}
+	target.SetMasterPassword([]byte("test"))
+	if value, err := target.Get(ctx, nil, "a"); err != nil || string(value) != "secret" {
+		t.Errorf("got %s %v", value, err)
+	}
+}
+
+func TestPutSameKeyTwice(t *testing.T) {
+	ctx := context.Background()
+	fileBackend := NewFileStore(path.Join(t.TempDir(), "store"), "test")
+	fileBackend.SetMasterPassword([]byte("test"))
+	if err := fileBackend.Put(ctx, nil, "a", "first", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileBackend.Put(ctx, nil, "a", "second", false); !errors.Is(err, ErrKeyExists) {
+		t.Errorf("expected ErrKeyExists, got %v", err)
+	}
+	if keys, _ := fileBackend.List(ctx, nil); len(keys) != 1 {
+		t.Fatalf("expected 1 entry, got %v", keys)
+	}
+	if err := fileBackend.Put(ctx, nil, "a", "third", true); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := fileBackend.List(ctx, nil); len(keys) != 1 {
+		t.Fatalf("expected 1 entry, got %v", keys)
+	}
+	if value, _ := fileBackend.Get(ctx, nil, "a"); string(value) != "third" {
+		t.Errorf("got %s want third", value)
+	}
+}
+
+func TestOverwriteKeepsMetadata(t *testing.T) {
+	fileBackend := NewFileStore(path.Join(t.TempDir(), "store"), "test")
+	fileBackend.SetMasterPassword([]byte("test"))
+	ctx := WithInfo(WithContentType(context.Background(), "application/json"), "owned by team")
+	if err := fileBackend.Put(ctx, nil, "a", `{"a":1}`, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileBackend.Put(context.Background(), nil, "a", `{"a":2}`, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileBackend.PutBatch(context.Background(), nil, map[string]string{"a": `{"a":3}`}, true); err != nil {
+		t.Fatal(err)
+	}
+	keys, _ := fileBackend.List(ctx, nil)
+	if len(keys) != 1 || keys[0].ContentType != "application/json" || keys[0].Info != "owned by team" {
+		t.Errorf("expected metadata to be kept, got: %v", keys)
+	}
+	if err := fileBackend.Put(WithInfo(context.Background(), "new"), nil, "a", `{"a":4}`, true); err != nil {
+		t.Fatal(err)
+	}
+	keys, _ = fileBackend.List(ctx, nil)
+	if keys[0].Info != "new" || keys[0].ContentType != "application/json" {
+		t.Errorf("expected new info, got: %v", keys)
+	}
+}
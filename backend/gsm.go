@@ -10,6 +10,7 @@ import (
 	"google.golang.org/api/iterator"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 type GSM struct {
@@ -34,7 +35,10 @@ func (b *GSM) Get(ctx context.Context, p *Profile, key string) ([]byte, error) {
 		),
 	})
 	if err != nil {
-		return nil, err
+		if status.Code(err) == codes.NotFound {
+			return nil, fmt.Errorf("%s %w, %v", key, ErrKeyNotFound, err)
+		}
+		return nil, gsmError(err)
 	}
 
 	if result.Payload == nil || result.Payload.Data == nil {
@@ -56,15 +60,21 @@ func (b *GSM) List(ctx context.Context, p *Profile) ([]Key, error) {
 			break
 		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to list secrets from GSM, %w", err)
+			return nil, fmt.Errorf("failed to list secrets from GSM, %w", gsmError(err))
 		}
 
-		keys = append(keys, Key{
+		key := Key{
 			Name:      b.fullNameToName(secret.Name),
 			CreatedAt: secret.CreateTime.AsTime(),
-			Info:      "creator: <Unknown>", // no owner
+			Info:      infoOrDefault(secret.Annotations, "creator: <Unknown>"), // no owner
 			Owner:     "<Unknown>",
-		})
+			// content type is stored as annotation
+			ContentType: secret.Annotations[contentTypeMetadata],
+		}
+		if expireTime := secret.GetExpireTime(); expireTime != nil {
+			key.ExpiresAt = expireTime.AsTime()
+		}
+		keys = append(keys, key)
 	}
 
 	return keys, nil
@@ -76,6 +86,8 @@ func (b *GSM) CheckExists(ctx context.Context, p *Profile, key string) (bool, er
 }
 
 func (b *GSM) Put(ctx context.Context, p *Profile, key, value string, overwrite bool) error {
+	// content type and info are stored as annotations
+	annotations := metadataFromContext(ctx)
 	_, err := b.client.CreateSecret(ctx, &secretmanagerpb.CreateSecretRequest{
 		Parent:   fmt.Sprintf("projects/%s", p.ProjectID),
 		SecretId: key,
@@ -83,12 +95,32 @@ func (b *GSM) Put(ctx context.Context, p *Profile, key, value string, overwrite
 			Replication: &secretmanagerpb.Replication{
 				Replication: &secretmanagerpb.Replication_Automatic_{},
 			},
+			Annotations: annotations,
 		},
 	})
 	if err != nil {
 		statusErr, ok := status.FromError(err)
 		if !ok || statusErr.Code() != codes.AlreadyExists {
-			return fmt.Errorf("failed to create secret in GSM, %w", err)
+			return fmt.Errorf("failed to create secret in GSM, %w", gsmError(err))
+		}
+		if annotations != nil {
+			// the update replaces all annotations, so those set by others are carried over
+			name := fmt.Sprintf("projects/%s/secrets/%s", p.ProjectID, key)
+			secret, err := b.client.GetSecret(ctx, &secretmanagerpb.GetSecretRequest{Name: name})
+			if err != nil {
+				return fmt.Errorf("failed to get annotations of secret in GSM, %w", gsmError(err))
+			}
+			_, err = b.client.UpdateSecret(ctx, &secretmanagerpb.UpdateSecretRequest{
+				Secret: &secretmanagerpb.Secret{
+					Name:        name,
+					Annotations: mergeMetadata(secret.Annotations, annotations),
+					Etag:        secret.Etag,
+				},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"annotations"}},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to update annotations of secret in GSM, %w", gsmError(err))
+			}
 		}
 	}
 
@@ -99,7 +131,7 @@ func (b *GSM) Put(ctx context.Context, p *Profile, key, value string, overwrite
 		},
 	})
 	if err != nil {
-		return fmt.Errorf("failed to add version after creating the secret in GSM, %w", err)
+		return fmt.Errorf("failed to add version after creating the secret in GSM, %w", gsmError(err))
 	}
 
 	return nil
@@ -110,12 +142,86 @@ func (b *GSM) Delete(ctx context.Context, p *Profile, key string) error {
 		Name: fmt.Sprintf("projects/%s/secrets/%s", p.ProjectID, key),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to delete secret from GSM, %w", err)
+		return fmt.Errorf("failed to delete secret from GSM, %w", gsmError(err))
+	}
+
+	return nil
+}
+
+// DeleteVersion destroys a single version of a secret.
+func (b *GSM) DeleteVersion(ctx context.Context, p *Profile, key, version string) error {
+	_, err := b.client.DestroySecretVersion(ctx, &secretmanagerpb.DestroySecretVersionRequest{
+		Name: fmt.Sprintf("projects/%s/secrets/%s/versions/%s", p.ProjectID, key, version),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to destroy secret version in GSM, %w", gsmError(err))
 	}
 
 	return nil
 }
 
+// ListVersions returns the versions of a secret, the latest first.
+func (b *GSM) ListVersions(ctx context.Context, p *Profile, key string) ([]Version, error) {
+	it := b.client.ListSecretVersions(ctx, &secretmanagerpb.ListSecretVersionsRequest{
+		Parent: fmt.Sprintf("projects/%s/secrets/%s", p.ProjectID, key),
+	})
+	var versions []Version
+	for {
+		version, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return nil, fmt.Errorf("%s %w, %v", key, ErrKeyNotFound, err)
+			}
+			return nil, fmt.Errorf("failed to list secret versions from GSM, %w", gsmError(err))
+		}
+		versions = append(versions, Version{
+			ID:        b.fullNameToName(version.Name),
+			CreatedAt: version.CreateTime.AsTime(),
+			State:     version.State.String(),
+		})
+	}
+	return versions, nil
+}
+
+// GetVersion returns the value of a single version of a secret.
+func (b *GSM) GetVersion(ctx context.Context, p *Profile, key, version string) ([]byte, error) {
+	result, err := b.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{
+		Name: fmt.Sprintf("projects/%s/secrets/%s/versions/%s", p.ProjectID, key, version),
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, fmt.Errorf("%s version %s %w, %v", key, version, ErrKeyNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to access secret version in GSM, %w", gsmError(err))
+	}
+	if result.Payload == nil || result.Payload.Data == nil {
+		return nil, fmt.Errorf("failed to get secret version from GSM, a nil result was returned")
+	}
+	return result.Payload.Data, nil
+}
+
+// WhoCan returns the roles and members of the IAM policy of the secret.
+func (b *GSM) WhoCan(ctx context.Context, p *Profile, key string) ([]AccessBinding, error) {
+	policy, err := b.client.IAM(fmt.Sprintf("projects/%s/secrets/%s", p.ProjectID, key)).Policy(ctx)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.NotFound:
+			return nil, fmt.Errorf("%s %w, %v", key, ErrKeyNotFound, err)
+		case codes.PermissionDenied:
+			return nil, fmt.Errorf("not allowed to read the IAM policy of %s, secretmanager.secrets.getIamPolicy permission is required, %w", key, permissionDenied(err))
+		}
+		return nil, fmt.Errorf("failed to get IAM policy from GSM, %w", err)
+	}
+	var bindings []AccessBinding
+	for _, role := range policy.Roles() {
+		bindings = append(bindings, AccessBinding{Role: string(role), Members: policy.Members(role)})
+	}
+	return bindings, nil
+}
+
 func (b *GSM) Close() error {
 	return b.client.Close()
 }
@@ -124,6 +230,15 @@ func (b *GSM) SetParameter(key string, value interface{}) {
 	// noop
 }
 
+// gsmError wraps the error in ErrPermissionDenied if the caller is not authenticated or not allowed.
+func gsmError(err error) error {
+	switch status.Code(err) {
+	case codes.PermissionDenied, codes.Unauthenticated:
+		return permissionDenied(err)
+	}
+	return err
+}
+
 ///
 
 func (b *GSM) fullNameToName(fullName string) string {
@@ -0,0 +1,209 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const k8sDefaultNamespace = "default"
+
+// K8sStore implements Backend for a single Kubernetes Secret, with each key stored as an entry of its data.
+type K8sStore struct {
+	secrets typedcorev1.SecretInterface
+	name    string
+}
+
+// NewK8sStore returns a new K8sStore for the Secret named secretName in the namespace given by the projectID of the profile.
+// Inside a cluster the service account of the pod is used unless a kubeconfig is set; otherwise the kubeconfig file of
+// the profile, or of KUBECONFIG or ~/.kube/config, is used with the kubeContext of the profile or the current context.
+func NewK8sStore(p *Profile) (*K8sStore, error) {
+	if len(p.SecretName) == 0 {
+		return nil, errors.New("no secretName in profile for k8s")
+	}
+	config, err := k8sRestConfig(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubernetes configuration, %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client, %w", err)
+	}
+	namespace := p.ProjectID
+	if len(namespace) == 0 {
+		namespace = k8sDefaultNamespace
+	}
+	return newK8sStore(clientset, namespace, p.SecretName), nil
+}
+
+func newK8sStore(clientset kubernetes.Interface, namespace, name string) *K8sStore {
+	return &K8sStore{secrets: clientset.CoreV1().Secrets(namespace), name: name}
+}
+
+func k8sRestConfig(p *Profile) (*rest.Config, error) {
+	if len(p.Kubeconfig) == 0 {
+		if config, err := rest.InClusterConfig(); err == nil {
+			return config, nil
+		}
+	}
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = p.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: p.KubeContext}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+}
+
+// k8sError wraps the error in ErrPermissionDenied if the API server refused the credentials or the request.
+func k8sError(err error) error {
+	if apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err) {
+		return permissionDenied(err)
+	}
+	return err
+}
+
+// secret returns the Secret, nil if it does not exist.
+func (k *K8sStore) secret(ctx context.Context) (*corev1.Secret, error) {
+	secret, err := k.secrets.Get(ctx, k.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, k8sError(err)
+	}
+	return secret, nil
+}
+
+func (k *K8sStore) Get(ctx context.Context, _ *Profile, key string) ([]byte, error) {
+	secret, err := k.secret(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret from kubernetes, %w", err)
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("%s %w", key, ErrKeyNotFound)
+	}
+	value, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("%s %w", key, ErrKeyNotFound)
+	}
+	return value, nil
+}
+
+func (k *K8sStore) List(ctx context.Context, _ *Profile) ([]Key, error) {
+	secret, err := k.secret(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets from kubernetes, %w", err)
+	}
+	if secret == nil {
+		return nil, nil
+	}
+	var keys []Key
+	for name := range secret.Data {
+		keys = append(keys, Key{
+			Name:  name,
+			Info:  fmt.Sprintf("secret: %s/%s", secret.Namespace, secret.Name),
+			Owner: "<Unknown>", // no owner
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	return keys, nil
+}
+
+func (k *K8sStore) CheckExists(ctx context.Context, _ *Profile, key string) (bool, error) {
+	secret, err := k.secret(ctx)
+	if err != nil || secret == nil {
+		return false, err
+	}
+	_, ok := secret.Data[key]
+	return ok, nil
+}
+
+// Put stores the value as an entry of the Secret, which is created if needed; without overwrite it fails if the entry exists.
+// The patch is conditional on the resource version that was read such that concurrent changes are not lost.
+func (k *K8sStore) Put(ctx context.Context, _ *Profile, key, value string, overwrite bool) error {
+	secret, err := k.secret(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to put secret in kubernetes, %w", err)
+	}
+	if secret == nil {
+		_, err := k.secrets.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: k.name},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{key: []byte(value)},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create secret in kubernetes, %w", k8sError(err))
+		}
+		return nil
+	}
+	if _, exists := secret.Data[key]; exists && !overwrite {
+		return fmt.Errorf("%s %w", key, ErrKeyExists)
+	}
+	if err := k.patchData(ctx, secret.ResourceVersion, key, []byte(value)); err != nil {
+		return fmt.Errorf("failed to put secret in kubernetes, %w", err)
+	}
+	return nil
+}
+
+// Delete removes the entry of the Secret, or the Secret itself if it is the last entry.
+func (k *K8sStore) Delete(ctx context.Context, _ *Profile, key string) error {
+	secret, err := k.secret(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete secret from kubernetes, %w", err)
+	}
+	if secret == nil {
+		return fmt.Errorf("%s %w", key, ErrKeyNotFound)
+	}
+	if _, ok := secret.Data[key]; !ok {
+		return fmt.Errorf("%s %w", key, ErrKeyNotFound)
+	}
+	if len(secret.Data) == 1 {
+		err := k.secrets.Delete(ctx, k.name, metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{ResourceVersion: &secret.ResourceVersion},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete secret from kubernetes, %w", k8sError(err))
+		}
+		return nil
+	}
+	// a nil value removes the entry
+	if err := k.patchData(ctx, secret.ResourceVersion, key, nil); err != nil {
+		return fmt.Errorf("failed to delete secret from kubernetes, %w", err)
+	}
+	return nil
+}
+
+// patchData sets, or if nil removes, the entry of the Secret using a merge patch that fails if the resource version changed.
+func (k *K8sStore) patchData(ctx context.Context, resourceVersion, key string, value []byte) error {
+	var entry interface{}
+	if value != nil {
+		entry = value // encoded as base64
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]string{"resourceVersion": resourceVersion},
+		"data":     map[string]interface{}{key: entry},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = k.secrets.Patch(ctx, k.name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return k8sError(err)
+}
+
+// Close is a noop; the client has no connection to close.
+func (k *K8sStore) Close() error {
+	return nil
+}
+
+func (k *K8sStore) SetParameter(key string, value interface{}) {
+	// noop
+}
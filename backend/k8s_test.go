@@ -0,0 +1,91 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestK8sStore(t *testing.T) {
+	ctx := context.Background()
+	p := &Profile{}
+	clientset := fake.NewSimpleClientset()
+	k := newK8sStore(clientset, "team", "kiya")
+
+	if keys, err := k.List(ctx, p); err != nil || len(keys) != 0 {
+		t.Errorf("got %v %v want no keys without a secret", keys, err)
+	}
+	if err := k.Put(ctx, p, "db.password", "one", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Put(ctx, p, "api.token", "two", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Put(ctx, p, "db.password", "three", false); !errors.Is(err, ErrKeyExists) {
+		t.Errorf("expected ErrKeyExists, got %v", err)
+	}
+	if err := k.Put(ctx, p, "db.password", "three", true); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := k.Get(ctx, p, "db.password"); err != nil || string(value) != "three" {
+		t.Errorf("got %s %v want three", value, err)
+	}
+	if _, err := k.Get(ctx, p, "missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	keys, err := k.List(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0].Name != "api.token" || keys[1].Name != "db.password" {
+		t.Errorf("got %v", keys)
+	}
+
+	if err := k.Delete(ctx, p, "db.password"); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := k.CheckExists(ctx, p, "db.password"); err != nil || exists {
+		t.Errorf("got %v %v want false", exists, err)
+	}
+	if err := k.Delete(ctx, p, "api.token"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clientset.CoreV1().Secrets("team").Get(ctx, "kiya", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the empty secret to be deleted, got %v", err)
+	}
+	if err := k.Delete(ctx, p, "api.token"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestK8sStoreKeepsOtherSecrets(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "kiya", Namespace: "team"},
+		Data:       map[string][]byte{"a": []byte("1")},
+	})
+	if value, err := newK8sStore(clientset, "team", "kiya").Get(ctx, &Profile{}, "a"); err != nil || string(value) != "1" {
+		t.Errorf("got %s %v want 1", value, err)
+	}
+	if _, err := newK8sStore(clientset, "other", "kiya").Get(ctx, &Profile{}, "a"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound in another namespace, got %v", err)
+	}
+}
+
+func TestK8sStorePermissionDenied(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("get", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "kiya", errors.New("no access"))
+	})
+	if _, err := newK8sStore(clientset, "team", "kiya").Get(context.Background(), &Profile{}, "a"); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("expected ErrPermissionDenied, got %v", err)
+	}
+}
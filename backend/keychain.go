@@ -0,0 +1,9 @@
+package backend
+
+// keychainService returns the service name under which the keys of a profile are stored in the keychain.
+func keychainService(projectID string) string {
+	if len(projectID) == 0 {
+		return "kiya"
+	}
+	return "kiya." + projectID
+}
@@ -0,0 +1,129 @@
+//go:build darwin && cgo
+
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/keybase/go-keychain"
+)
+
+// KeychainStore implements Backend for the login keychain of macOS, with each key stored as a generic password
+// whose account is the key and whose service is derived from the projectID of the profile.
+type KeychainStore struct {
+	service string
+}
+
+// NewKeychainStore returns a new KeychainStore for the profile.
+func NewKeychainStore(p *Profile) (Backend, error) {
+	return &KeychainStore{service: keychainService(p.ProjectID)}, nil
+}
+
+// keychainError wraps the error in ErrPermissionDenied if the keychain is locked or access was denied by the user.
+func keychainError(err error) error {
+	for _, each := range []keychain.Error{keychain.ErrorAuthFailed, keychain.ErrorInteractionNotAllowed, keychain.ErrorUserCanceled} {
+		if errors.Is(err, each) {
+			return permissionDenied(err)
+		}
+	}
+	return err
+}
+
+// query returns the item that matches the generic password of the key, or of all keys if empty.
+func (k *KeychainStore) query(key string) keychain.Item {
+	item := keychain.NewItem()
+	item.SetSecClass(keychain.SecClassGenericPassword)
+	item.SetService(k.service)
+	if len(key) > 0 {
+		item.SetAccount(key)
+	}
+	return item
+}
+
+func (k *KeychainStore) Get(ctx context.Context, _ *Profile, key string) ([]byte, error) {
+	query := k.query(key)
+	query.SetMatchLimit(keychain.MatchLimitOne)
+	query.SetReturnData(true)
+	results, err := keychain.QueryItem(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret from keychain, %w", keychainError(err))
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%s %w", key, ErrKeyNotFound)
+	}
+	return results[0].Data, nil
+}
+
+func (k *KeychainStore) List(ctx context.Context, _ *Profile) ([]Key, error) {
+	query := k.query("")
+	query.SetMatchLimit(keychain.MatchLimitAll)
+	query.SetReturnAttributes(true)
+	results, err := keychain.QueryItem(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets from keychain, %w", keychainError(err))
+	}
+	var keys []Key
+	for _, each := range results {
+		keys = append(keys, Key{
+			Name:      each.Account,
+			CreatedAt: each.CreationDate,
+			Info:      fmt.Sprintf("service: %s", k.service),
+			Owner:     "<Unknown>", // no owner
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	return keys, nil
+}
+
+func (k *KeychainStore) CheckExists(ctx context.Context, _ *Profile, key string) (bool, error) {
+	query := k.query(key)
+	query.SetMatchLimit(keychain.MatchLimitOne)
+	query.SetReturnAttributes(true)
+	results, err := keychain.QueryItem(query)
+	if err != nil {
+		return false, keychainError(err)
+	}
+	return len(results) > 0, nil
+}
+
+// Put adds the value as a generic password; with overwrite an existing password is updated.
+func (k *KeychainStore) Put(ctx context.Context, _ *Profile, key, value string, overwrite bool) error {
+	item := keychain.NewGenericPassword(k.service, key, key, []byte(value), "")
+	item.SetAccessible(keychain.AccessibleWhenUnlocked)
+	err := keychain.AddItem(item)
+	if errors.Is(err, keychain.ErrorDuplicateItem) {
+		if !overwrite {
+			return fmt.Errorf("%s %w", key, ErrKeyExists)
+		}
+		update := keychain.NewItem()
+		update.SetData([]byte(value))
+		err = keychain.UpdateItem(k.query(key), update)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to put secret in keychain, %w", keychainError(err))
+	}
+	return nil
+}
+
+func (k *KeychainStore) Delete(ctx context.Context, _ *Profile, key string) error {
+	err := keychain.DeleteItem(k.query(key))
+	if errors.Is(err, keychain.ErrorItemNotFound) {
+		return fmt.Errorf("%s %w", key, ErrKeyNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete secret from keychain, %w", keychainError(err))
+	}
+	return nil
+}
+
+// Close is a noop; the keychain needs no connection.
+func (k *KeychainStore) Close() error {
+	return nil
+}
+
+func (k *KeychainStore) SetParameter(key string, value interface{}) {
+	// noop
+}
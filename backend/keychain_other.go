@@ -0,0 +1,10 @@
+//go:build !darwin || !cgo
+
+package backend
+
+import "fmt"
+
+// NewKeychainStore fails because the keychain is only available on macOS, in a build with cgo enabled.
+func NewKeychainStore(p *Profile) (Backend, error) {
+	return nil, fmt.Errorf("keychain backend requires macOS and a build with cgo, %w", ErrNotSupported)
+}
@@ -0,0 +1,14 @@
+//go:build !darwin || !cgo
+
+package backend
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewKeychainStoreNotSupported(t *testing.T) {
+	if _, err := NewKeychainStore(&Profile{}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
@@ -0,0 +1,12 @@
+package backend
+
+import "testing"
+
+func TestKeychainService(t *testing.T) {
+	if got, want := keychainService(""), "kiya"; got != want {
+		t.Errorf("got [%s] want [%s]", got, want)
+	}
+	if got, want := keychainService("laptop"), "kiya.laptop"; got != want {
+		t.Errorf("got [%s] want [%s]", got, want)
+	}
+}
@@ -0,0 +1,138 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// KeyEncodingBackend decorates a Backend such that logical path-style keys (a/b/c) are stored
+// using a separator that is legal for the backend, e.g. a__b__c for GSM or a--b--c for AKV.
+// List decodes the stored names back to their logical form.
+type KeyEncodingBackend struct {
+	backend   Backend
+	separator string
+}
+
+// NewKeyEncodingBackend returns a decorated Backend that replaces each slash in a key by the separator.
+// If the separator is empty or a slash then the backend is returned as is.
+func NewKeyEncodingBackend(b Backend, separator string) Backend {
+	if separator == "" || separator == "/" {
+		return b
+	}
+	return &KeyEncodingBackend{backend: b, separator: separator}
+}
+
+// encode returns the backend key for a logical key.
+// A logical key must not contain the separator itself, otherwise it cannot be decoded.
+func (k *KeyEncodingBackend) encode(key string) (string, error) {
+	if strings.Contains(key, k.separator) {
+		return "", fmt.Errorf("key [%s] cannot contain the key separator [%s]", key, k.separator)
+	}
+	return strings.ReplaceAll(key, "/", k.separator), nil
+}
+
+// decode returns the logical key for a backend key.
+func (k *KeyEncodingBackend) decode(key string) string {
+	return strings.ReplaceAll(key, k.separator, "/")
+}
+
+func (k *KeyEncodingBackend) Get(ctx context.Context, p *Profile, key string) ([]byte, error) {
+	encoded, err := k.encode(key)
+	if err != nil {
+		return nil, err
+	}
+	return k.backend.Get(ctx, p, encoded)
+}
+
+func (k *KeyEncodingBackend) List(ctx context.Context, p *Profile) ([]Key, error) {
+	keys, err := k.backend.List(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	for i := range keys {
+		keys[i].Name = k.decode(keys[i].Name)
+	}
+	return keys, nil
+}
+
+func (k *KeyEncodingBackend) CheckExists(ctx context.Context, p *Profile, key string) (bool, error) {
+	encoded, err := k.encode(key)
+	if err != nil {
+		return false, err
+	}
+	return k.backend.CheckExists(ctx, p, encoded)
+}
+
+func (k *KeyEncodingBackend) Put(ctx context.Context, p *Profile, key, value string, overwrite bool) error {
+	encoded, err := k.encode(key)
+	if err != nil {
+		return err
+	}
+	return k.backend.Put(ctx, p, encoded, value, overwrite)
+}
+
+func (k *KeyEncodingBackend) Delete(ctx context.Context, p *Profile, key string) error {
+	encoded, err := k.encode(key)
+	if err != nil {
+		return err
+	}
+	return k.backend.Delete(ctx, p, encoded)
+}
+
+func (k *KeyEncodingBackend) DeleteVersion(ctx context.Context, p *Profile, key, version string) error {
+	encoded, err := k.encode(key)
+	if err != nil {
+		return err
+	}
+	return DeleteVersion(ctx, k.backend, p, encoded, version)
+}
+
+func (k *KeyEncodingBackend) ListVersions(ctx context.Context, p *Profile, key string) ([]Version, error) {
+	encoded, err := k.encode(key)
+	if err != nil {
+		return nil, err
+	}
+	return ListVersions(ctx, k.backend, p, encoded)
+}
+
+func (k *KeyEncodingBackend) GetVersion(ctx context.Context, p *Profile, key, version string) ([]byte, error) {
+	encoded, err := k.encode(key)
+	if err != nil {
+		return nil, err
+	}
+	return GetVersion(ctx, k.backend, p, encoded, version)
+}
+
+func (k *KeyEncodingBackend) WhoCan(ctx context.Context, p *Profile, key string) ([]AccessBinding, error) {
+	encoded, err := k.encode(key)
+	if err != nil {
+		return nil, err
+	}
+	return WhoCan(ctx, k.backend, p, encoded)
+}
+
+func (k *KeyEncodingBackend) PutBatch(ctx context.Context, p *Profile, values map[string]string, overwrite bool) error {
+	encoded := make(map[string]string, len(values))
+	for key, value := range values {
+		encodedKey, err := k.encode(key)
+		if err != nil {
+			return err
+		}
+		encoded[encodedKey] = value
+	}
+	return PutBatch(ctx, k.backend, p, encoded, overwrite)
+}
+
+func (k *KeyEncodingBackend) SetParameter(key string, value interface{}) {
+	k.backend.SetParameter(key, value)
+}
+
+func (k *KeyEncodingBackend) Close() error {
+	return k.backend.Close()
+}
+
+// Unwrap returns the decorated backend.
+func (k *KeyEncodingBackend) Unwrap() Backend {
+	return k.backend
+}
@@ -0,0 +1,126 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"testing"
+)
+
+func TestKeyEncodingBackendRoundTrip(t *testing.T) {
+	store := NewFileStore(path.Join(t.TempDir(), "store"), "test")
+	encoding := NewKeyEncodingBackend(store, "__")
+	ctx := context.Background()
+
+	if err := encoding.Put(ctx, nil, "a/b/c", "value", false); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := store.CheckExists(ctx, nil, "a__b__c"); !exists {
+		t.Error("Expected key to be stored as a__b__c")
+	}
+	value, err := encoding.Get(ctx, nil, "a/b/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(value) != "value" {
+		t.Errorf("Expected: value, got: %s", value)
+	}
+	keys, err := encoding.List(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].Name != "a/b/c" {
+		t.Errorf("Expected: [a/b/c], got: %v", keys)
+	}
+}
+
+func TestKeyEncodingBackendRejectsSeparatorInKey(t *testing.T) {
+	encoding := NewKeyEncodingBackend(NewFileStore(path.Join(t.TempDir(), "store"), "test"), "--")
+	if err := encoding.Put(context.Background(), nil, "a--b", "value", false); err == nil {
+		t.Error("Expected error for key containing the separator")
+	}
+}
+
+func TestKeyEncodingBackendWithoutSeparator(t *testing.T) {
+	store := NewFileStore(path.Join(t.TempDir(), "store"), "test")
+	if NewKeyEncodingBackend(store, "") != Backend(store) {
+		t.Error("Expected backend to be returned as is")
+	}
+}
+
+func TestWhoCanNotSupportedThroughDecorators(t *testing.T) {
+	b := NewKeyEncodingBackend(NewFileStore(path.Join(t.TempDir(), "store"), "test"), "__")
+	if _, err := WhoCan(context.Background(), b, &Profile{}, "a/b"); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Expected: %v, got: %v", ErrNotSupported, err)
+	}
+}
+
+// versionedStore is a FileStore that keeps each value put as a version, numbered from 1.
+type versionedStore struct {
+	*FileStore
+	versions map[string][]string
+}
+
+func (v *versionedStore) Put(ctx context.Context, p *Profile, key, value string, overwrite bool) error {
+	if err := v.FileStore.Put(ctx, p, key, value, overwrite); err != nil {
+		return err
+	}
+	v.versions[key] = append(v.versions[key], value)
+	return nil
+}
+
+func (v *versionedStore) DeleteVersion(ctx context.Context, p *Profile, key, version string) error {
+	return nil
+}
+
+func (v *versionedStore) ListVersions(ctx context.Context, p *Profile, key string) (list []Version, err error) {
+	for i := len(v.versions[key]); i > 0; i-- {
+		list = append(list, Version{ID: strconv.Itoa(i), State: "ENABLED"})
+	}
+	return list, nil
+}
+
+func (v *versionedStore) GetVersion(ctx context.Context, p *Profile, key, version string) ([]byte, error) {
+	i, _ := strconv.Atoi(version)
+	if i < 1 || i > len(v.versions[key]) {
+		return nil, fmt.Errorf("%s version %s %w", key, version, ErrKeyNotFound)
+	}
+	return []byte(v.versions[key][i-1]), nil
+}
+
+func TestVersionsThroughDecorators(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileStore(path.Join(t.TempDir(), "store"), "test")
+	store.SetParameter("masterPassword", []byte("test"))
+	versioned := &versionedStore{FileStore: store, versions: map[string][]string{}}
+	b, err := NewValueEncodingBackend(NewKeyEncodingBackend(versioned, "__"), EncodingBase64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Put(ctx, &Profile{}, "a/b", "one", false)
+	b.Put(ctx, &Profile{}, "a/b", "two", true)
+
+	versions, err := ListVersions(ctx, b, &Profile{}, "a/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].ID != "2" {
+		t.Errorf("Expected: versions 2 and 1, got: %v", versions)
+	}
+	value, err := GetVersion(ctx, b, &Profile{}, "a/b", "1")
+	if err != nil || string(value) != "one" {
+		t.Errorf("Expected: one, got: %s %v", value, err)
+	}
+}
+
+func TestVersionsNotSupportedThroughDecorators(t *testing.T) {
+	b := NewKeyEncodingBackend(NewFileStore(path.Join(t.TempDir(), "store"), "test"), "__")
+	if _, err := ListVersions(context.Background(), b, &Profile{}, "a/b"); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Expected: %v, got: %v", ErrNotSupported, err)
+	}
+	if _, err := GetVersion(context.Background(), b, &Profile{}, "a/b", "1"); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Expected: %v, got: %v", ErrNotSupported, err)
+	}
+}
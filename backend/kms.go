@@ -3,13 +3,15 @@ package backend
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"net/http"
 
 	"cloud.google.com/go/storage"
 	"github.com/emicklei/tre"
 	"google.golang.org/api/cloudkms/v1"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
 )
 
@@ -28,14 +30,15 @@ func NewKMS(kmsService *cloudkms.Service, storageClient *storage.Client) *KMS {
 func (b *KMS) Get(ctx context.Context, p *Profile, key string) ([]byte, error) {
 	encryptedValue, err := b.loadSecret(p, key)
 	if err != nil {
-		log.Fatal(tre.New(err, "get failed", "key", key))
-		return nil, err
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return nil, fmt.Errorf("%s %w", key, ErrKeyNotFound)
+		}
+		return nil, kmsError(tre.New(err, "get failed", "key", key))
 	}
 
 	decryptedValue, err := b.getDecryptedValue(p, encryptedValue)
 	if err != nil {
-		log.Fatal(tre.New(err, "get failed", "cipherText", encryptedValue))
-		return nil, err
+		return nil, kmsError(tre.New(err, "get failed", "cipherText", encryptedValue))
 	}
 
 	return decryptedValue, nil
@@ -45,13 +48,13 @@ func (b *KMS) CheckExists(ctx context.Context, p *Profile, key string) (bool, er
 	bucket := b.storageClient.Bucket(p.Bucket)
 	r, err := bucket.Object(key).NewReader(ctx)
 	if err != nil {
-		return false, tre.New(err, "failed to get bucket", "profile", p.Label, "key", key)
+		return false, kmsError(tre.New(err, "failed to get bucket", "profile", p.Label, "key", key))
 	}
 	defer r.Close()
 
 	_, err = io.ReadAll(r)
 	if err != nil {
-		return false, tre.New(err, "reading encrypted value failed", "profile", p.Label, "key", key)
+		return false, kmsError(tre.New(err, "reading encrypted value failed", "profile", p.Label, "key", key))
 	}
 
 	return true, nil
@@ -60,11 +63,11 @@ func (b *KMS) CheckExists(ctx context.Context, p *Profile, key string) (bool, er
 func (b *KMS) Put(ctx context.Context, p *Profile, key, value string, overwrite bool) error {
 	encryptedValue, err := b.getEncryptedValue(p, value)
 	if err != nil {
-		return tre.New(err, "failed to fetch encrypted value", "key", key)
+		return kmsError(tre.New(err, "failed to fetch encrypted value", "key", key))
 	}
 
-	if err := b.storeSecret(p, key, encryptedValue); err != nil {
-		return tre.New(err, "store secret failed", "key", key, "encryptedValue", encryptedValue)
+	if err := b.storeSecret(p, key, encryptedValue, metadataFromContext(ctx)); err != nil {
+		return kmsError(tre.New(err, "store secret failed", "key", key, "encryptedValue", encryptedValue))
 	}
 
 	return nil
@@ -78,11 +81,11 @@ func (b *KMS) Delete(ctx context.Context, p *Profile, key string) error {
 
 	bucket := b.storageClient.Bucket(p.Bucket)
 	if _, err := bucket.Attrs(ctx); err != nil {
-		return tre.New(err, "bucket does not exist", "bucket", p.Bucket)
+		return kmsError(tre.New(err, "bucket does not exist", "bucket", p.Bucket))
 	}
 
 	err = bucket.Object(key).Delete(ctx)
-	return tre.New(err, "failed to delete secret", "key", key)
+	return kmsError(tre.New(err, "failed to delete secret", "key", key))
 }
 
 func (b *KMS) List(ctx context.Context, p *Profile) ([]Key, error) {
@@ -96,13 +99,15 @@ func (b *KMS) List(ctx context.Context, p *Profile) ([]Key, error) {
 		if err == iterator.Done {
 			break
 		} else if err != nil {
-			return nil, tre.New(err, "list failed")
+			return nil, kmsError(tre.New(err, "list failed"))
 		}
 		keys = append(keys, Key{
 			Name:      next.Name,
 			CreatedAt: next.Created,
-			Info:      fmt.Sprintf("creator: %s", next.Owner),
+			Info:      infoOrDefault(next.Metadata, fmt.Sprintf("creator: %s", next.Owner)),
 			Owner:     next.Owner,
+			// content type is stored as metadata
+			ContentType: next.Metadata[contentTypeMetadata],
 		})
 	}
 
@@ -113,6 +118,18 @@ func (b *KMS) Close() error {
 	return b.storageClient.Close()
 }
 
+// kmsError wraps the error in ErrPermissionDenied if Cloud KMS or Cloud Storage responded with 401 or 403.
+func kmsError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && (apiErr.Code == http.StatusUnauthorized || apiErr.Code == http.StatusForbidden) {
+		return permissionDenied(err)
+	}
+	return err
+}
+
 ///
 
 func (b *KMS) loadSecret(p *Profile, key string) ([]byte, error) {
@@ -175,7 +192,7 @@ func (b *KMS) getEncryptedValue(p *Profile, plainText string) (string, error) {
 	return resp.Ciphertext, nil
 }
 
-func (b *KMS) storeSecret(p *Profile, key, encryptedValue string) error {
+func (b *KMS) storeSecret(p *Profile, key, encryptedValue string, metadata map[string]string) error {
 	bucket := b.storageClient.Bucket(p.Bucket)
 	if _, err := bucket.Attrs(context.Background()); err != nil {
 		return tre.New(err, "bucket does not exist", "bucket", p.Bucket)
@@ -183,6 +200,8 @@ func (b *KMS) storeSecret(p *Profile, key, encryptedValue string) error {
 
 	w := bucket.Object(key).NewWriter(context.Background())
 	defer w.Close()
+	// the object holds the encrypted value so content type and info of the secret are stored as metadata
+	w.Metadata = metadata
 
 	_, err := fmt.Fprint(w, encryptedValue)
 	return tre.New(err, "writing encrypted value failed", "encryptedValue", encryptedValue)
@@ -0,0 +1,75 @@
+package backend
+
+import "context"
+
+// contentTypeMetadata is the name of the tag, annotation or metadata entry that holds the content type
+// for backends without a native content type field.
+const contentTypeMetadata = "content-type"
+
+type contentTypeKey struct{}
+
+// WithContentType returns a context that makes a Put store the content type, e.g. application/json, with the secret.
+func WithContentType(ctx context.Context, contentType string) context.Context {
+	return context.WithValue(ctx, contentTypeKey{}, contentType)
+}
+
+// ContentTypeFromContext returns the content type set by WithContentType or else an empty string.
+func ContentTypeFromContext(ctx context.Context) string {
+	contentType, _ := ctx.Value(contentTypeKey{}).(string)
+	return contentType
+}
+
+// infoMetadata is the name of the tag, annotation or metadata entry that holds the info of a secret.
+const infoMetadata = "info"
+
+type infoKey struct{}
+
+// WithInfo returns a context that makes a Put store the info, a human readable description, with the secret
+// if the backend supports it.
+func WithInfo(ctx context.Context, info string) context.Context {
+	return context.WithValue(ctx, infoKey{}, info)
+}
+
+// InfoFromContext returns the info set by WithInfo or else an empty string.
+func InfoFromContext(ctx context.Context) string {
+	info, _ := ctx.Value(infoKey{}).(string)
+	return info
+}
+
+// metadataFromContext returns the content type and info of the context as metadata entries, nil if there are none.
+func metadataFromContext(ctx context.Context) map[string]string {
+	var metadata map[string]string
+	for name, value := range map[string]string{
+		contentTypeMetadata: ContentTypeFromContext(ctx),
+		infoMetadata:        InfoFromContext(ctx),
+	} {
+		if len(value) == 0 {
+			continue
+		}
+		if metadata == nil {
+			metadata = map[string]string{}
+		}
+		metadata[name] = value
+	}
+	return metadata
+}
+
+// mergeMetadata returns the existing entries updated with the given ones.
+func mergeMetadata(existing, updates map[string]string) map[string]string {
+	merged := make(map[string]string, len(existing)+len(updates))
+	for name, value := range existing {
+		merged[name] = value
+	}
+	for name, value := range updates {
+		merged[name] = value
+	}
+	return merged
+}
+
+// infoOrDefault returns the info stored in the metadata or else the default info.
+func infoOrDefault(metadata map[string]string, defaultInfo string) string {
+	if info, ok := metadata[infoMetadata]; ok {
+		return info
+	}
+	return defaultInfo
+}
@@ -0,0 +1,17 @@
+package backend
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeMetadata(t *testing.T) {
+	existing := map[string]string{"owner": "team-a", infoMetadata: "old"}
+	got := mergeMetadata(existing, map[string]string{infoMetadata: "new"})
+	if want := map[string]string{"owner": "team-a", infoMetadata: "new"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected: %v, got: %v", want, got)
+	}
+	if existing[infoMetadata] != "old" {
+		t.Error("Expected the existing metadata to be unchanged")
+	}
+}
@@ -0,0 +1,164 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the operation duration histogram.
+var durationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// MetricsBackend decorates a Backend and collects counts and durations of its operations.
+type MetricsBackend struct {
+	backend   Backend
+	mutex     sync.Mutex
+	counts    map[[2]string]uint64 // operation,result -> count
+	durations map[string]*histogram
+}
+
+type histogram struct {
+	buckets []uint64 // cumulative counts per bucket in durationBuckets
+	sum     float64
+	count   uint64
+}
+
+// NewMetricsBackend returns a decorated Backend that collects metrics.
+func NewMetricsBackend(b Backend) *MetricsBackend {
+	return &MetricsBackend{
+		backend:   b,
+		counts:    map[[2]string]uint64{},
+		durations: map[string]*histogram{},
+	}
+}
+
+func (m *MetricsBackend) observe(operation string, start time.Time, err error) {
+	seconds := time.Since(start).Seconds()
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.counts[[2]string{operation, result}]++
+	h, ok := m.durations[operation]
+	if !ok {
+		h = &histogram{buckets: make([]uint64, len(durationBuckets))}
+		m.durations[operation] = h
+	}
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// WriteMetrics writes all collected metrics in the Prometheus text exposition format.
+func (m *MetricsBackend) WriteMetrics(w io.Writer) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	fmt.Fprintln(w, "# HELP kiya_backend_operations_total Number of backend operations.")
+	fmt.Fprintln(w, "# TYPE kiya_backend_operations_total counter")
+	labels := make([][2]string, 0, len(m.counts))
+	for each := range m.counts {
+		labels = append(labels, each)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i][0] == labels[j][0] {
+			return labels[i][1] < labels[j][1]
+		}
+		return labels[i][0] < labels[j][0]
+	})
+	for _, each := range labels {
+		fmt.Fprintf(w, "kiya_backend_operations_total{operation=%q,result=%q} %d\n", each[0], each[1], m.counts[each])
+	}
+
+	fmt.Fprintln(w, "# HELP kiya_backend_operation_duration_seconds Duration of backend operations.")
+	fmt.Fprintln(w, "# TYPE kiya_backend_operation_duration_seconds histogram")
+	operations := make([]string, 0, len(m.durations))
+	for each := range m.durations {
+		operations = append(operations, each)
+	}
+	sort.Strings(operations)
+	for _, each := range operations {
+		h := m.durations[each]
+		for i, bound := range durationBuckets {
+			fmt.Fprintf(w, "kiya_backend_operation_duration_seconds_bucket{operation=%q,le=\"%g\"} %d\n", each, bound, h.buckets[i])
+		}
+		fmt.Fprintf(w, "kiya_backend_operation_duration_seconds_bucket{operation=%q,le=\"+Inf\"} %d\n", each, h.count)
+		fmt.Fprintf(w, "kiya_backend_operation_duration_seconds_sum{operation=%q} %g\n", each, h.sum)
+		fmt.Fprintf(w, "kiya_backend_operation_duration_seconds_count{operation=%q} %d\n", each, h.count)
+	}
+}
+
+func (m *MetricsBackend) Get(ctx context.Context, p *Profile, key string) (value []byte, err error) {
+	defer func(start time.Time) { m.observe("get", start, err) }(time.Now())
+	return m.backend.Get(ctx, p, key)
+}
+
+func (m *MetricsBackend) List(ctx context.Context, p *Profile) (keys []Key, err error) {
+	defer func(start time.Time) { m.observe("list", start, err) }(time.Now())
+	return m.backend.List(ctx, p)
+}
+
+func (m *MetricsBackend) CheckExists(ctx context.Context, p *Profile, key string) (exists bool, err error) {
+	defer func(start time.Time) { m.observe("check_exists", start, err) }(time.Now())
+	return m.backend.CheckExists(ctx, p, key)
+}
+
+func (m *MetricsBackend) Put(ctx context.Context, p *Profile, key, value string, overwrite bool) (err error) {
+	defer func(start time.Time) { m.observe("put", start, err) }(time.Now())
+	return m.backend.Put(ctx, p, key, value, overwrite)
+}
+
+func (m *MetricsBackend) Delete(ctx context.Context, p *Profile, key string) (err error) {
+	defer func(start time.Time) { m.observe("delete", start, err) }(time.Now())
+	return m.backend.Delete(ctx, p, key)
+}
+
+func (m *MetricsBackend) DeleteVersion(ctx context.Context, p *Profile, key, version string) (err error) {
+	defer func(start time.Time) { m.observe("delete_version", start, err) }(time.Now())
+	return DeleteVersion(ctx, m.backend, p, key, version)
+}
+
+func (m *MetricsBackend) ListVersions(ctx context.Context, p *Profile, key string) (versions []Version, err error) {
+	defer func(start time.Time) { m.observe("list_versions", start, err) }(time.Now())
+	return ListVersions(ctx, m.backend, p, key)
+}
+
+func (m *MetricsBackend) GetVersion(ctx context.Context, p *Profile, key, version string) (value []byte, err error) {
+	defer func(start time.Time) { m.observe("get_version", start, err) }(time.Now())
+	return GetVersion(ctx, m.backend, p, key, version)
+}
+
+func (m *MetricsBackend) PutBatch(ctx context.Context, p *Profile, values map[string]string, overwrite bool) (err error) {
+	defer func(start time.Time) { m.observe("put_batch", start, err) }(time.Now())
+	return PutBatch(ctx, m.backend, p, values, overwrite)
+}
+
+func (m *MetricsBackend) WhoCan(ctx context.Context, p *Profile, key string) (bindings []AccessBinding, err error) {
+	defer func(start time.Time) { m.observe("who_can", start, err) }(time.Now())
+	return WhoCan(ctx, m.backend, p, key)
+}
+
+// SetParameter is passed to the decorated backend without collecting metrics.
+func (m *MetricsBackend) SetParameter(key string, value interface{}) {
+	m.backend.SetParameter(key, value)
+}
+
+// Close is passed to the decorated backend without collecting metrics.
+func (m *MetricsBackend) Close() error {
+	return m.backend.Close()
+}
+
+// Unwrap returns the decorated backend.
+func (m *MetricsBackend) Unwrap() Backend {
+	return m.backend
+}
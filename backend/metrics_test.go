@@ -0,0 +1,30 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestMetricsBackendCountsOperations(t *testing.T) {
+	metrics := NewMetricsBackend(NewFileStore(path.Join(t.TempDir(), "store"), "test"))
+	metrics.Put(context.Background(), nil, "key", "value", false)
+	metrics.Get(context.Background(), nil, "key")
+	metrics.Get(context.Background(), nil, "missing")
+
+	var buf bytes.Buffer
+	metrics.WriteMetrics(&buf)
+	for _, want := range []string{
+		`kiya_backend_operations_total{operation="get",result="failure"} 1`,
+		`kiya_backend_operations_total{operation="get",result="success"} 1`,
+		`kiya_backend_operations_total{operation="put",result="success"} 1`,
+		`kiya_backend_operation_duration_seconds_count{operation="get"} 2`,
+		`kiya_backend_operation_duration_seconds_bucket{operation="put",le="+Inf"} 1`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected metrics to contain: %s, got:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package backend
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/emicklei/tre"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPermissionDeniedPerBackend(t *testing.T) {
+	for _, each := range []struct {
+		name   string
+		err    error
+		denied bool
+	}{
+		{"gsm permission denied", gsmError(status.Error(codes.PermissionDenied, "denied")), true},
+		{"gsm unauthenticated", gsmError(status.Error(codes.Unauthenticated, "no token")), true},
+		{"gsm unavailable", gsmError(status.Error(codes.Unavailable, "down")), false},
+		{"akv forbidden", akvError(&azcore.ResponseError{StatusCode: http.StatusForbidden}), true},
+		{"akv unauthorized", akvError(&azcore.ResponseError{StatusCode: http.StatusUnauthorized}), true},
+		{"akv throttled", akvError(&azcore.ResponseError{StatusCode: http.StatusTooManyRequests}), false},
+		{"kms forbidden", kmsError(tre.New(&googleapi.Error{Code: http.StatusForbidden}, "get failed")), true},
+		{"kms server error", kmsError(&googleapi.Error{Code: http.StatusInternalServerError}), false},
+		{"etcd permission denied", etcdError(rpctypes.ErrPermissionDenied), true},
+		{"etcd auth failed", etcdError(rpctypes.ErrAuthFailed), true},
+		{"etcd no leader", etcdError(rpctypes.ErrNoLeader), false},
+	} {
+		if got := errors.Is(each.err, ErrPermissionDenied); got != each.denied {
+			t.Errorf("%s: Expected permission denied: %v, got: %v (%v)", each.name, each.denied, got, each.err)
+		}
+	}
+	if kmsError(nil) != nil {
+		t.Error("Expected nil for no error")
+	}
+}
@@ -0,0 +1,133 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validate returns an error describing the first rule of the policy that the value violates.
+// A nil policy accepts any value. Errors never contain the value itself.
+func (policy *Policy) Validate(value string) error {
+	if policy == nil {
+		return nil
+	}
+	if n := utf8.RuneCountInString(value); n < policy.MinLength {
+		return fmt.Errorf("value has %d characters, at least %d are required", n, policy.MinLength)
+	}
+	if policy.MustBeJSON && !json.Valid([]byte(value)) {
+		return fmt.Errorf("value must be valid JSON")
+	}
+	if len(policy.MustMatch) > 0 {
+		pattern, err := regexp.Compile(policy.MustMatch)
+		if err != nil {
+			return fmt.Errorf("invalid policy pattern [%s]: %v", policy.MustMatch, err)
+		}
+		if !pattern.MatchString(value) {
+			return fmt.Errorf("value must match [%s]", policy.MustMatch)
+		}
+	}
+	for _, each := range policy.Forbidden {
+		if len(each) > 0 && strings.Contains(value, each) {
+			return fmt.Errorf("value must not contain [%s]", each)
+		}
+	}
+	return nil
+}
+
+// PolicyBackend decorates a Backend such that no value violating the policy of a profile is stored,
+// whichever command stores it.
+type PolicyBackend struct {
+	backend Backend
+	policy  *Policy
+}
+
+// NewPolicyBackend returns a decorated Backend that validates each value before it is stored.
+// If the policy is nil then the backend is returned as is.
+func NewPolicyBackend(b Backend, policy *Policy) Backend {
+	if policy == nil {
+		return b
+	}
+	return &PolicyBackend{backend: b, policy: policy}
+}
+
+// validate checks the value as given by the user; a raw value is decoded first.
+func (v *PolicyBackend) validate(ctx context.Context, p *Profile, key, value string) error {
+	if isRawValue(ctx) {
+		decoded, err := DecodeValue([]byte(value))
+		if err != nil {
+			return err
+		}
+		value = string(decoded)
+		defer Zero(decoded)
+	}
+	if err := v.policy.Validate(value); err != nil {
+		return fmt.Errorf("value of [%s] violates the policy of [%s]: %w", key, p.Label, err)
+	}
+	return nil
+}
+
+func (v *PolicyBackend) Get(ctx context.Context, p *Profile, key string) ([]byte, error) {
+	return v.backend.Get(ctx, p, key)
+}
+
+func (v *PolicyBackend) List(ctx context.Context, p *Profile) ([]Key, error) {
+	return v.backend.List(ctx, p)
+}
+
+func (v *PolicyBackend) CheckExists(ctx context.Context, p *Profile, key string) (bool, error) {
+	return v.backend.CheckExists(ctx, p, key)
+}
+
+func (v *PolicyBackend) Put(ctx context.Context, p *Profile, key, value string, overwrite bool) error {
+	if err := v.validate(ctx, p, key, value); err != nil {
+		return err
+	}
+	return v.backend.Put(ctx, p, key, value, overwrite)
+}
+
+func (v *PolicyBackend) Delete(ctx context.Context, p *Profile, key string) error {
+	return v.backend.Delete(ctx, p, key)
+}
+
+func (v *PolicyBackend) DeleteVersion(ctx context.Context, p *Profile, key, version string) error {
+	return DeleteVersion(ctx, v.backend, p, key, version)
+}
+
+func (v *PolicyBackend) ListVersions(ctx context.Context, p *Profile, key string) ([]Version, error) {
+	return ListVersions(ctx, v.backend, p, key)
+}
+
+func (v *PolicyBackend) GetVersion(ctx context.Context, p *Profile, key, version string) ([]byte, error) {
+	return GetVersion(ctx, v.backend, p, key, version)
+}
+
+func (v *PolicyBackend) WhoCan(ctx context.Context, p *Profile, key string) ([]AccessBinding, error) {
+	return WhoCan(ctx, v.backend, p, key)
+}
+
+// PutBatch stores none of the values if any of them violates the policy.
+func (v *PolicyBackend) PutBatch(ctx context.Context, p *Profile, values map[string]string, overwrite bool) error {
+	for key, value := range values {
+		if err := v.validate(ctx, p, key, value); err != nil {
+			return err
+		}
+	}
+	return PutBatch(ctx, v.backend, p, values, overwrite)
+}
+
+func (v *PolicyBackend) SetParameter(key string, value interface{}) {
+	v.backend.SetParameter(key, value)
+}
+
+func (v *PolicyBackend) Close() error {
+	return v.backend.Close()
+}
+
+// Unwrap returns the decorated backend.
+func (v *PolicyBackend) Unwrap() Backend {
+	return v.backend
+}
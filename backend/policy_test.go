@@ -0,0 +1,79 @@
+package backend
+
+import (
+	"context"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	policy := &Policy{
+		MinLength:  8,
+		MustBeJSON: true,
+		MustMatch:  `"user"`,
+		Forbidden:  []string{"changeme"},
+	}
+	for _, each := range []struct {
+		value string
+		error string
+	}{
+		{`{"user":"john"}`, ""},
+		{`{}`, "at least 8"},
+		{`user=john;pw=x`, "valid JSON"},
+		{`{"name":"john"}`, "must match"},
+		{`{"user":"changeme"}`, "must not contain [changeme]"},
+	} {
+		err := policy.Validate(each.value)
+		if len(each.error) == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", each.value, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), each.error) {
+			t.Errorf("%s: got [%v] want error containing [%s]", each.value, err, each.error)
+		}
+	}
+}
+
+func TestPolicyValidateWithoutPolicy(t *testing.T) {
+	var policy *Policy
+	if err := policy.Validate(""); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPolicyValidateInvalidPattern(t *testing.T) {
+	if err := (&Policy{MustMatch: "("}).Validate("value"); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
+
+func TestPolicyBackendRejectsViolatingValues(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileStore(path.Join(t.TempDir(), "store"), "test")
+	store.SetMasterPassword([]byte("test"))
+	p := &Profile{Label: "prod", Policy: &Policy{MinLength: 8}}
+	b := NewPolicyBackend(store, p.Policy)
+	if err := b.Put(ctx, p, "a", "short", false); err == nil || !strings.Contains(err.Error(), "policy of [prod]") {
+		t.Errorf("expected policy violation, got %v", err)
+	}
+	if err := PutBatch(ctx, b, p, map[string]string{"a": "long enough", "b": "short"}, false); err == nil {
+		t.Error("expected policy violation for batch")
+	}
+	if keys, _ := store.List(ctx, p); len(keys) != 0 {
+		t.Errorf("expected nothing stored, got %v", keys)
+	}
+	// a raw value is validated as decoded
+	encoded, _ := EncodeValue("short", EncodingBase64)
+	if err := b.Put(WithRawValue(ctx), p, "a", encoded, false); err == nil {
+		t.Error("expected policy violation for raw value")
+	}
+	if err := b.Put(ctx, p, "a", "long enough", false); err != nil {
+		t.Error(err)
+	}
+	if b := NewPolicyBackend(store, nil); b != Backend(store) {
+		t.Error("expected backend as is without policy")
+	}
+}
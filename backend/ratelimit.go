@@ -0,0 +1,116 @@
+package backend
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimitedBackend decorates a Backend such that calls to the underlying service
+// do not exceed a maximum number of requests per second.
+type RateLimitedBackend struct {
+	backend Backend
+	limiter *rate.Limiter
+}
+
+// NewRateLimitedBackend returns a decorated Backend that allows requestsPerSecond calls.
+// If requestsPerSecond is not positive then the backend is returned as is.
+func NewRateLimitedBackend(b Backend, requestsPerSecond float64) Backend {
+	if requestsPerSecond <= 0 {
+		return b
+	}
+	return &RateLimitedBackend{
+		backend: b,
+		limiter: rate.NewLimiter(rate.Limit(requestsPerSecond), 1),
+	}
+}
+
+func (r *RateLimitedBackend) Get(ctx context.Context, p *Profile, key string) ([]byte, error) {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return r.backend.Get(ctx, p, key)
+}
+
+func (r *RateLimitedBackend) List(ctx context.Context, p *Profile) ([]Key, error) {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return r.backend.List(ctx, p)
+}
+
+func (r *RateLimitedBackend) CheckExists(ctx context.Context, p *Profile, key string) (bool, error) {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return false, err
+	}
+	return r.backend.CheckExists(ctx, p, key)
+}
+
+func (r *RateLimitedBackend) Put(ctx context.Context, p *Profile, key, value string, overwrite bool) error {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return r.backend.Put(ctx, p, key, value, overwrite)
+}
+
+func (r *RateLimitedBackend) Delete(ctx context.Context, p *Profile, key string) error {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return r.backend.Delete(ctx, p, key)
+}
+
+func (r *RateLimitedBackend) DeleteVersion(ctx context.Context, p *Profile, key, version string) error {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return DeleteVersion(ctx, r.backend, p, key, version)
+}
+
+func (r *RateLimitedBackend) ListVersions(ctx context.Context, p *Profile, key string) ([]Version, error) {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return ListVersions(ctx, r.backend, p, key)
+}
+
+func (r *RateLimitedBackend) GetVersion(ctx context.Context, p *Profile, key, version string) ([]byte, error) {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return GetVersion(ctx, r.backend, p, key, version)
+}
+
+func (r *RateLimitedBackend) WhoCan(ctx context.Context, p *Profile, key string) ([]AccessBinding, error) {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return WhoCan(ctx, r.backend, p, key)
+}
+
+// PutBatch counts as a single request only if the innermost backend stores the values at once,
+// otherwise each value is put, and limited, separately.
+func (r *RateLimitedBackend) PutBatch(ctx context.Context, p *Profile, values map[string]string, overwrite bool) error {
+	if !SupportsBatch(r.backend) {
+		return putEach(ctx, r, p, values, overwrite)
+	}
+	if err := r.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return PutBatch(ctx, r.backend, p, values, overwrite)
+}
+
+// SetParameter is passed to the decorated backend without limiting.
+func (r *RateLimitedBackend) SetParameter(key string, value interface{}) {
+	r.backend.SetParameter(key, value)
+}
+
+// Close is passed to the decorated backend without limiting.
+func (r *RateLimitedBackend) Close() error {
+	return r.backend.Close()
+}
+
+// Unwrap returns the decorated backend.
+func (r *RateLimitedBackend) Unwrap() Backend {
+	return r.backend
+}
@@ -0,0 +1,27 @@
+package backend
+
+import (
+	"context"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedBackendLimitsEachPutOfBatchFallback(t *testing.T) {
+	store := NewFileStore(path.Join(t.TempDir(), "store"), "test")
+	b := NewRateLimitedBackend(store, 20)
+	values := map[string]string{"a": "1", "b": "2", "c": "3"}
+	start := time.Now()
+	if err := PutBatch(context.Background(), b, nil, values, false); err != nil {
+		t.Fatal(err)
+	}
+	// the first put uses the burst, the next two wait 50ms each
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected each put to be limited, took: %s", elapsed)
+	}
+	for key := range values {
+		if exists, _ := store.CheckExists(context.Background(), nil, key); !exists {
+			t.Errorf("Expected: %s to exist", key)
+		}
+	}
+}
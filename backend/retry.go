@@ -0,0 +1,225 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Default delays of a Retry that sets MaxAttempts only.
+const (
+	defaultRetryBaseDelay = 200 * time.Millisecond
+	defaultRetryMaxDelay  = 5 * time.Second
+)
+
+// Retry describes how often and how fast failed backend calls are retried.
+type Retry struct {
+	// MaxAttempts is the maximum number of attempts of each call, including the first; no retries if zero or one
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled for each next retry, e.g. 200ms (default)
+	BaseDelay string
+	// MaxDelay is the maximum delay between two attempts, e.g. 5s (default)
+	MaxDelay string
+	// Jitter is the fraction, between 0 and 1, by which each delay is randomly shortened
+	Jitter float64
+}
+
+// Delays returns the parsed base and maximum delay, or their defaults if empty.
+func (r Retry) Delays() (base, max time.Duration, err error) {
+	base, max = defaultRetryBaseDelay, defaultRetryMaxDelay
+	if len(r.BaseDelay) > 0 {
+		if base, err = time.ParseDuration(r.BaseDelay); err != nil {
+			return 0, 0, fmt.Errorf("invalid retry baseDelay [%s]: %w", r.BaseDelay, err)
+		}
+	}
+	if len(r.MaxDelay) > 0 {
+		if max, err = time.ParseDuration(r.MaxDelay); err != nil {
+			return 0, 0, fmt.Errorf("invalid retry maxDelay [%s]: %w", r.MaxDelay, err)
+		}
+	} else if base > max {
+		max = base
+	}
+	return base, max, nil
+}
+
+// Validate returns an error if a value is negative, the maximum delay is less than the base delay
+// or the jitter is not between 0 and 1.
+func (r Retry) Validate() error {
+	if r.MaxAttempts < 0 {
+		return fmt.Errorf("retry maxAttempts [%d] must not be negative", r.MaxAttempts)
+	}
+	base, max, err := r.Delays()
+	if err != nil {
+		return err
+	}
+	if base < 0 || max < 0 {
+		return fmt.Errorf("retry delays [%s, %s] must not be negative", base, max)
+	}
+	if max < base {
+		return fmt.Errorf("retry maxDelay [%s] must not be less than baseDelay [%s]", max, base)
+	}
+	if r.Jitter < 0 || r.Jitter > 1 {
+		return fmt.Errorf("retry jitter [%v] must be between 0 and 1", r.Jitter)
+	}
+	return nil
+}
+
+// RetryingBackend decorates a Backend such that failed calls are retried with exponential backoff.
+// Errors that retrying cannot fix, such as ErrKeyNotFound, are returned immediately.
+type RetryingBackend struct {
+	backend     Backend
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	jitter      float64
+}
+
+// NewRetryingBackend returns a decorated Backend that retries according to the Retry.
+// If it allows a single attempt only then the backend is returned as is.
+func NewRetryingBackend(b Backend, r Retry) (Backend, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	if r.MaxAttempts <= 1 {
+		return b, nil
+	}
+	base, max, _ := r.Delays()
+	return &RetryingBackend{
+		backend:     b,
+		maxAttempts: r.MaxAttempts,
+		baseDelay:   base,
+		maxDelay:    max,
+		jitter:      r.Jitter,
+	}, nil
+}
+
+// delay returns how long to wait before the next attempt, given the number of failed attempts.
+func (r *RetryingBackend) delay(failed int) time.Duration {
+	d := r.baseDelay
+	for i := 1; i < failed && d < r.maxDelay; i++ {
+		d *= 2
+	}
+	if d > r.maxDelay {
+		d = r.maxDelay
+	}
+	return d - time.Duration(rand.Float64()*r.jitter*float64(d))
+}
+
+// isPermanent returns true if retrying cannot change the outcome.
+func isPermanent(err error) bool {
+	return errors.Is(err, ErrKeyNotFound) ||
+		errors.Is(err, ErrKeyExists) ||
+		errors.Is(err, ErrNotSupported) ||
+		errors.Is(err, ErrPermissionDenied) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// do calls f until it succeeds, fails permanently or the maximum number of attempts is reached.
+func (r *RetryingBackend) do(ctx context.Context, f func() error) error {
+	var err error
+	for failed := 1; ; failed++ {
+		if err = f(); err == nil || isPermanent(err) || failed == r.maxAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(r.delay(failed)):
+		}
+	}
+}
+
+func (r *RetryingBackend) Get(ctx context.Context, p *Profile, key string) (value []byte, err error) {
+	err = r.do(ctx, func() (err error) {
+		value, err = r.backend.Get(ctx, p, key)
+		return
+	})
+	return
+}
+
+func (r *RetryingBackend) List(ctx context.Context, p *Profile) (keys []Key, err error) {
+	err = r.do(ctx, func() (err error) {
+		keys, err = r.backend.List(ctx, p)
+		return
+	})
+	return
+}
+
+func (r *RetryingBackend) CheckExists(ctx context.Context, p *Profile, key string) (exists bool, err error) {
+	err = r.do(ctx, func() (err error) {
+		exists, err = r.backend.CheckExists(ctx, p, key)
+		return
+	})
+	return
+}
+
+func (r *RetryingBackend) Put(ctx context.Context, p *Profile, key, value string, overwrite bool) error {
+	return r.do(ctx, func() error {
+		return r.backend.Put(ctx, p, key, value, overwrite)
+	})
+}
+
+func (r *RetryingBackend) Delete(ctx context.Context, p *Profile, key string) error {
+	return r.do(ctx, func() error {
+		return r.backend.Delete(ctx, p, key)
+	})
+}
+
+func (r *RetryingBackend) DeleteVersion(ctx context.Context, p *Profile, key, version string) error {
+	return r.do(ctx, func() error {
+		return DeleteVersion(ctx, r.backend, p, key, version)
+	})
+}
+
+func (r *RetryingBackend) ListVersions(ctx context.Context, p *Profile, key string) (versions []Version, err error) {
+	err = r.do(ctx, func() (err error) {
+		versions, err = ListVersions(ctx, r.backend, p, key)
+		return
+	})
+	return
+}
+
+func (r *RetryingBackend) GetVersion(ctx context.Context, p *Profile, key, version string) (value []byte, err error) {
+	err = r.do(ctx, func() (err error) {
+		value, err = GetVersion(ctx, r.backend, p, key, version)
+		return
+	})
+	return
+}
+
+func (r *RetryingBackend) WhoCan(ctx context.Context, p *Profile, key string) (bindings []AccessBinding, err error) {
+	err = r.do(ctx, func() (err error) {
+		bindings, err = WhoCan(ctx, r.backend, p, key)
+		return
+	})
+	return
+}
+
+// PutBatch retries the batch as a whole only if the innermost backend stores the values at once.
+// Otherwise each put is retried separately such that a retry does not fail on keys that were already stored.
+func (r *RetryingBackend) PutBatch(ctx context.Context, p *Profile, values map[string]string, overwrite bool) error {
+	if !SupportsBatch(r.backend) {
+		return putEach(ctx, r, p, values, overwrite)
+	}
+	return r.do(ctx, func() error {
+		return PutBatch(ctx, r.backend, p, values, overwrite)
+	})
+}
+
+// SetParameter is passed to the decorated backend.
+func (r *RetryingBackend) SetParameter(key string, value interface{}) {
+	r.backend.SetParameter(key, value)
+}
+
+// Close is passed to the decorated backend without retrying.
+func (r *RetryingBackend) Close() error {
+	return r.backend.Close()
+}
+
+// Unwrap returns the decorated backend.
+func (r *RetryingBackend) Unwrap() Backend {
+	return r.backend
+}
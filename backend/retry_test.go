@@ -0,0 +1,128 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"testing"
+	"time"
+)
+
+// flakyBackend fails the first calls of Get with an error.
+type flakyBackend struct {
+	Backend
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flakyBackend) Get(ctx context.Context, p *Profile, key string) ([]byte, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, f.err
+	}
+	return f.Backend.Get(ctx, p, key)
+}
+
+func newFlakyBackend(t *testing.T, failures int, err error) *flakyBackend {
+	store := NewFileStore(path.Join(t.TempDir(), "store"), "test")
+	if err := store.Put(context.Background(), nil, "a", "value", false); err != nil {
+		t.Fatal(err)
+	}
+	return &flakyBackend{Backend: store, failures: failures, err: err}
+}
+
+func TestRetryingBackendRetriesUntilSuccess(t *testing.T) {
+	flaky := newFlakyBackend(t, 2, errors.New("unavailable"))
+	b, err := NewRetryingBackend(flaky, Retry{MaxAttempts: 3, BaseDelay: "1ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := b.Get(context.Background(), nil, "a")
+	if err != nil || string(value) != "value" {
+		t.Errorf("Expected: value <nil>, got: %s %v", value, err)
+	}
+	if flaky.calls != 3 {
+		t.Errorf("Expected: 3 calls, got: %d", flaky.calls)
+	}
+}
+
+func TestRetryingBackendGivesUp(t *testing.T) {
+	flaky := newFlakyBackend(t, 5, errors.New("unavailable"))
+	b, _ := NewRetryingBackend(flaky, Retry{MaxAttempts: 2, BaseDelay: "1ms"})
+	if _, err := b.Get(context.Background(), nil, "a"); err == nil {
+		t.Error("Expected error after the last attempt")
+	}
+	if flaky.calls != 2 {
+		t.Errorf("Expected: 2 calls, got: %d", flaky.calls)
+	}
+}
+
+func TestRetryingBackendDoesNotRetryPermanentErrors(t *testing.T) {
+	flaky := newFlakyBackend(t, 5, fmt.Errorf("a %w", ErrKeyNotFound))
+	b, _ := NewRetryingBackend(flaky, Retry{MaxAttempts: 3, BaseDelay: "1ms"})
+	if _, err := b.Get(context.Background(), nil, "a"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected: ErrKeyNotFound, got: %v", err)
+	}
+	if flaky.calls != 1 {
+		t.Errorf("Expected: 1 call, got: %d", flaky.calls)
+	}
+}
+
+func TestRetryingBackendDelay(t *testing.T) {
+	r := &RetryingBackend{baseDelay: 100 * time.Millisecond, maxDelay: 300 * time.Millisecond}
+	for failed, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 300 * time.Millisecond, 10: 300 * time.Millisecond} {
+		if got := r.delay(failed); got != want {
+			t.Errorf("delay(%d) Expected: %s, got: %s", failed, want, got)
+		}
+	}
+}
+
+func TestRetryValidate(t *testing.T) {
+	for _, each := range []struct {
+		retry Retry
+		valid bool
+	}{
+		{Retry{}, true},
+		{Retry{MaxAttempts: 3, BaseDelay: "100ms", MaxDelay: "2s", Jitter: 0.5}, true},
+		{Retry{MaxAttempts: 3, BaseDelay: "10s"}, true},
+		{Retry{MaxAttempts: -1}, false},
+		{Retry{BaseDelay: "-1s"}, false},
+		{Retry{BaseDelay: "2s", MaxDelay: "1s"}, false},
+		{Retry{BaseDelay: "soon"}, false},
+		{Retry{Jitter: 1.5}, false},
+	} {
+		if err := each.retry.Validate(); (err == nil) != each.valid {
+			t.Errorf("%+v Expected valid: %v, got: %v", each.retry, each.valid, err)
+		}
+	}
+}
+
+// flakyPutBackend fails the second call of Put with an error.
+type flakyPutBackend struct {
+	Backend
+	calls int
+}
+
+func (f *flakyPutBackend) Put(ctx context.Context, p *Profile, key, value string, overwrite bool) error {
+	f.calls++
+	if f.calls == 2 {
+		return errors.New("unavailable")
+	}
+	return f.Backend.Put(ctx, p, key, value, overwrite)
+}
+
+func TestRetryingBackendRetriesEachPutOfBatchFallback(t *testing.T) {
+	store := NewFileStore(path.Join(t.TempDir(), "store"), "test")
+	b, _ := NewRetryingBackend(&flakyPutBackend{Backend: store}, Retry{MaxAttempts: 2, BaseDelay: "1ms"})
+	// a retry of the whole batch would fail with ErrKeyExists on the keys stored before the failure
+	values := map[string]string{"a": "1", "b": "2", "c": "3"}
+	if err := PutBatch(context.Background(), b, nil, values, false); err != nil {
+		t.Fatal(err)
+	}
+	keys, _ := store.List(context.Background(), nil)
+	if len(keys) != 3 {
+		t.Errorf("Expected: 3 keys, got: %v", keys)
+	}
+}
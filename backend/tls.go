@@ -0,0 +1,91 @@
+package backend
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// TLSConfig returns the TLS configuration based on the certificate settings of the profile, nil if none are set.
+// Custom CA certificates are trusted in addition to those of the system.
+// Callers should warn if TLSSkipVerify is set, as connections can then be intercepted.
+func TLSConfig(p *Profile) (*tls.Config, error) {
+	return tlsConfig(p, true)
+}
+
+// tlsConfig returns the TLS configuration of the profile; if trustSystem is false then
+// custom CA certificates are the only ones trusted.
+func tlsConfig(p *Profile, trustSystem bool) (*tls.Config, error) {
+	if len(p.CACertFile) == 0 && len(p.CACertPath) == 0 && len(p.CertFile) == 0 && !p.TLSSkipVerify {
+		return nil, nil
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(p.CACertFile) > 0 || len(p.CACertPath) > 0 {
+		pool := x509.NewCertPool()
+		if trustSystem {
+			if system, err := x509.SystemCertPool(); err == nil {
+				pool = system
+			}
+		}
+		if err := appendCACerts(pool, p.CACertFile, p.CACertPath); err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	if len(p.CertFile) > 0 {
+		cert, err := tls.LoadX509KeyPair(p.CertFile, p.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate, %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if p.TLSSkipVerify {
+		config.InsecureSkipVerify = true
+	}
+	return config, nil
+}
+
+// appendCACerts adds the certificates of the file and of each .pem or .crt file in the directory to the pool.
+func appendCACerts(pool *x509.CertPool, file, dir string) error {
+	files := []string{}
+	if len(file) > 0 {
+		files = append(files, file)
+	}
+	if len(dir) > 0 {
+		for _, pattern := range []string{"*.pem", "*.crt"} {
+			matches, err := filepath.Glob(filepath.Join(dir, pattern))
+			if err != nil {
+				return err
+			}
+			files = append(files, matches...)
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no .pem or .crt files found in %s", dir)
+		}
+	}
+	for _, each := range files {
+		pem, err := os.ReadFile(each)
+		if err != nil {
+			return fmt.Errorf("failed to read CA certificate, %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", each)
+		}
+	}
+	return nil
+}
+
+// NewHTTPClient returns a client using the TLS configuration of the profile, nil if the profile has none
+// such that the default client of a backend is used.
+func NewHTTPClient(p *Profile) (*http.Client, error) {
+	config, err := TLSConfig(p)
+	if err != nil || config == nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	return &http.Client{Transport: transport}, nil
+}
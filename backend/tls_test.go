@@ -0,0 +1,58 @@
+package backend
+
+import (
+	"encoding/pem"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTLSConfigWithoutFiles(t *testing.T) {
+	config, err := TLSConfig(&Profile{})
+	if err != nil || config != nil {
+		t.Errorf("expected no TLS config, got %v %v", config, err)
+	}
+	if _, err := TLSConfig(&Profile{CACertFile: "/does/not/exist"}); err == nil {
+		t.Error("expected error for missing CA certificate")
+	}
+	if _, err := TLSConfig(&Profile{CACertPath: t.TempDir()}); err == nil {
+		t.Error("expected error for directory without certificates")
+	}
+}
+
+func TestHTTPClientTrustsCACertPath(t *testing.T) {
+	server := httptest.NewTLSServer(nil)
+	defer server.Close()
+	dir := t.TempDir()
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(filepath.Join(dir, "ca.pem"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewHTTPClient(&Profile{CACertPath: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+func TestEtcdTLSConfigOnlyTrustsCustomCA(t *testing.T) {
+	server := httptest.NewTLSServer(nil)
+	defer server.Close()
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := tlsConfig(&Profile{CACertFile: file}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(config.RootCAs.Subjects()); got != 1 {
+		t.Errorf("got %d trusted certificates want 1", got)
+	}
+}
@@ -0,0 +1,194 @@
+package backend
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Supported value encodings.
+const (
+	EncodingNone   = "none"
+	EncodingBase64 = "base64"
+	EncodingGzip   = "gzip"
+)
+
+// encodingHeaderPrefix starts the header of an encoded value, e.g. "kiya-encoding:gzip;".
+const encodingHeaderPrefix = "kiya-encoding:"
+
+// ValueEncodingBackend decorates a Backend such that values are stored using an encoding.
+// The encoding is recorded in a header of the stored value and reversed on Get,
+// such that values stored with any encoding can be read.
+type ValueEncodingBackend struct {
+	backend  Backend
+	encoding string
+}
+
+// NewValueEncodingBackend returns a decorated Backend that stores values using the encoding (none, base64 or gzip).
+func NewValueEncodingBackend(b Backend, encoding string) (*ValueEncodingBackend, error) {
+	switch encoding {
+	case "":
+		encoding = EncodingNone
+	case EncodingNone, EncodingBase64, EncodingGzip:
+	default:
+		return nil, fmt.Errorf("unknown encoding [%s], use none, base64 or gzip", encoding)
+	}
+	return &ValueEncodingBackend{backend: b, encoding: encoding}, nil
+}
+
+// EncodeValue returns the value with a header, encoded using the encoding.
+func EncodeValue(value, encoding string) (string, error) {
+	switch encoding {
+	case "", EncodingNone:
+		return value, nil
+	case EncodingBase64:
+		return encodingHeaderPrefix + EncodingBase64 + ";" + base64.StdEncoding.EncodeToString([]byte(value)), nil
+	case EncodingGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := io.WriteString(w, value); err != nil {
+			return "", err
+		}
+		if err := w.Close(); err != nil {
+			return "", err
+		}
+		// base64 keeps the stored value valid for backends that only accept text
+		return encodingHeaderPrefix + EncodingGzip + ";" + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+	default:
+		return "", fmt.Errorf("unknown encoding [%s]", encoding)
+	}
+}
+
+// DecodeValue reverses the encoding recorded in the header of a value. Values without a header are returned as is.
+func DecodeValue(value []byte) ([]byte, error) {
+	if !bytes.HasPrefix(value, []byte(encodingHeaderPrefix)) {
+		return value, nil
+	}
+	header, payload, ok := strings.Cut(string(value[len(encodingHeaderPrefix):]), ";")
+	if !ok {
+		return nil, fmt.Errorf("invalid encoding header")
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s encoded value, %w", header, err)
+	}
+	switch header {
+	case EncodingBase64:
+		return data, nil
+	case EncodingGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip encoded value, %w", err)
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	default:
+		return nil, fmt.Errorf("unknown encoding [%s]", header)
+	}
+}
+
+// EncodingOf returns the encoding recorded in the header of a stored value, EncodingNone if it has no header.
+func EncodingOf(value []byte) string {
+	if !bytes.HasPrefix(value, []byte(encodingHeaderPrefix)) {
+		return EncodingNone
+	}
+	header, _, _ := strings.Cut(string(value[len(encodingHeaderPrefix):]), ";")
+	return header
+}
+
+type rawValueKey struct{}
+
+// WithRawValue returns a context that makes Get and Put pass values as stored, neither decoded nor encoded,
+// such that a value can be written back with the encoding it was stored with.
+func WithRawValue(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rawValueKey{}, true)
+}
+
+func isRawValue(ctx context.Context) bool {
+	raw, _ := ctx.Value(rawValueKey{}).(bool)
+	return raw
+}
+
+func (v *ValueEncodingBackend) Get(ctx context.Context, p *Profile, key string) ([]byte, error) {
+	value, err := v.backend.Get(ctx, p, key)
+	if err != nil || isRawValue(ctx) {
+		return value, err
+	}
+	return DecodeValue(value)
+}
+
+func (v *ValueEncodingBackend) List(ctx context.Context, p *Profile) ([]Key, error) {
+	return v.backend.List(ctx, p)
+}
+
+func (v *ValueEncodingBackend) CheckExists(ctx context.Context, p *Profile, key string) (bool, error) {
+	return v.backend.CheckExists(ctx, p, key)
+}
+
+func (v *ValueEncodingBackend) Put(ctx context.Context, p *Profile, key, value string, overwrite bool) error {
+	if isRawValue(ctx) {
+		return v.backend.Put(ctx, p, key, value, overwrite)
+	}
+	encoded, err := EncodeValue(value, v.encoding)
+	if err != nil {
+		return err
+	}
+	return v.backend.Put(ctx, p, key, encoded, overwrite)
+}
+
+func (v *ValueEncodingBackend) Delete(ctx context.Context, p *Profile, key string) error {
+	return v.backend.Delete(ctx, p, key)
+}
+
+func (v *ValueEncodingBackend) DeleteVersion(ctx context.Context, p *Profile, key, version string) error {
+	return DeleteVersion(ctx, v.backend, p, key, version)
+}
+
+func (v *ValueEncodingBackend) ListVersions(ctx context.Context, p *Profile, key string) ([]Version, error) {
+	return ListVersions(ctx, v.backend, p, key)
+}
+
+// GetVersion decodes the value of the version as Get does.
+func (v *ValueEncodingBackend) GetVersion(ctx context.Context, p *Profile, key, version string) ([]byte, error) {
+	value, err := GetVersion(ctx, v.backend, p, key, version)
+	if err != nil || isRawValue(ctx) {
+		return value, err
+	}
+	return DecodeValue(value)
+}
+
+func (v *ValueEncodingBackend) WhoCan(ctx context.Context, p *Profile, key string) ([]AccessBinding, error) {
+	return WhoCan(ctx, v.backend, p, key)
+}
+
+func (v *ValueEncodingBackend) PutBatch(ctx context.Context, p *Profile, values map[string]string, overwrite bool) error {
+	if isRawValue(ctx) {
+		return PutBatch(ctx, v.backend, p, values, overwrite)
+	}
+	encoded := make(map[string]string, len(values))
+	for key, value := range values {
+		encodedValue, err := EncodeValue(value, v.encoding)
+		if err != nil {
+			return err
+		}
+		encoded[key] = encodedValue
+	}
+	return PutBatch(ctx, v.backend, p, encoded, overwrite)
+}
+
+func (v *ValueEncodingBackend) SetParameter(key string, value interface{}) {
+	v.backend.SetParameter(key, value)
+}
+
+func (v *ValueEncodingBackend) Close() error {
+	return v.backend.Close()
+}
+
+// Unwrap returns the decorated backend.
+func (v *ValueEncodingBackend) Unwrap() Backend {
+	return v.backend
+}
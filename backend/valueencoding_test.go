@@ -0,0 +1,58 @@
+package backend
+
+import (
+	"context"
+	"path"
+	"testing"
+)
+
+func TestEncodeDecodeValue(t *testing.T) {
+	value := `{"long":"json json json json json json json json json json"}`
+	for _, encoding := range []string{EncodingNone, EncodingBase64, EncodingGzip} {
+		encoded, err := EncodeValue(value, encoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := EncodingOf([]byte(encoded)); got != encoding {
+			t.Errorf("Expected encoding: %s, got: %s", encoding, got)
+		}
+		decoded, err := DecodeValue([]byte(encoded))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(decoded) != value {
+			t.Errorf("Expected: %s, got: %s (%s)", value, decoded, encoding)
+		}
+	}
+}
+
+func TestDecodeValueUnknownEncoding(t *testing.T) {
+	if _, err := DecodeValue([]byte("kiya-encoding:zip;AAAA")); err == nil {
+		t.Error("Expected error for unknown encoding")
+	}
+}
+
+func TestValueEncodingBackendRawValue(t *testing.T) {
+	ctx := context.Background()
+	store := &versionedStore{FileStore: NewFileStore(path.Join(t.TempDir(), "store"), "test"), versions: map[string][]string{}}
+	b, err := NewValueEncodingBackend(store, "base64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, _ := EncodeValue("secret", "gzip")
+	if err := b.PutBatch(WithRawValue(ctx), nil, map[string]string{"a": encoded}, false); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := store.Get(ctx, nil, "a"); string(stored) != encoded {
+		t.Errorf("raw batch value was encoded again: %q", stored)
+	}
+	b.Put(WithRawValue(ctx), nil, "b", encoded, false)
+	raw, err := b.GetVersion(WithRawValue(ctx), nil, "b", "1")
+	if err != nil || string(raw) != encoded {
+		t.Errorf("got %q %v want the raw value", raw, err)
+	}
+	decoded, err := b.GetVersion(ctx, nil, "b", "1")
+	if err != nil || string(decoded) != "secret" {
+		t.Errorf("got %q %v want secret", decoded, err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// combinedKeyCommands are the commands of a profile that accept a combined profile/key argument.
+// Commands that are not scoped to a profile, such as locate, migrate and config, are never rewritten.
+var combinedKeyCommands = map[string]bool{
+	"get": true, "copy": true, "get-many": true, "rename": true, "move": true, "list": true,
+}
+
+// resolveCombinedKey rewrites arguments of the form [command] [profile/key] [...]
+// into [profile] [command] [key] [...] if the command accepts a key. The rewrite is forced when combined is true,
+// otherwise it is only applied if the first argument is not a profile but the first segment of the key is.
+func resolveCombinedKey(args []string, profiles map[string]backend.Profile, combined bool) ([]string, error) {
+	if len(args) < 2 {
+		return args, nil
+	}
+	if !combinedKeyCommands[args[0]] && !keyArgCommands[args[0]] {
+		return args, nil
+	}
+	if _, ok := profiles[args[0]]; ok && !combined {
+		return args, nil
+	}
+	slash := strings.Index(args[1], "/")
+	if slash == -1 {
+		if combined {
+			return nil, fmt.Errorf("combined key [%s] must be of the form profile/key", args[1])
+		}
+		return args, nil
+	}
+	profileName, key := args[1][:slash], args[1][slash+1:]
+	if _, ok := profiles[profileName]; !ok {
+		if combined {
+			return nil, fmt.Errorf("no such profile [%s] in combined key [%s] please check your .kiya file", profileName, args[1])
+		}
+		return args, nil
+	}
+	return append([]string{profileName, args[0], key}, args[2:]...), nil
+}
+
+// normalizeKey trims leading and trailing slashes of a path-style key and collapses doubled slashes,
+// such that prod//db/ and prod/db are the same key for every backend.
+// Hierarchical parameter names of the ssm backend start with a slash, which is kept.
+func normalizeKey(p *backend.Profile, key string) string {
+	segments := strings.Split(key, "/")
+	kept := segments[:0]
+	for _, each := range segments {
+		if len(each) > 0 {
+			kept = append(kept, each)
+		}
+	}
+	normalized := strings.Join(kept, "/")
+	if p.Backend == "ssm" && strings.HasPrefix(key, "/") && len(normalized) > 0 {
+		return "/" + normalized
+	}
+	return normalized
+}
+
+// keyArgCommands are the commands whose third argument is a key.
+var keyArgCommands = map[string]bool{
+	"put": true, "create": true, "paste": true, "generate": true, "delete": true, "verify": true,
+	"touch": true, "who-can": true, "replace-in": true, "exists": true, "versions": true,
+}
+
+// normalizeKeyArgs returns the arguments [profile] [command] [...] with each key normalized.
+// Filters, as used by list, are not keys and are left unchanged.
+func normalizeKeyArgs(args []string, profiles map[string]backend.Profile) []string {
+	if len(args) < 3 {
+		return args
+	}
+	p, ok := profiles[args[0]]
+	if !ok {
+		return args
+	}
+	normalized := append([]string{}, args...)
+	switch command := args[1]; {
+	case keyArgCommands[command]:
+		normalized[2] = normalizeKey(&p, args[2])
+	case command == "get" || command == "copy" || command == "get-many":
+		// several keys, for get each possibly followed by =field
+		for i := 2; i < len(args); i++ {
+			key, field, hasField := strings.Cut(args[i], "=")
+			normalized[i] = normalizeKey(&p, key)
+			if hasField {
+				normalized[i] += "=" + field
+			}
+		}
+	case command == "rename":
+		normalized[2] = normalizeKey(&p, args[2])
+		if len(args) > 3 {
+			normalized[3] = normalizeKey(&p, args[3])
+		}
+	case command == "move":
+		normalized[2] = normalizeKey(&p, args[2])
+		if len(args) > 4 {
+			target := profiles[args[3]]
+			normalized[4] = normalizeKey(&target, args[4])
+		}
+	}
+	return normalized
+}
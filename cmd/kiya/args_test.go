@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+func TestResolveCombinedKey(t *testing.T) {
+	profiles := map[string]backend.Profile{"prod": {}, "get": {}}
+	for _, each := range []struct {
+		args     []string
+		combined bool
+		want     []string
+	}{
+		{[]string{"prod", "get", "db/password"}, false, []string{"prod", "get", "db/password"}},
+		{[]string{"copy", "prod/db/password"}, false, []string{"prod", "copy", "db/password"}},
+		{[]string{"copy", "test/db/password"}, false, []string{"copy", "test/db/password"}},
+		{[]string{"get", "prod/db/password"}, true, []string{"prod", "get", "db/password"}},
+		{[]string{"move", "prod/db/password", "other"}, false, []string{"prod", "move", "db/password", "other"}},
+		{[]string{"locate", "prod/db/password"}, false, []string{"locate", "prod/db/password"}},
+		{[]string{"locate", "prod/db/password"}, true, []string{"locate", "prod/db/password"}},
+		{[]string{"migrate", "prod/db", "other"}, false, []string{"migrate", "prod/db", "other"}},
+	} {
+		got, err := resolveCombinedKey(each.args, profiles, each.combined)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, each.want) {
+			t.Errorf("resolveCombinedKey(%v, %v) got %v want %v", each.args, each.combined, got, each.want)
+		}
+	}
+	if _, err := resolveCombinedKey([]string{"get", "test/db/password"}, profiles, true); err == nil {
+		t.Error("expected error for unknown profile in combined key")
+	}
+}
+
+func TestNormalizeKey(t *testing.T) {
+	for _, each := range []struct {
+		backend, key, want string
+	}{
+		{"gsm", "prod/db", "prod/db"},
+		{"gsm", "/prod//db/", "prod/db"},
+		{"file", "prod///db//password", "prod/db/password"},
+		{"kms", "//", ""},
+		{"ssm", "/prod//db/", "/prod/db"},
+		{"ssm", "prod/db/", "prod/db"},
+		{"etcd", "prod/db/", "prod/db"},
+	} {
+		if got := normalizeKey(&backend.Profile{Backend: each.backend}, each.key); got != each.want {
+			t.Errorf("normalizeKey(%s, %q) got [%s] want [%s]", each.backend, each.key, got, each.want)
+		}
+	}
+}
+
+func TestNormalizeKeyArgs(t *testing.T) {
+	profiles := map[string]backend.Profile{"prod": {Backend: "gsm"}, "aws": {Backend: "ssm"}}
+	for _, each := range []struct {
+		args, want []string
+	}{
+		{[]string{"prod", "get", "prod//db/"}, []string{"prod", "get", "prod/db"}},
+		{[]string{"prod", "get", "/db/user=username"}, []string{"prod", "get", "db/user=username"}},
+		{[]string{"prod", "list", "prod/db/"}, []string{"prod", "list", "prod/db/"}},
+		{[]string{"prod", "put", "db/", "value/"}, []string{"prod", "put", "db", "value/"}},
+		{[]string{"prod", "move", "db//a", "aws", "/db//a"}, []string{"prod", "move", "db/a", "aws", "/db/a"}},
+		{[]string{"prod", "rename", "db//a/", "/db/b"}, []string{"prod", "rename", "db/a", "db/b"}},
+		{[]string{"migrate", "--from", "prod"}, []string{"migrate", "--from", "prod"}},
+	} {
+		if got := normalizeKeyArgs(each.args, profiles); !reflect.DeepEqual(got, each.want) {
+			t.Errorf("normalizeKeyArgs(%v) got %v want %v", each.args, got, each.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// backendCache memoizes the backends of profiles such that commands using several profiles
+// set up each client, and authenticate, only once per invocation.
+type backendCache struct {
+	mutex    sync.Mutex
+	backends map[string]backend.Backend // profile label -> backend
+}
+
+var backends = &backendCache{backends: map[string]backend.Backend{}}
+
+// add registers an already created backend for the profile label.
+func (c *backendCache) add(label string, b backend.Backend) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.backends[label] = b
+}
+
+// get returns the backend of the profile, creating and decorating it on first use.
+// A new backend that needs a master password prompts for it.
+func (c *backendCache) get(ctx context.Context, p *backend.Profile) (backend.Backend, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if b, ok := c.backends[p.Label]; ok {
+		return b, nil
+	}
+	b, err := getBackend(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	b, err = decorateBackend(b, p)
+	if err != nil {
+		return nil, err
+	}
+	if shouldPromptForPassword(b) {
+		fmt.Printf("Profile [%s]\n", p.Label)
+		if err := setMasterPassword(b); err != nil {
+			return nil, err
+		}
+	}
+	c.backends[p.Label] = b
+	return b, nil
+}
+
+// closeAll closes all backends and returns an error describing those that failed.
+func (c *backendCache) closeAll() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var failures []string
+	for label, each := range c.backends {
+		if err := each.Close(); err != nil {
+			failures = append(failures, fmt.Sprintf("[%s] %s", label, err.Error()))
+		}
+	}
+	c.backends = map[string]backend.Backend{}
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return errors.New(strings.Join(failures, ", "))
+	}
+	return nil
+}
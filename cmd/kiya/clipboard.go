@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/atotto/clipboard"
+)
+
+// clipboardCommand returns the command line to use for writing or reading the clipboard.
+// A flag takes precedence over the environment variable.
+func clipboardCommand(flagValue, envName string) string {
+	if len(flagValue) > 0 {
+		return flagValue
+	}
+	return os.Getenv(envName)
+}
+
+// maxOSC52Payload is the maximum size of the base64 encoded value that most terminals accept in an OSC52 sequence.
+const maxOSC52Payload = 74994
+
+// writeClipboard puts the value on the clipboard using the configured command, OSC52 or else the library.
+func writeClipboard(value string) error {
+	command := clipboardCommand(*oClipboardCmd, "KIYA_CLIPBOARD_CMD")
+	if len(command) == 0 {
+		if useOSC52() {
+			return writeOSC52(value)
+		}
+		return clipboard.WriteAll(value)
+	}
+	cmd, err := newClipboardCmd(command)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = strings.NewReader(value)
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// readClipboard returns the contents of the clipboard using the configured command or else the library.
+func readClipboard() (string, error) {
+	command := clipboardCommand(*oClipboardPasteCmd, "KIYA_CLIPBOARD_PASTE_CMD")
+	if len(command) == 0 {
+		return clipboard.ReadAll()
+	}
+	cmd, err := newClipboardCmd(command)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func newClipboardCmd(command string) (*exec.Cmd, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, errors.New("empty clipboard command")
+	}
+	return exec.Command(fields[0], fields[1:]...), nil
+}
+
+// useOSC52 returns true if requested or if running over SSH without a local clipboard tool.
+func useOSC52() bool {
+	return *oOSC52 || (len(os.Getenv("SSH_TTY")) > 0 && clipboard.Unsupported)
+}
+
+// writeOSC52 emits the OSC52 escape sequence that makes the terminal emulator copy the value.
+func writeOSC52(value string) error {
+	payload := base64.StdEncoding.EncodeToString([]byte(value))
+	if len(payload) > maxOSC52Payload {
+		return fmt.Errorf("value too large for OSC52 clipboard, %d bytes encoded exceeds %d", len(payload), maxOSC52Payload)
+	}
+	var out io.Writer = os.Stderr
+	if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+		defer tty.Close()
+		out = tty
+	}
+	_, err := fmt.Fprintf(out, "\x1b]52;c;%s\a", payload)
+	return err
+}
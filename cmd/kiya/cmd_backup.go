@@ -6,8 +6,9 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
+	"strings"
+	"sync"
 
 	"github.com/kramphub/kiya/backend"
 )
@@ -22,67 +23,108 @@ type Backup struct {
 
 // String returns a base64 String representation of the Backup.
 func (b *Backup) String() string {
-	buf := encodeToJson(b)
+	// encoding a string, a bool and bytes cannot fail
+	buf, _ := json.Marshal(b)
 	return base64.URLEncoding.EncodeToString(buf)
 }
 
-// FromString returns a Backup from a string representation.
-func (b *Backup) FromString(str string) {
+// FromString decodes a Backup from its string representation.
+func (b *Backup) FromString(str string) error {
 	buf, err := base64.URLEncoding.DecodeString(str)
 	if err != nil {
-		log.Fatalf("[FATAL] decode from string failed: %s", err.Error())
+		return fmt.Errorf("decode from string failed, %w", err)
 	}
-
-	err = json.Unmarshal(buf, b)
-	if err != nil {
-		log.Fatalf("[FATAL] decode JSON string failed: %s", err.Error())
+	if err := json.Unmarshal(buf, b); err != nil {
+		return fmt.Errorf("decode JSON string failed, %w", err)
 	}
+	return nil
 }
 
 // SecretAsBytes returns the secret as bytes.
-func (b *Backup) SecretAsBytes() []byte {
+func (b *Backup) SecretAsBytes() ([]byte, error) {
 	buf, err := base64.URLEncoding.DecodeString(b.Secret)
 	if err != nil {
-		log.Fatalf("[FATAL] decode secret base64 string failed: %s", err.Error())
+		return nil, fmt.Errorf("decode secret base64 string failed, %w", err)
 	}
-
-	return buf
+	return buf, nil
 }
 
 // commandBackup creates a backup of all keys in store.
-func commandBackup(ctx context.Context, b backend.Backend, target backend.Profile, filter string) (*Backup, error) {
-	items, err := getItems(ctx, b, target, filter)
+func commandBackup(ctx context.Context, b backend.Backend, target backend.Profile, filter string, excludes []string, concurrency int) (*Backup, error) {
+	items, err := getItems(ctx, b, target, filter, excludes, concurrency)
 	if err != nil {
 		return nil, err
 	}
 
-	buf := encodeToJson(items)
+	buf, err := encodeToJson(items)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Backup{Data: buf}, nil
 }
 
-// getItems returns all keys in store.
-func getItems(ctx context.Context, b backend.Backend, target backend.Profile, filter string) (map[string][]byte, error) {
+// getItems returns all keys in store that are not excluded, fetching at most concurrency values at the same time.
+func getItems(ctx context.Context, b backend.Backend, target backend.Profile, filter string, excludes []string, concurrency int) (map[string][]byte, error) {
 	items := make(map[string][]byte)
 
-	keys := commandList(ctx, b, &target, filter)
+	listed, err := commandList(ctx, b, &target, filter)
+	if err != nil {
+		return nil, err
+	}
+	keys := excludeKeys(listed, excludes)
 	totalKeys := len(keys)
 
-	for i, key := range keys {
+	var mutex sync.Mutex
+	forEachConcurrently(totalKeys, concurrency, func(i int) {
+		key := keys[i]
 		buf, err := b.Get(ctx, &target, key.Name)
+
+		mutex.Lock()
+		defer mutex.Unlock()
 		if err != nil {
-			fmt.Printf("error: get key '%s' failed, %s", key.Name, err.Error())
-			continue
+			fmt.Printf("error: get key '%s' failed, %s", key.Name, scrub(err.Error()))
+			return
 		}
 
 		items[key.Name] = buf
-		fmt.Printf("\rSaved keys: %d/%d", i+1, totalKeys)
-	}
+		fmt.Printf("\rSaved keys: %d/%d", len(items), totalKeys)
+	})
 	fmt.Println()
 
 	return items, nil
 }
 
+// excludeKeys returns the keys that match none of the exclude patterns.
+// A pattern ending with a slash excludes all keys with that prefix, otherwise it is a glob pattern.
+func excludeKeys(keys []backend.Key, excludes []string) []backend.Key {
+	if len(excludes) == 0 {
+		return keys
+	}
+	included := make([]backend.Key, 0, len(keys))
+	for _, each := range keys {
+		if !isExcluded(each.Name, excludes) {
+			included = append(included, each)
+		}
+	}
+	if excluded := len(keys) - len(included); excluded > 0 {
+		fmt.Printf("Excluded %d key(s)\n", excluded)
+	}
+	return included
+}
+
+func isExcluded(key string, excludes []string) bool {
+	for _, pattern := range excludes {
+		if strings.HasSuffix(pattern, "/") && strings.HasPrefix(key, pattern) {
+			return true
+		}
+		if matchKey(key, pattern, matchGlob) {
+			return true
+		}
+	}
+	return false
+}
+
 // getPublicKey returns the public key from file or store.
 func getPublicKey(ctx context.Context, b backend.Backend, target backend.Profile, location, key string) (*rsa.PublicKey, error) {
 	switch location {
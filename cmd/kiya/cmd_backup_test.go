@@ -7,6 +7,7 @@ import (
 	"testing"
 	"testing/fstest"
 
+	"github.com/kramphub/kiya/backend"
 	"github.com/stretchr/testify/require"
 )
 
@@ -19,7 +20,7 @@ func TestBackup_String(t *testing.T) {
 	bakStr := bak.String()
 
 	bak2 := Backup{}
-	bak2.FromString(bakStr)
+	require.NoError(t, bak2.FromString(bakStr))
 
 	if bak.Secret != bak2.Secret {
 		t.Fail()
@@ -54,7 +55,7 @@ func TestBackupWithoutEncryption(t *testing.T) {
 	require.NoError(t, err)
 
 	backup2 := Backup{}
-	backup2.FromString(string(backBuf))
+	require.NoError(t, backup2.FromString(string(backBuf)))
 
 	require.Equal(t, len(backup.Data), len(backup2.Data))
 
@@ -82,7 +83,9 @@ func TestBackupWithEncryption(t *testing.T) {
 		Data:      buf,
 	}
 
-	encryptedBuf, err := encrypt(buf, backup.SecretAsBytes())
+	secretAsBytes, err := backup.SecretAsBytes()
+	require.NoError(t, err)
+	encryptedBuf, err := encrypt(buf, secretAsBytes)
 	require.NoError(t, err)
 	backup.Data = encryptedBuf
 	encryptedSecret, err := encryptSecret(secret, publicKey)
@@ -99,10 +102,12 @@ func TestBackupWithEncryption(t *testing.T) {
 	require.NoError(t, err)
 
 	backup2 := Backup{}
-	backup2.FromString(string(backBuf))
+	require.NoError(t, backup2.FromString(string(backBuf)))
 	secretBuf, err := base64.URLEncoding.DecodeString(secret)
 	require.NoError(t, err)
-	require.False(t, bytes.Equal(backup2.SecretAsBytes(), secretBuf), "the secret may not be encrypted")
+	secretAsBytes, err = backup2.SecretAsBytes()
+	require.NoError(t, err)
+	require.False(t, bytes.Equal(secretAsBytes, secretBuf), "the secret may not be encrypted")
 
 	decryptedSecret, err := decryptSecret(backup2.Secret, privateKey)
 	require.NoError(t, err)
@@ -131,3 +136,11 @@ func setupTestData(t *testing.T) (map[string]interface{}, []byte) {
 	require.NoError(t, err)
 	return input, buf
 }
+
+func TestExcludeKeys(t *testing.T) {
+	keys := []backend.Key{{Name: "app/db"}, {Name: "selftest/a"}, {Name: "tmp/x/y"}, {Name: "tmpfile"}}
+	included := excludeKeys(keys, []string{"selftest/*", "tmp/"})
+	require.Len(t, included, 2)
+	require.Equal(t, "app/db", included[0].Name)
+	require.Equal(t, "tmpfile", included[1].Name)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kramphub/kiya"
+	"github.com/kramphub/kiya/backend"
+	"gopkg.in/yaml.v3"
+)
+
+// secretFieldMarkers identify profile fields whose values are never shown.
+var secretFieldMarkers = []string{"token", "password", "credential", "apikey"}
+
+// commandConfig handles the config subcommands.
+// kiya config show [--format json|yaml] [profile]
+func commandConfig(args []string) error {
+	if len(args) == 0 || args[0] != "show" {
+		return errors.New("usage: kiya config show [--format json|yaml] [profile]")
+	}
+	flags := flag.NewFlagSet("config show", flag.ExitOnError)
+	format := flags.String("format", "json", "output format, json or yaml")
+	flags.Parse(args[1:])
+
+	var resolved interface{}
+	if label := flags.Arg(0); len(label) > 0 {
+		p, ok := kiya.Profiles[label]
+		if !ok {
+			return fmt.Errorf("no such profile [%s] please check your .kiya file", label)
+		}
+		resolved = profileView(p)
+	} else {
+		all := map[string]map[string]interface{}{}
+		for label, p := range kiya.Profiles {
+			all[label] = profileView(p)
+		}
+		resolved = all
+	}
+	if err := writeConfig(os.Stdout, resolved, *format); err != nil {
+		return err
+	}
+	return nil
+}
+
+// profileView returns the fields of a profile as shown to the user, with secret fields redacted.
+func profileView(p backend.Profile) map[string]interface{} {
+	data, _ := json.Marshal(p)
+	view := map[string]interface{}{}
+	json.Unmarshal(data, &view)
+	view["SecretRunes"] = string(p.SecretRunes)
+	for field, value := range view {
+		if value != nil && value != "" && isSecretField(field) {
+			view[field] = redacted
+		}
+	}
+	return view
+}
+
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+	for _, each := range secretFieldMarkers {
+		if strings.Contains(name, each) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeConfig(w io.Writer, v interface{}, format string) error {
+	switch format {
+	case "json":
+		return writeJSON(w, v)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		return enc.Encode(v)
+	}
+	return fmt.Errorf("invalid format [%s], use json or yaml", format)
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+func TestProfileView(t *testing.T) {
+	view := profileView(backend.Profile{Label: "dev", ProjectID: "p", SecretRunes: []rune("abc")})
+	if got, want := view["SecretRunes"], "abc"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := view["ProjectID"], "p"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if !isSecretField("AuthToken") || isSecretField("SecretRunes") {
+		t.Error("unexpected secret field detection")
+	}
+}
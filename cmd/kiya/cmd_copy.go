@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/emicklei/tre"
+	"github.com/kramphub/kiya/backend"
+)
+
+// copyFormat describes how the values of one or more keys are composed into a single string.
+type copyFormat struct {
+	// Field, if not empty, selects a top-level field from each JSON value
+	Field string
+	// Template, if not empty, is a Go template executed with formatData
+	Template string
+	// Separator is used to join multiple values if no template is given
+	Separator string
+}
+
+// formatData is passed to a copy template.
+type formatData struct {
+	Keys   []string
+	Values []string
+	// Value is the joined value of all keys
+	Value string
+}
+
+// parseCopyArgs returns the keys or filter of copy. The -select, -first, -join, -field and -format flags
+// are also accepted after the command, before or after the keys.
+func parseCopyArgs(args []string) ([]string, error) {
+	flags := flag.NewFlagSet("copy", flag.ContinueOnError)
+	flags.BoolVar(oSelect, "select", *oSelect, "list the keys matching the optional filter and pick the one to copy")
+	flags.BoolVar(oFirst, "first", *oFirst, "with -select, copy the only matching key without prompting")
+	flags.StringVar(oJoin, "join", *oJoin, "separator used to join the values of multiple keys")
+	flags.StringVar(oField, "field", *oField, "if not empty then copy this top-level field of a JSON value")
+	flags.StringVar(oFormat, "format", *oFormat, "if not empty then copy the result of this Go template with .Keys, .Values and .Value")
+	keys := []string{}
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return keys, nil
+		}
+		keys = append(keys, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// composeValue fetches the values of all keys and composes them according to the format.
+func composeValue(ctx context.Context, b backend.Backend, target *backend.Profile, keys []string, format copyFormat) (string, error) {
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value, err := b.Get(ctx, target, key)
+		if err != nil {
+			return "", tre.New(err, "get failed", "key", key)
+		}
+		if len(format.Field) > 0 {
+			value, err = extractField(value, format.Field)
+			if err != nil {
+				return "", tre.New(err, "field extraction failed", "key", key, "field", format.Field)
+			}
+		}
+		values = append(values, string(value))
+	}
+	return formatValues(keys, values, format)
+}
+
+// formatValues joins the values or, if set, executes the template of the format.
+func formatValues(keys, values []string, format copyFormat) (string, error) {
+	data := formatData{
+		Keys:   keys,
+		Values: values,
+		Value:  strings.Join(values, format.Separator),
+	}
+	if len(format.Template) == 0 {
+		return data.Value, nil
+	}
+	tmpl, err := template.New("format").Parse(format.Template)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// extractField returns the value of a top-level field of a JSON object.
+// String values are returned without quotes, other values as JSON.
+func extractField(value []byte, field string) ([]byte, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(value, &object); err != nil {
+		return nil, fmt.Errorf("value is not a JSON object, %w", err)
+	}
+	raw, ok := object[field]
+	if !ok {
+		return nil, fmt.Errorf("no such field [%s]", field)
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return []byte(text), nil
+	}
+	return raw, nil
+}
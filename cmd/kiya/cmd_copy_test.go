@@ -0,0 +1,71 @@
+package main
+
+import "testing"
+
+func TestExtractField(t *testing.T) {
+	value := []byte(`{"user":"admin","port":5432}`)
+	for field, want := range map[string]string{"user": "admin", "port": "5432"} {
+		got, err := extractField(value, field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("got [%s] want [%s]", got, want)
+		}
+	}
+	if _, err := extractField(value, "missing"); err == nil {
+		t.Error("expected error for missing field")
+	}
+}
+
+func TestFormatValues(t *testing.T) {
+	keys, values := []string{"db/user", "db/pass"}, []string{"admin", "secret"}
+	got, err := formatValues(keys, values, copyFormat{Separator: ":"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "admin:secret"; got != want {
+		t.Errorf("got [%s] want [%s]", got, want)
+	}
+	got, err = formatValues(keys, values, copyFormat{Template: `{"u":"{{index .Values 0}}","p":"{{index .Values 1}}"}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"u":"admin","p":"secret"}`; got != want {
+		t.Errorf("got [%s] want [%s]", got, want)
+	}
+}
+
+func TestParseCopyArgsWithSelect(t *testing.T) {
+	defer func() { *oSelect, *oFirst = false, false }()
+	keys, err := parseCopyArgs([]string{"--select", "--first", "db"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !*oSelect || !*oFirst {
+		t.Error("expected select and first to be set")
+	}
+	if len(keys) != 1 || keys[0] != "db" {
+		t.Errorf("got %v want [db]", keys)
+	}
+}
+
+func TestParseCopyArgsWithTrailingFlags(t *testing.T) {
+	defer func(join string) { *oJoin = join }(*oJoin)
+	keys, err := parseCopyArgs([]string{"db/user", "db/host", "--join", "@"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != "db/user" || keys[1] != "db/host" {
+		t.Errorf("got %v want [db/user db/host]", keys)
+	}
+	if *oJoin != "@" {
+		t.Errorf("got join [%s] want [@]", *oJoin)
+	}
+	if _, err := parseCopyArgs([]string{"db/user", "--join"}); err == nil {
+		t.Error("expected error for a flag without value")
+	}
+	if _, err := parseCopyArgs([]string{"db/user", "--unknown"}); err == nil {
+		t.Error("expected error for an unknown flag")
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// commandCreate stores the value of a new key; it never prompts and never overwrites.
+// It returns an error wrapping backend.ErrKeyExists if the key already exists.
+func commandCreate(ctx context.Context, b backend.Backend, target *backend.Profile, key, value string) error {
+	registerSecret(value)
+	if err := target.Policy.Validate(value); err != nil {
+		return fmt.Errorf("[%s] violates the policy of [%s]: %w", key, target.Label, err)
+	}
+	exists, err := b.CheckExists(ctx, target, key)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%s %w in [%s]", key, backend.ErrKeyExists, target.Label)
+	}
+	// backends that check on write also fail if the key was created meanwhile
+	return b.Put(ctx, target, key, value, false)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+func TestCreateFailsIfKeyExists(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+
+	if err := commandCreate(ctx, b, target, "a", "first"); err != nil {
+		t.Fatal(err)
+	}
+	if err := commandCreate(ctx, b, target, "a", "second"); !errors.Is(err, backend.ErrKeyExists) {
+		t.Fatalf("expected ErrKeyExists, got %v", err)
+	}
+	if value, _ := b.Get(ctx, target, "a"); string(value) != "first" {
+		t.Errorf("value was overwritten, got %s", value)
+	}
+}
+
+func TestCreateRejectsPolicyViolation(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	target.Policy = &backend.Policy{MinLength: 8}
+
+	if err := commandCreate(ctx, b, target, "a", "short"); err == nil {
+		t.Fatal("expected policy violation")
+	}
+	if exists, _ := b.CheckExists(ctx, target, "a"); exists {
+		t.Error("value was stored despite policy violation")
+	}
+}
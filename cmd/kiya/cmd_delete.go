@@ -2,21 +2,37 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 
 	"github.com/kramphub/kiya/backend"
 )
 
 // commandDelete deletes a stored key
-func commandDelete(ctx context.Context, b backend.Backend, target *backend.Profile, key string) {
-	if promptForYes(fmt.Sprintf("Are you sure to delete [%s] from [%s] (y/N)? ", key, target.Label)) {
-		if err := b.Delete(ctx, target, key); err != nil {
-			fmt.Printf("failed to delete [%s] from [%s] because [%v]\n", key, target.Label, err)
-		} else {
-			fmt.Printf("Successfully deleted [%s] from [%s]\n", key, target.Label)
-		}
-	} else {
-		log.Fatalln("delete aborted")
+func commandDelete(ctx context.Context, b backend.Backend, target *backend.Profile, key string) error {
+	if len(*oKeyVersion) > 0 {
+		return commandDeleteVersion(ctx, b, target, key, *oKeyVersion)
 	}
+	if !promptForYes(fmt.Sprintf("Are you sure to delete [%s] from [%s] (y/N)? ", key, target.Label)) {
+		return errors.New("delete aborted")
+	}
+	if err := b.Delete(ctx, target, key); err != nil {
+		return deniedOr(err, "deleting", key, target,
+			fmt.Errorf("failed to delete [%s] from [%s] because [%v]", key, target.Label, err))
+	}
+	fmt.Printf("Successfully deleted [%s] from [%s]\n", key, target.Label)
+	return nil
+}
+
+// commandDeleteVersion destroys a single version of a stored key
+func commandDeleteVersion(ctx context.Context, b backend.Backend, target *backend.Profile, key, version string) error {
+	if !promptForYes(fmt.Sprintf("Are you sure to destroy version [%s] of [%s] from [%s] (y/N)? ", version, key, target.Label)) {
+		return errors.New("delete aborted")
+	}
+	if err := backend.DeleteVersion(ctx, b, target, key, version); err != nil {
+		return deniedOr(err, "deleting", key, target,
+			fmt.Errorf("failed to destroy version [%s] of [%s] from [%s] because [%v]", version, key, target.Label, err))
+	}
+	fmt.Printf("Successfully destroyed version [%s] of [%s] from [%s]\n", version, key, target.Label)
+	return nil
 }
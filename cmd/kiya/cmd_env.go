@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/emicklei/tre"
+	"github.com/kramphub/kiya/backend"
+)
+
+// commandEnv writes a line for each key matching the filter, either as dotenv KEY=VALUE or as shell export KEY='VALUE'.
+// kiya [profile] env [|filter-term]
+func commandEnv(ctx context.Context, b backend.Backend, target *backend.Profile, filter string, export bool, concurrency int, w io.Writer) error {
+	variables, err := fetchEnv(ctx, b, target, filter, concurrency)
+	if err != nil {
+		return deniedOr(err, "reading", "", target, tre.New(err, "env failed"))
+	}
+	for _, each := range variables {
+		fmt.Fprintln(w, envLine(each.name, each.value, export))
+	}
+	return nil
+}
+
+// envVariable is a key as environment variable with its value.
+type envVariable struct {
+	key, name, value string
+}
+
+// fetchEnv returns the keys matching the filter as environment variables, sorted by key.
+// Errors are returned rather than fatal such that run can keep polling after a transient failure.
+func fetchEnv(ctx context.Context, b backend.Backend, target *backend.Profile, filter string, concurrency int) ([]envVariable, error) {
+	all, err := b.List(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("list failed, %w", err)
+	}
+	keys := filterKeys(all, filter)
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	values := make([][]byte, len(keys))
+	errs := make([]error, len(keys))
+	forEachConcurrently(len(keys), concurrency, func(i int) {
+		values[i], errs[i] = b.Get(ctx, target, keys[i].Name)
+	})
+	variables := make([]envVariable, 0, len(keys))
+	for i, each := range keys {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("get %s failed, %w", each.Name, errs[i])
+		}
+		registerSecret(string(values[i]))
+		variables = append(variables, envVariable{key: each.Name, name: envName(each.Name), value: string(values[i])})
+		backend.Zero(values[i])
+	}
+	return variables, nil
+}
+
+// envName returns the key as environment variable name: uppercase with each character that is not a letter or digit replaced by an underscore.
+func envName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(key))
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+func envLine(name, value string, export bool) string {
+	if export {
+		// single quotes prevent any expansion by the shell; an embedded single quote ends, escapes and reopens the quoting
+		return fmt.Sprintf("export %s='%s'", name, strings.ReplaceAll(value, "'", `'\''`))
+	}
+	if strings.ContainsAny(value, " \t\n\r\"'#$\\") {
+		replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`)
+		return fmt.Sprintf(`%s="%s"`, name, replacer.Replace(value))
+	}
+	return name + "=" + value
+}
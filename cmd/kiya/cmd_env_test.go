@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+func TestEnvName(t *testing.T) {
+	for _, each := range []struct{ key, want string }{
+		{"db/password", "DB_PASSWORD"},
+		{"api-key.v2", "API_KEY_V2"},
+		{"1st", "_1ST"},
+	} {
+		if got := envName(each.key); got != each.want {
+			t.Errorf("got [%s] want [%s]", got, each.want)
+		}
+	}
+}
+
+func TestEnvLine(t *testing.T) {
+	for _, each := range []struct {
+		value  string
+		export bool
+		want   string
+	}{
+		{"secret", false, "K=secret"},
+		{"two words", false, `K="two words"`},
+		{"it's $HOME", true, `export K='it'\''s $HOME'`},
+		{"plain", true, "export K='plain'"},
+	} {
+		if got := envLine("K", each.value, each.export); got != each.want {
+			t.Errorf("got [%s] want [%s]", got, each.want)
+		}
+	}
+}
+
+// failingList is a backend whose List fails.
+type failingList struct {
+	backend.Backend
+}
+
+func (failingList) List(ctx context.Context, p *backend.Profile) ([]backend.Key, error) {
+	return nil, errors.New("unavailable")
+}
+
+func TestFetchEnvReturnsListError(t *testing.T) {
+	if _, err := fetchEnv(context.Background(), failingList{}, &backend.Profile{}, "", 1); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestFetchEnvFilter(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "db/password", "secret", false)
+	b.Put(ctx, target, "api/token", "other", false)
+	variables, err := fetchEnv(ctx, b, target, "db", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variables) != 1 || variables[0].name != "DB_PASSWORD" || variables[0].value != "secret" {
+		t.Errorf("got %v", variables)
+	}
+}
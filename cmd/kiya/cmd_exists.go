@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// commandExists returns exit code 0 if the key exists and 1 if it does not.
+// Nothing is written unless verbose is set.
+// kiya [profile] exists [key]
+func commandExists(ctx context.Context, b backend.Backend, target *backend.Profile, key string, verbose bool, w io.Writer) (int, error) {
+	exists, err := b.CheckExists(ctx, target, key)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		if verbose {
+			fmt.Fprintf(w, "[%s] does not exist in [%s]\n", key, target.Label)
+		}
+		return 1, nil
+	}
+	if verbose {
+		fmt.Fprintf(w, "[%s] exists in [%s]\n", key, target.Label)
+	}
+	return 0, nil
+}
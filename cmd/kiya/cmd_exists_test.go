@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExistsExitCode(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "db/password", "secret", false)
+
+	for _, each := range []struct {
+		key     string
+		verbose bool
+		code    int
+		output  string
+	}{
+		{"db/password", false, 0, ""},
+		{"db/missing", false, 1, ""},
+		{"db/password", true, 0, "[db/password] exists in [test]\n"},
+		{"db/missing", true, 1, "[db/missing] does not exist in [test]\n"},
+	} {
+		out := new(strings.Builder)
+		code, err := commandExists(ctx, b, target, each.key, each.verbose, out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != each.code || out.String() != each.output {
+			t.Errorf("%s verbose=%v got %d %q want %d %q", each.key, each.verbose, code, out.String(), each.code, each.output)
+		}
+	}
+}
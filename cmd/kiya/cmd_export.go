@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/kramphub/kiya/backend"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported values for the format of export.
+const (
+	exportNDJSON = "ndjson"
+	exportEnv    = "env"
+	exportJSON   = "json"
+	exportYAML   = "yaml"
+)
+
+// exportRecord is a single key in an export, its value is only included on request.
+type exportRecord struct {
+	Name        string    `json:"name"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Owner       string    `json:"owner,omitempty"`
+	Info        string    `json:"info,omitempty"`
+	ContentType string    `json:"contentType,omitempty"`
+	Value       *string   `json:"value,omitempty"`
+}
+
+// commandExport writes the keys matching the filter in the format.
+// The env, json and yaml formats consist of values and therefore require includeValues;
+// ndjson only contains the values if includeValues is true.
+// kiya [profile] export [|filter-term]
+func commandExport(ctx context.Context, b backend.Backend, target *backend.Profile, filter, format string, includeValues bool, concurrency int, w io.Writer) error {
+	if format != exportNDJSON && !includeValues {
+		return fmt.Errorf("export format [%s] writes secret values, add -include-values to confirm", format)
+	}
+	switch format {
+	case exportNDJSON:
+		return exportInventory(ctx, b, target, filter, includeValues, w)
+	case exportEnv:
+		return exportEnvFile(ctx, b, target, filter, concurrency, w)
+	case exportJSON, exportYAML:
+		return exportMap(ctx, b, target, filter, format, concurrency, w)
+	}
+	return fmt.Errorf("unknown export format [%s], use %s, %s, %s or %s", format, exportNDJSON, exportEnv, exportJSON, exportYAML)
+}
+
+// exportInventory writes a JSON object per key, one per line, as soon as it is available.
+// Values are only fetched and written if includeValues is true.
+func exportInventory(ctx context.Context, b backend.Backend, target *backend.Profile, filter string, includeValues bool, w io.Writer) error {
+	keys, err := commandList(ctx, b, target, filter)
+	if err != nil {
+		return err
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	// one object per line, never indented
+	enc := json.NewEncoder(w)
+	for _, each := range keys {
+		record := exportRecord{
+			Name:        each.Name,
+			CreatedAt:   each.CreatedAt,
+			Owner:       each.Owner,
+			Info:        each.Info,
+			ContentType: each.ContentType,
+		}
+		if includeValues {
+			data, err := b.Get(ctx, target, each.Name)
+			if err != nil {
+				return fmt.Errorf("get %s failed, %w", each.Name, err)
+			}
+			value := string(data)
+			backend.Zero(data)
+			registerSecret(value)
+			record.Value = &value
+		}
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportEnvFile writes a dotenv line for each key, named as by env, e.g. db/password becomes DB_PASSWORD.
+// Keys that result in the same name are an error.
+func exportEnvFile(ctx context.Context, b backend.Backend, target *backend.Profile, filter string, concurrency int, w io.Writer) error {
+	variables, err := fetchEnv(ctx, b, target, filter, concurrency)
+	if err != nil {
+		return err
+	}
+	seen := map[string]string{}
+	for _, each := range variables {
+		if other, ok := seen[each.name]; ok {
+			return fmt.Errorf("keys [%s] and [%s] are both exported as %s", other, each.key, each.name)
+		}
+		seen[each.name] = each.key
+	}
+	for _, each := range variables {
+		fmt.Fprintln(w, envLine(each.name, each.value, false))
+	}
+	return nil
+}
+
+// exportMap writes a flat JSON object or YAML mapping of each key to its value.
+func exportMap(ctx context.Context, b backend.Backend, target *backend.Profile, filter, format string, concurrency int, w io.Writer) error {
+	keys, err := commandList(ctx, b, target, filter)
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(keys))
+	for i, each := range keys {
+		names[i] = each.Name
+	}
+	values, err := fetchMany(ctx, b, target, names, concurrency)
+	if err != nil {
+		return err
+	}
+	flat := make(map[string]string, len(values))
+	for _, each := range values {
+		flat[each.Key] = each.Value
+	}
+	if format == exportJSON {
+		return writeJSON(w, flat)
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(flat); err != nil {
+		return err
+	}
+	return enc.Close()
+}
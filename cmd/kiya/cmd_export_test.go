@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExportNDJSON(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "b", "second", false)
+	b.Put(ctx, target, "a", "first", false)
+
+	out := new(bytes.Buffer)
+	if err := commandExport(ctx, b, target, "", exportNDJSON, false, 1, out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], `{"name":"a"`) {
+		t.Fatalf("unexpected export %s", out)
+	}
+	if strings.Contains(out.String(), "first") {
+		t.Errorf("value exported without include values: %s", out)
+	}
+
+	out.Reset()
+	if err := commandExport(ctx, b, target, "a", exportNDJSON, true, 1, out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"value":"first"`) {
+		t.Errorf("missing value: %s", out)
+	}
+}
+
+func TestExportEnvQuoting(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "db/url", "postgres://host/db?sslmode=require", false)
+	b.Put(ctx, target, "db/password", "with space", false)
+	b.Put(ctx, target, "tls/key", "line1\nline2", false)
+	b.Put(ctx, target, "quote", `say "hi"`, false)
+
+	out := new(bytes.Buffer)
+	if err := commandExport(ctx, b, target, "", exportEnv, true, 2, out); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		`DB_PASSWORD="with space"`,
+		`DB_URL=postgres://host/db?sslmode=require`,
+		`QUOTE="say \"hi\""`,
+		`TLS_KEY="line1\nline2"`,
+	}, "\n") + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExportEnvRejectsNameCollision(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "db/user", "a", false)
+	b.Put(ctx, target, "db-user", "b", false)
+
+	err := commandExport(ctx, b, target, "", exportEnv, true, 1, new(bytes.Buffer))
+	if err == nil || !strings.Contains(err.Error(), "both exported as DB_USER") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestExportJSONAndYAML(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "db/user", "admin", false)
+	b.Put(ctx, target, "db/password", "a=b", false)
+
+	out := new(bytes.Buffer)
+	if err := commandExport(ctx, b, target, "", exportJSON, true, 2, out); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), `{"db/password":"a=b","db/user":"admin"}`+"\n"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+	out.Reset()
+	if err := commandExport(ctx, b, target, "", exportYAML, true, 2, out); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "db/password: a=b\ndb/user: admin\n"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestExportValuesRequireIncludeValues(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "db/password", "secret", false)
+
+	for _, format := range []string{exportEnv, exportJSON, exportYAML} {
+		out := new(bytes.Buffer)
+		err := commandExport(ctx, b, target, "", format, false, 1, out)
+		if err == nil || !strings.Contains(err.Error(), "-include-values") {
+			t.Errorf("%s: unexpected error %v", format, err)
+		}
+		if out.Len() > 0 {
+			t.Errorf("%s: unexpected output %q", format, out.String())
+		}
+	}
+}
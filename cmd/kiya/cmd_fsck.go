@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// commandFsck tries to get the value of every key in the profile, at most concurrency at the same time,
+// and reports the keys that cannot be retrieved. It returns false if any key failed.
+// Once the context is done, e.g. by -timeout, the remaining keys are reported as failed without a Get.
+func commandFsck(ctx context.Context, b backend.Backend, target *backend.Profile, concurrency int) (bool, error) {
+	keys, err := commandList(ctx, b, target, "")
+	if err != nil {
+		return false, err
+	}
+
+	var mutex sync.Mutex
+	failures := map[string]error{}
+	forEachConcurrently(len(keys), concurrency, func(i int) {
+		err := ctx.Err()
+		if err == nil {
+			var value []byte
+			value, err = b.Get(ctx, target, keys[i].Name)
+			backend.Zero(value)
+		}
+		if err != nil {
+			mutex.Lock()
+			failures[keys[i].Name] = err
+			mutex.Unlock()
+		}
+	})
+
+	failed := make([]string, 0, len(failures))
+	for each := range failures {
+		failed = append(failed, each)
+	}
+	sort.Strings(failed)
+	for _, each := range failed {
+		fmt.Printf("[FAIL] %s: %v\n", each, failures[each])
+	}
+
+	result := "PASS"
+	if len(failed) > 0 {
+		result = "FAIL"
+	}
+	fmt.Printf("%s: checked %d key(s) in [%s], %d ok, %d failed\n", result, len(keys), target.Label, len(keys)-len(failed), len(failed))
+	return len(failed) == 0, nil
+}
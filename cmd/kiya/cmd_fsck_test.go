@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFsckReportsUndecryptableKey(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "a", "1", false)
+	b.SetParameter("masterPassword", []byte("other"))
+	b.Put(ctx, target, "b", "2", false)
+
+	ok, err := commandFsck(ctx, b, target, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Error("expected fsck to fail on a key encrypted with another password")
+	}
+	b.Delete(ctx, target, "a")
+	if ok, _ := commandFsck(ctx, b, target, 2); !ok {
+		t.Error("expected fsck to pass")
+	}
+}
+
+func TestFsckStopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "a", "1", false)
+	cancel()
+
+	ok, err := commandFsck(ctx, b, target, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Error("expected fsck to fail if its deadline passed")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// defaultGenerateLength is the length used if none is entered interactively.
+const defaultGenerateLength = 20
+
+// recommendedEntropy is the estimated entropy in bits below which generate warns, unless -min-entropy refuses it.
+const recommendedEntropy = 80
+
+// runePreset is a named set of runes to generate a secret from.
+type runePreset struct {
+	name  string
+	runes string
+}
+
+// runePresets are offered by the interactive generate, after the runes of the profile.
+var runePresets = []runePreset{
+	{"alphanumeric", "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"},
+	{"alphanumeric and symbols", "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!#$%&()*+,-./:;<=>?@[]^_{|}~"},
+	{"hex", "0123456789abcdef"},
+	{"digits only", "0123456789"},
+}
+
+// promptForGenerate asks for the length of a secret and the set of runes to use.
+// The first choice, also the default, keeps the runes of the profile which can be empty.
+func promptForGenerate(in io.Reader, out io.Writer, profileRunes []rune) (int, []rune, error) {
+	reader := bufio.NewReader(in)
+	fmt.Fprintf(out, "Length of secret [%d]: ", defaultGenerateLength)
+	line, _ := reader.ReadString('\n')
+	length := defaultGenerateLength
+	if answer := strings.TrimSpace(line); len(answer) > 0 {
+		n, err := strconv.Atoi(answer)
+		if err != nil || n < 1 {
+			return 0, nil, fmt.Errorf("invalid length [%s]", answer)
+		}
+		length = n
+	}
+
+	fmt.Fprintln(out, "Characters:")
+	fmt.Fprintln(out, "  1) profile")
+	for i, each := range runePresets {
+		fmt.Fprintf(out, "  %d) %s\n", i+2, each.name)
+	}
+	fmt.Fprint(out, "Choice [1]: ")
+	line, _ = reader.ReadString('\n')
+	answer := strings.TrimSpace(line)
+	if len(answer) == 0 || answer == "1" {
+		return length, profileRunes, nil
+	}
+	choice, err := strconv.Atoi(answer)
+	if err != nil || choice < 2 || choice > len(runePresets)+1 {
+		return 0, nil, fmt.Errorf("invalid choice [%s]", answer)
+	}
+	return length, []rune(runePresets[choice-2].runes), nil
+}
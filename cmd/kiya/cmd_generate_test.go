@@ -0,0 +1,29 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPromptForGenerate(t *testing.T) {
+	length, runes, err := promptForGenerate(strings.NewReader("32\n4\n"), io.Discard, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if length != 32 || string(runes) != "0123456789abcdef" {
+		t.Errorf("got %d [%s]", length, string(runes))
+	}
+
+	length, runes, err = promptForGenerate(strings.NewReader("\n\n"), io.Discard, []rune("xyz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if length != defaultGenerateLength || string(runes) != "xyz" {
+		t.Errorf("got %d [%s]", length, string(runes))
+	}
+
+	if _, _, err := promptForGenerate(strings.NewReader("abc\n"), io.Discard, nil); err == nil {
+		t.Error("expected error for invalid length")
+	}
+}
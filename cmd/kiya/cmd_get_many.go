@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// keyValue is a key with its value as written by get-many.
+type keyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// commandGetMany fetches the values of all keys concurrently and writes them in the order of the keys,
+// as key=value lines or, if output is json, as a JSON array. Nothing is written if any key fails;
+// the error then describes each failed key.
+// kiya [profile] get-many [key] [key...]
+func commandGetMany(ctx context.Context, b backend.Backend, target *backend.Profile, keys []string, concurrency int, output string, w io.Writer) error {
+	if len(keys) == 0 {
+		return errors.New("missing keys, use kiya [profile] get-many [key] [key...]")
+	}
+	values, err := fetchMany(ctx, b, target, keys, concurrency)
+	if err != nil {
+		return err
+	}
+	if output == outputJSON {
+		return writeJSON(w, values)
+	}
+	for _, each := range values {
+		fmt.Fprintln(w, envLine(each.Key, each.Value, false))
+	}
+	return nil
+}
+
+// fetchMany returns the values of the keys, in the same order, using at most concurrency lookups at a time.
+func fetchMany(ctx context.Context, b backend.Backend, target *backend.Profile, keys []string, concurrency int) ([]keyValue, error) {
+	values := make([][]byte, len(keys))
+	errs := make([]error, len(keys))
+	forEachConcurrently(len(keys), concurrency, func(i int) {
+		values[i], errs[i] = b.Get(ctx, target, keys[i])
+	})
+	var failures []string
+	result := make([]keyValue, 0, len(keys))
+	for i, each := range keys {
+		if errs[i] != nil {
+			failures = append(failures, fmt.Sprintf("[%s] %v", each, errs[i]))
+			continue
+		}
+		registerSecret(string(values[i]))
+		result = append(result, keyValue{Key: each, Value: string(values[i])})
+		backend.Zero(values[i])
+	}
+	if len(failures) > 0 {
+		return nil, fmt.Errorf("%d of %d keys failed: %s", len(failures), len(keys), strings.Join(failures, ", "))
+	}
+	return result, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGetManyPreservesOrder(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "db/user", "admin", false)
+	b.Put(ctx, target, "db/password", "s3cret pw", false)
+	b.Put(ctx, target, "api/token", "abc", false)
+
+	out := new(strings.Builder)
+	if err := commandGetMany(ctx, b, target, []string{"db/user", "api/token", "db/password"}, 2, outputTable, out); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "db/user=admin\napi/token=abc\ndb/password=\"s3cret pw\"\n"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+
+	out.Reset()
+	if err := commandGetMany(ctx, b, target, []string{"api/token", "db/user"}, 2, outputJSON, out); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), `[{"key":"api/token","value":"abc"},{"key":"db/user","value":"admin"}]`+"\n"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestGetManyReportsEachFailedKey(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "db/user", "admin", false)
+
+	out := new(strings.Builder)
+	err := commandGetMany(ctx, b, target, []string{"missing/a", "db/user", "missing/b"}, 2, outputTable, out)
+	if err == nil || !strings.Contains(err.Error(), "2 of 3 keys failed") ||
+		!strings.Contains(err.Error(), "[missing/a]") || !strings.Contains(err.Error(), "[missing/b]") {
+		t.Errorf("unexpected error %v", err)
+	}
+	if out.Len() > 0 {
+		t.Errorf("expected no output, got %q", out.String())
+	}
+}
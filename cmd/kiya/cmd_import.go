@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// Supported values for the format of import.
+const (
+	importEnv  = "env"
+	importJSON = "json"
+)
+
+// importSummary counts the outcome of an import per key.
+type importSummary struct {
+	Created, Overwritten, Skipped, Failed int
+}
+
+func (s importSummary) String() string {
+	return fmt.Sprintf("%d created, %d overwritten, %d skipped, %d failed", s.Created, s.Overwritten, s.Skipped, s.Failed)
+}
+
+// importFormat returns the format, or if empty the format by the extension of the filename: json for .json, env otherwise.
+func importFormat(format, filename string) (string, error) {
+	if len(format) == 0 {
+		if strings.EqualFold(filepath.Ext(filename), ".json") {
+			return importJSON, nil
+		}
+		return importEnv, nil
+	}
+	if format != importEnv && format != importJSON {
+		return "", fmt.Errorf("unknown import format [%s], use %s or %s", format, importEnv, importJSON)
+	}
+	return format, nil
+}
+
+// parseImport returns the key value pairs of the input, in the order of an env file or sorted by key for JSON.
+func parseImport(r io.Reader, format string) ([]keyValue, error) {
+	if format == importJSON {
+		return parseJSONImport(r)
+	}
+	return parseEnvImport(r)
+}
+
+// parseJSONImport returns the fields of a flat JSON object, whose values must be strings.
+func parseJSONImport(r io.Reader) ([]keyValue, error) {
+	var object map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&object); err != nil {
+		return nil, fmt.Errorf("input is not a JSON object, %w", err)
+	}
+	pairs := make([]keyValue, 0, len(object))
+	for key, value := range object {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value of [%s] is not a string", key)
+		}
+		pairs = append(pairs, keyValue{Key: key, Value: s})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+	return pairs, nil
+}
+
+// parseEnvImport returns the variables of a dotenv file. Blank lines and comments are ignored,
+// an export prefix is allowed and values can be single or double quoted.
+func parseEnvImport(r io.Reader) ([]keyValue, error) {
+	var pairs []keyValue
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimSpace(strings.TrimPrefix(text, "export "))
+		key, value, ok := strings.Cut(text, "=")
+		key = strings.TrimSpace(key)
+		if !ok || len(key) == 0 {
+			return nil, fmt.Errorf("line %d is not of the form KEY=VALUE", line)
+		}
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		pairs = append(pairs, keyValue{Key: key, Value: value})
+	}
+	return pairs, scanner.Err()
+}
+
+// parseEnvValue returns the unquoted value. Double quoted values support the escapes written by export,
+// single quoted values are literal except for the escaped single quote written by env -export.
+// An unquoted value ends at a comment that is preceded by whitespace.
+func parseEnvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		end := closingQuote(value)
+		if end == -1 {
+			return "", fmt.Errorf("missing closing double quote")
+		}
+		replacer := strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n", `\r`, "\r", `\$`, "$")
+		return replacer.Replace(value[1:end]), nil
+	case strings.HasPrefix(value, "'"):
+		inner := strings.ReplaceAll(value[1:], `'\''`, "\x00")
+		end := strings.Index(inner, "'")
+		if end == -1 {
+			return "", fmt.Errorf("missing closing single quote")
+		}
+		return strings.ReplaceAll(inner[:end], "\x00", "'"), nil
+	}
+	if i := strings.Index(value, " #"); i != -1 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
+
+// closingQuote returns the index of the double quote that ends the value, skipping escaped characters, or -1.
+func closingQuote(value string) int {
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// commandImport stores each pair. Keys are normalized as on the command line. Existing keys are only replaced
+// if overwrite is true, otherwise they are skipped with a warning. Values that violate the policy of the profile fail.
+// Progress and failures are written to w.
+// kiya [profile] import [|file]
+func commandImport(ctx context.Context, b backend.Backend, target *backend.Profile, pairs []keyValue, overwrite bool, w io.Writer) (importSummary, error) {
+	var summary importSummary
+	for _, each := range pairs {
+		registerSecret(each.Value)
+	}
+	existing, err := existingKeys(ctx, b, target)
+	if err != nil {
+		return summary, err
+	}
+	for _, each := range pairs {
+		key := normalizeKey(target, each.Key)
+		if len(key) == 0 {
+			fmt.Fprintf(w, "failed to import [%s], it is not a valid key\n", each.Key)
+			summary.Failed++
+			continue
+		}
+		exists := existing[key]
+		if exists && !overwrite {
+			fmt.Fprintf(w, "[WARN] skipped [%s], it already exists in [%s], use --overwrite to replace it\n", key, target.Label)
+			summary.Skipped++
+			continue
+		}
+		if err := target.Policy.Validate(each.Value); err != nil {
+			fmt.Fprintf(w, "failed to import [%s], it violates the policy of [%s]: %v\n", key, target.Label, err)
+			summary.Failed++
+			continue
+		}
+		if err := b.Put(ctx, target, key, each.Value, exists); err != nil {
+			fmt.Fprintf(w, "failed to import [%s] because [%v]\n", key, scrub(err.Error()))
+			summary.Failed++
+			continue
+		}
+		// a later pair with the same normalized key is a conflict too
+		existing[key] = true
+		if exists {
+			summary.Overwritten++
+		} else {
+			summary.Created++
+		}
+	}
+	return summary, nil
+}
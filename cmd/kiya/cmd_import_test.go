@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+func TestParseEnvImport(t *testing.T) {
+	input := `# comment
+export A=plain
+B = "two words\nand \"quotes\" \$HOME"
+
+C='it'\''s literal \n'
+D=value # trailing comment
+E=
+`
+	pairs, err := parseEnvImport(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []keyValue{
+		{Key: "A", Value: "plain"},
+		{Key: "B", Value: "two words\nand \"quotes\" $HOME"},
+		{Key: "C", Value: `it's literal \n`},
+		{Key: "D", Value: "value"},
+		{Key: "E", Value: ""},
+	}
+	if len(pairs) != len(want) {
+		t.Fatalf("got %v want %v", pairs, want)
+	}
+	for i := range want {
+		if pairs[i] != want[i] {
+			t.Errorf("got %q want %q", pairs[i], want[i])
+		}
+	}
+}
+
+func TestParseEnvImportRoundTrip(t *testing.T) {
+	value := "a \"b\"\n$c \\ d"
+	pairs, err := parseEnvImport(strings.NewReader(envLine("K", value, false)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 1 || pairs[0].Value != value {
+		t.Errorf("got %q want %q", pairs, value)
+	}
+}
+
+func TestParseEnvImportInvalid(t *testing.T) {
+	for _, each := range []string{"NOVALUE", "=x", `A="open`, "A='open"} {
+		if _, err := parseEnvImport(strings.NewReader(each)); err == nil {
+			t.Errorf("expected error for %q", each)
+		}
+	}
+}
+
+func TestParseJSONImport(t *testing.T) {
+	pairs, err := parseJSONImport(strings.NewReader(`{"b/key":"2","a":"1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 2 || pairs[0] != (keyValue{Key: "a", Value: "1"}) || pairs[1] != (keyValue{Key: "b/key", Value: "2"}) {
+		t.Errorf("got %v", pairs)
+	}
+	if _, err := parseJSONImport(strings.NewReader(`{"a":1}`)); err == nil {
+		t.Error("expected error for a value that is not a string")
+	}
+}
+
+func TestImportFormat(t *testing.T) {
+	for _, each := range []struct{ format, filename, want string }{
+		{"", "secrets.json", importJSON},
+		{"", ".env", importEnv},
+		{"", "", importEnv},
+		{"json", "secrets.env", importJSON},
+	} {
+		if got, err := importFormat(each.format, each.filename); err != nil || got != each.want {
+			t.Errorf("importFormat(%q,%q) got %s %v want %s", each.format, each.filename, got, err, each.want)
+		}
+	}
+	if _, err := importFormat("yaml", ""); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	target.Policy = &backend.Policy{MinLength: 2}
+	b.Put(ctx, target, "existing", "old", false)
+
+	pairs := []keyValue{{Key: "new", Value: "created"}, {Key: "existing", Value: "new"}, {Key: "short", Value: "x"}}
+	out := new(bytes.Buffer)
+	summary, err := commandImport(ctx, b, target, pairs, false, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (importSummary{Created: 1, Skipped: 1, Failed: 1}); summary != want {
+		t.Errorf("got %v want %v", summary, want)
+	}
+	if !strings.Contains(out.String(), "skipped [existing]") {
+		t.Errorf("missing warning: %s", out)
+	}
+	if v, _ := b.Get(ctx, target, "existing"); string(v) != "old" {
+		t.Errorf("existing key changed to %s", v)
+	}
+
+	summary, err = commandImport(ctx, b, target, pairs[:2], true, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (importSummary{Overwritten: 2}); summary != want {
+		t.Errorf("got %v want %v", summary, want)
+	}
+	if v, _ := b.Get(ctx, target, "existing"); string(v) != "new" {
+		t.Errorf("got %s want new", v)
+	}
+}
+
+func TestImportNormalizesKeysAndRegistersValues(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+
+	pairs, err := parseJSONImport(strings.NewReader(`{"/prod//db/":"Zq7Xv9Kw2import","prod/db":"other","/":"empty"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := commandImport(ctx, b, target, pairs, false, new(bytes.Buffer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (importSummary{Created: 1, Skipped: 1, Failed: 1}); summary != want {
+		t.Errorf("got %v want %v", summary, want)
+	}
+	if v, _ := b.Get(ctx, target, "prod/db"); string(v) != "Zq7Xv9Kw2import" {
+		t.Errorf("got %s want Zq7Xv9Kw2import", v)
+	}
+	if got := scrub("value Zq7Xv9Kw2import"); strings.Contains(got, "Zq7Xv9Kw2import") {
+		t.Errorf("value of JSON import not registered, got %s", got)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/kramphub/kiya/backend"
+	"gopkg.in/yaml.v3"
+)
+
+// k8sField maps a key to a field in the data of a Kubernetes Secret.
+type k8sField struct {
+	key, field string
+}
+
+// k8sSecret is a minimal v1.Secret manifest.
+type k8sSecret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sMetadata       `yaml:"metadata"`
+	Type       string            `yaml:"type"`
+	Data       map[string]string `yaml:"data"`
+}
+
+type k8sMetadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// parseK8sFields returns the name of the Secret and the field for each key argument.
+// An argument is either key=field or key, whose field is the field of name/field or else the last segment of the key.
+func parseK8sFields(nameAndField string, args []string) (string, []k8sField, error) {
+	name, defaultField, _ := strings.Cut(nameAndField, "/")
+	if len(name) == 0 {
+		return "", nil, errors.New("missing name of the Kubernetes Secret")
+	}
+	if len(args) == 0 {
+		return "", nil, errors.New("missing key")
+	}
+	if len(defaultField) > 0 && len(args) > 1 {
+		return "", nil, fmt.Errorf("use key=field for each key instead of %s", nameAndField)
+	}
+	fields := make([]k8sField, 0, len(args))
+	seen := map[string]bool{}
+	for _, each := range args {
+		key, field, ok := strings.Cut(each, "=")
+		if !ok {
+			field = defaultField
+			if len(field) == 0 {
+				field = path.Base(key)
+			}
+		}
+		if len(key) == 0 || len(field) == 0 {
+			return "", nil, fmt.Errorf("invalid key=field [%s]", each)
+		}
+		if seen[field] {
+			return "", nil, fmt.Errorf("duplicate field [%s]", field)
+		}
+		seen[field] = true
+		fields = append(fields, k8sField{key: key, field: field})
+	}
+	return name, fields, nil
+}
+
+// commandK8sSecret writes a Kubernetes Secret manifest with the value of each key as a base64 encoded field.
+// kiya -k8s-secret name[/field] [profile] get [key|key=field] [|key=field ...]
+func commandK8sSecret(ctx context.Context, b backend.Backend, target *backend.Profile, nameAndField, namespace string, args []string, w io.Writer) error {
+	name, fields, err := parseK8sFields(nameAndField, args)
+	if err != nil {
+		return err
+	}
+	secret := k8sSecret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   k8sMetadata{Name: name, Namespace: namespace},
+		Type:       "Opaque",
+		Data:       map[string]string{},
+	}
+	for _, each := range fields {
+		value, err := b.Get(ctx, target, each.key)
+		if err != nil {
+			return fmt.Errorf("get %s failed, %w", each.key, err)
+		}
+		registerSecret(string(value))
+		secret.Data[each.field] = base64.StdEncoding.EncodeToString(value)
+		backend.Zero(value)
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(secret); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestParseK8sFields(t *testing.T) {
+	name, fields, err := parseK8sFields("db", []string{"prod/db/user=username", "prod/db/password"})
+	if err != nil || name != "db" {
+		t.Fatalf("got %s %v", name, err)
+	}
+	if fields[0] != (k8sField{"prod/db/user", "username"}) || fields[1] != (k8sField{"prod/db/password", "password"}) {
+		t.Errorf("got %v", fields)
+	}
+	if _, fields, _ := parseK8sFields("db/pw", []string{"prod/db/password"}); fields[0].field != "pw" {
+		t.Errorf("got %v", fields)
+	}
+	for _, each := range [][]string{{}, {"a/x", "b/x"}, {"=field"}} {
+		if _, _, err := parseK8sFields("db", each); err == nil {
+			t.Errorf("expected error for %v", each)
+		}
+	}
+	if _, _, err := parseK8sFields("db/pw", []string{"a", "b"}); err == nil {
+		t.Error("expected error for name/field with multiple keys")
+	}
+}
+
+func TestK8sSecretManifest(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "db/password", "secret", false)
+
+	out := new(bytes.Buffer)
+	if err := commandK8sSecret(ctx, b, target, "db", "apps", []string{"db/password"}, out); err != nil {
+		t.Fatal(err)
+	}
+	want := `apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+  namespace: apps
+type: Opaque
+data:
+  password: c2VjcmV0
+`
+	if got := out.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
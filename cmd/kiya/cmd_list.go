@@ -3,8 +3,8 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
+	"path"
 	"strings"
 	"time"
 
@@ -14,16 +14,20 @@ import (
 )
 
 // commandList lists keys in a specific profile
-func commandList(ctx context.Context, b backend.Backend, target *backend.Profile, filter string) []backend.Key {
+func commandList(ctx context.Context, b backend.Backend, target *backend.Profile, filter string) ([]backend.Key, error) {
 	keys, err := b.List(ctx, target)
 	if err != nil {
-		log.Fatal(err)
+		return nil, deniedOr(err, "listing", "", target, err)
 	}
+	return filterKeys(keys, filter), nil
+}
 
+// filterKeys returns the keys whose name matches the filter, all keys if the filter is empty.
+func filterKeys(keys []backend.Key, filter string) []backend.Key {
 	filteredKeys := make([]backend.Key, 0)
 	for _, k := range keys {
 		if len(filter) > 0 {
-			if !caseInsensitiveContains(k.Name, filter) {
+			if !matchKey(k.Name, filter, *oMatch) {
 				continue
 			}
 		}
@@ -34,20 +38,41 @@ func commandList(ctx context.Context, b backend.Backend, target *backend.Profile
 	return filteredKeys
 }
 
-// writeTable writes a human-readable table with parameters info.
-func writeTable(keys []backend.Key, target *backend.Profile, filter string) {
+// Supported values for the output flag.
+const (
+	outputTable    = "table"
+	outputMarkdown = "markdown"
+	outputJSON     = "json"
+)
+
+// newlineMarker replaces line breaks in table cells such that each row stays on a single line.
+const newlineMarker = "↵"
+
+// writeTable writes a human-readable table with parameters info, either as text or as GitHub-flavored Markdown.
+func writeTable(keys []backend.Key, target *backend.Profile, filter, output string) error {
+	if output == outputJSON {
+		return writeKeysJSON(keys, filter, *oStripPrefix)
+	}
 	filteredCount := 0
 
 	data := make([][]string, 0)
 
 	for _, k := range keys {
 		if len(filter) > 0 {
-			if !caseInsensitiveContains(k.Name, filter) {
+			if !matchKey(k.Name, filter, *oMatch) {
 				filteredCount++
 				continue
 			}
 		}
-		data = append(data, []string{fmt.Sprintf("kiya %s copy %s", target.Label, k.Name), k.CreatedAt.Format(time.RFC822), k.Info})
+		// never truncate the command such that it can be copied
+		first := sanitizeCell(fmt.Sprintf("kiya %s copy %s", target.Label, k.Name), 0, false)
+		if len(*oStripPrefix) > 0 {
+			first = sanitizeCell(stripPrefix(k.Name, *oStripPrefix), 0, false)
+		}
+		data = append(data, []string{
+			first,
+			k.CreatedAt.Format(time.RFC822),
+			sanitizeCell(keyInfo(k), *oMaxColWidth, *oWrap)})
 	}
 
 	if len(filter) > 0 {
@@ -55,13 +80,129 @@ func writeTable(keys []backend.Key, target *backend.Profile, filter string) {
 	}
 
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetAutoWrapText(false)
-	table.SetHeader([]string{"Copy to clipboard command", "Created", "Info"})
+	table.SetAutoWrapText(*oWrap && output == outputTable)
+	if *oMaxColWidth > 0 {
+		table.SetColWidth(*oMaxColWidth)
+	}
+	if len(*oStripPrefix) > 0 {
+		// a stripped name is no longer a valid copy command
+		table.SetHeader([]string{"Key", "Created", "Info"})
+	} else {
+		table.SetHeader([]string{"Copy to clipboard command", "Created", "Info"})
+	}
+	if output == outputMarkdown {
+		setMarkdown(table, data)
+	}
 	table.AppendBulk(data)
 	table.Render() // writes to stdout
+	return nil
+}
+
+// listedKey is a key in a JSON listing, with its name without the stripped prefix if any.
+type listedKey struct {
+	backend.Key
+	StrippedName string `json:",omitempty"`
+}
+
+// stripPrefix returns the name without the prefix, or the name unchanged if it does not start with the prefix.
+func stripPrefix(name, prefix string) string {
+	if stripped := strings.TrimPrefix(name, prefix); len(stripped) > 0 {
+		return stripped
+	}
+	return name
+}
+
+// writeKeysJSON writes the keys matching the filter, with their full info, as a JSON array.
+func writeKeysJSON(keys []backend.Key, filter, prefix string) error {
+	matching := make([]listedKey, 0, len(keys))
+	for _, k := range keys {
+		if len(filter) == 0 || matchKey(k.Name, filter, *oMatch) {
+			listed := listedKey{Key: k}
+			if len(prefix) > 0 {
+				listed.StrippedName = stripPrefix(k.Name, prefix)
+			}
+			matching = append(matching, listed)
+		}
+	}
+	return writeJSON(os.Stdout, matching)
+}
+
+// sanitizeCell returns the cell content on a single line, using a visible marker for each line break.
+// Unless wrapped, content longer than maxWidth runes is truncated with an ellipsis; zero means no maximum.
+func sanitizeCell(cell string, maxWidth int, wrap bool) string {
+	cell = strings.ReplaceAll(cell, "\r\n", "\n")
+	cell = strings.ReplaceAll(cell, "\r", "\n")
+	cell = strings.ReplaceAll(cell, "\n", newlineMarker)
+	if wrap || maxWidth <= 0 {
+		return cell
+	}
+	runes := []rune(cell)
+	if len(runes) <= maxWidth {
+		return cell
+	}
+	if maxWidth == 1 {
+		return "…"
+	}
+	return string(runes[:maxWidth-1]) + "…"
+}
+
+// setMarkdown makes the table render as GitHub-flavored Markdown and escapes the pipes in its data.
+func setMarkdown(table *tablewriter.Table, data [][]string) {
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	for _, row := range data {
+		for i, cell := range row {
+			row[i] = strings.ReplaceAll(cell, "|", "\\|")
+		}
+	}
+}
+
+// Supported values for the match flag.
+const (
+	matchSubstring = "substring"
+	matchExact     = "exact"
+	matchPrefix    = "prefix"
+	matchGlob      = "glob"
+)
+
+// matchKey returns whether a key name matches the filter using a match mode.
+// Only substring matching ignores case.
+func matchKey(key, filter, mode string) bool {
+	switch mode {
+	case matchExact:
+		return key == filter
+	case matchPrefix:
+		return strings.HasPrefix(key, filter)
+	case matchGlob:
+		ok, err := path.Match(filter, key)
+		return ok && err == nil
+	default:
+		return caseInsensitiveContains(key, filter)
+	}
+}
+
+// isValidMatchMode returns whether mode is one of the supported match modes.
+func isValidMatchMode(mode string) bool {
+	switch mode {
+	case matchSubstring, matchExact, matchPrefix, matchGlob:
+		return true
+	}
+	return false
 }
 
 func caseInsensitiveContains(key, filter string) bool {
 	key, filter = strings.ToLower(key), strings.ToLower(filter)
 	return strings.Contains(key, filter)
 }
+
+// keyInfo returns the info of the key including its content type, if known.
+func keyInfo(k backend.Key) string {
+	if len(k.ContentType) == 0 {
+		return k.Info
+	}
+	if len(k.Info) == 0 {
+		return "content-type: " + k.ContentType
+	}
+	return k.Info + " content-type: " + k.ContentType
+}
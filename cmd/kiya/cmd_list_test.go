@@ -0,0 +1,59 @@
+package main
+
+import "testing"
+
+func TestMatchKey(t *testing.T) {
+	for _, each := range []struct {
+		key, filter, mode string
+		want              bool
+	}{
+		{"concourse/cd-pipeline", "CD-pipe", matchSubstring, true},
+		{"concourse/cd-pipeline", "bitbucket", matchSubstring, false},
+		{"concourse/cd-pipeline", "concourse/cd-pipeline", matchExact, true},
+		{"concourse/cd-pipeline", "concourse/cd", matchExact, false},
+		{"concourse/cd-pipeline", "concourse/", matchPrefix, true},
+		{"concourse/cd-pipeline", "cd-pipeline", matchPrefix, false},
+		{"concourse/cd-pipeline", "concourse/*", matchGlob, true},
+		{"concourse/cd-pipeline", "*", matchGlob, false},
+		{"concourse/cd-pipeline", "*/cd-pipelin?", matchGlob, true},
+		{"concourse/cd-pipeline", "concourse/cd-pipelin?x", matchGlob, false},
+		{"concourse/cd-pipeline", "concourse/[", matchGlob, false},
+	} {
+		if got := matchKey(each.key, each.filter, each.mode); got != each.want {
+			t.Errorf("matchKey(%q, %q, %q) got [%v] want [%v]", each.key, each.filter, each.mode, got, each.want)
+		}
+	}
+}
+
+func TestSanitizeCell(t *testing.T) {
+	for _, each := range []struct {
+		cell     string
+		maxWidth int
+		wrap     bool
+		want     string
+	}{
+		{"line1\nline2", 0, false, "line1↵line2"},
+		{"line1\r\nline2", 0, false, "line1↵line2"},
+		{"abcdef", 4, false, "abc…"},
+		{"abcdef", 4, true, "abcdef"},
+		{"abc", 4, false, "abc"},
+	} {
+		if got := sanitizeCell(each.cell, each.maxWidth, each.wrap); got != each.want {
+			t.Errorf("got [%s] want [%s]", got, each.want)
+		}
+	}
+}
+
+func TestStripPrefix(t *testing.T) {
+	for _, each := range []struct {
+		name, prefix, want string
+	}{
+		{"prod/db/password", "prod/db/", "password"},
+		{"prod/api/token", "prod/db/", "prod/api/token"},
+		{"prod/db/", "prod/db/", "prod/db/"},
+	} {
+		if got := stripPrefix(each.name, each.prefix); got != each.want {
+			t.Errorf("stripPrefix(%q, %q) got [%s] want [%s]", each.name, each.prefix, got, each.want)
+		}
+	}
+}
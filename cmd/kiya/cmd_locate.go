@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/kramphub/kiya"
+	"github.com/kramphub/kiya/backend"
+	"github.com/olekukonko/tablewriter"
+)
+
+// Status of a key in a profile as reported by locate.
+const (
+	locatePresent = "present"
+	locateAbsent  = "absent"
+	locateFailed  = "failed"
+)
+
+// locateResult describes whether a key exists in a single profile.
+type locateResult struct {
+	Profile   string     `json:"profile"`
+	Backend   string     `json:"backend"`
+	Status    string     `json:"status"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// commandLocate reports, for an exact key name, each profile that contains it and when it was created.
+// kiya locate [key] [--all-profiles] [--output table|json] [profile ...]
+func commandLocate(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("locate", flag.ExitOnError)
+	allProfiles := flags.Bool("all-profiles", false, "search all profiles of the configuration")
+	output := flags.String("output", *oOutput, "format of the report: table or json")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		return errors.New("missing key, use kiya locate [key] [--all-profiles] [profile ...]")
+	}
+	key := flags.Arg(0)
+	// also accept flags after the key
+	flags.Parse(flags.Args()[1:])
+
+	names := flags.Args()
+	if *allProfiles {
+		names = names[:0]
+		for name := range kiya.Profiles {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return errors.New("no profiles to search, use --all-profiles or name the profiles after the key")
+	}
+	sort.Strings(names)
+
+	results := make([]locateResult, 0, len(names))
+	for _, name := range names {
+		profile, ok := kiya.Profiles[name]
+		if !ok {
+			return fmt.Errorf("no such profile [%s] please check your .kiya file", name)
+		}
+		b, err := backends.get(ctx, &profile)
+		if err != nil {
+			results = append(results, locateResult{Profile: name, Backend: profile.Backend, Status: locateFailed, Error: err.Error()})
+			continue
+		}
+		results = append(results, locateKey(ctx, b, &profile, key))
+	}
+	if *output == outputJSON {
+		if err := writeJSON(os.Stdout, results); err != nil {
+			return err
+		}
+		return nil
+	}
+	writeLocateTable(os.Stdout, results)
+	return nil
+}
+
+// locateKey looks up the key in the listing of the profile to report its status and creation time.
+func locateKey(ctx context.Context, b backend.Backend, target *backend.Profile, key string) locateResult {
+	result := locateResult{Profile: target.Label, Backend: target.Backend, Status: locateAbsent}
+	keys, err := b.List(ctx, target)
+	if err != nil {
+		result.Status = locateFailed
+		result.Error = scrub(err.Error())
+		return result
+	}
+	for _, each := range keys {
+		if each.Name == key {
+			createdAt := each.CreatedAt
+			result.Status = locatePresent
+			result.CreatedAt = &createdAt
+			break
+		}
+	}
+	return result
+}
+
+// writeLocateTable writes a table with a row for each profile.
+func writeLocateTable(w io.Writer, results []locateResult) {
+	data := make([][]string, 0, len(results))
+	for _, each := range results {
+		created := ""
+		if each.CreatedAt != nil {
+			created = each.CreatedAt.Format(time.RFC822)
+		}
+		data = append(data, []string{each.Profile, each.Backend, each.Status, created, sanitizeCell(each.Error, *oMaxColWidth, false)})
+	}
+	table := tablewriter.NewWriter(w)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Profile", "Backend", "Status", "Created", "Error"})
+	table.AppendBulk(data)
+	table.Render()
+}
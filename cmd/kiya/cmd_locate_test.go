@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLocateKey(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	target.Label, target.Backend = "dev", "file"
+	b.Put(ctx, target, "db/password", "secret", false)
+
+	present := locateKey(ctx, b, target, "db/password")
+	if present.Status != locatePresent || present.CreatedAt == nil {
+		t.Errorf("unexpected result %#v", present)
+	}
+	absent := locateKey(ctx, b, target, "db/pass")
+	if absent.Status != locateAbsent || absent.CreatedAt != nil {
+		t.Errorf("unexpected result %#v", absent)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/emicklei/tre"
+	"github.com/kramphub/kiya"
+	"github.com/kramphub/kiya/backend"
+	"github.com/olekukonko/tablewriter"
+)
+
+// migrateResult is the outcome of migrating a single key.
+type migrateResult struct {
+	key      string
+	result   string
+	verified bool
+	purged   bool
+	// skipped is true if the key exists in the target and was left as is
+	skipped bool
+}
+
+// countMigrated returns the number of keys that were (or in a dry run would be) migrated, skipped because
+// they exist in the target, and that failed.
+func countMigrated(results []migrateResult, dryRun bool) (migrated, skipped, failed int) {
+	for _, each := range results {
+		switch {
+		case each.skipped:
+			skipped++
+		case each.verified || dryRun:
+			migrated++
+		default:
+			failed++
+		}
+	}
+	return
+}
+
+// commandMigrate copies all keys from one profile to another, possibly using another backend type.
+// kiya migrate --from [profile] --to [profile] [--overwrite] [--dry-run] [--purge-source]
+func commandMigrate(ctx context.Context, args []string, concurrency int) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	from := flags.String("from", "", "profile to migrate from")
+	to := flags.String("to", "", "profile to migrate to")
+	overwrite := flags.Bool("overwrite", false, "overwrite keys that already exist in the target profile")
+	dryRun := flags.Bool("dry-run", false, "only report what would be migrated")
+	// the global -force, e.g. kiya -force migrate, is the default
+	force := flags.Bool("force", *oForce, "overwrite keys in the target profile even if their value is unchanged")
+	purgeSource := flags.Bool("purge-source", false, "delete each key from the source profile after it is verified in the target profile")
+	flags.Parse(args)
+
+	source, ok := kiya.Profiles[*from]
+	if !ok {
+		return fmt.Errorf("no such profile [%s] for --from please check your .kiya file", *from)
+	}
+	target, ok := kiya.Profiles[*to]
+	if !ok {
+		return fmt.Errorf("no such profile [%s] for --to please check your .kiya file", *to)
+	}
+	if source.Label == target.Label {
+		return fmt.Errorf("cannot migrate profile [%s] to itself", source.Label)
+	}
+	fmt.Println(describeTransfer("migrate", "all keys", &source, &target, *purgeSource && !*dryRun))
+	if *purgeSource && !*dryRun && !promptForYes(fmt.Sprintf("Are you sure to delete all migrated keys from [%s] (y/N)? ", source.Label)) {
+		return errors.New("migrate aborted")
+	}
+
+	sourceBackend, err := backends.get(ctx, &source)
+	if err != nil {
+		return tre.New(err, "migrate failed", "profile", source.Label)
+	}
+	targetBackend, err := backends.get(ctx, &target)
+	if err != nil {
+		return tre.New(err, "migrate failed", "profile", target.Label)
+	}
+
+	keys, err := commandList(ctx, sourceBackend, &source, "")
+	if err != nil {
+		return err
+	}
+	// a single listing instead of checking each key
+	existing, err := existingKeys(ctx, targetBackend, &target)
+	if err != nil {
+		return tre.New(err, "migrate failed, cannot list keys", "profile", target.Label)
+	}
+	results := make([]migrateResult, len(keys))
+	forEachConcurrently(len(keys), concurrency, func(i int) {
+		results[i] = migrateKey(ctx, sourceBackend, &source, targetBackend, &target, keys[i].Name, existing[keys[i].Name], *overwrite, *force, *dryRun, *purgeSource)
+	})
+
+	sort.Slice(results, func(i, j int) bool { return results[i].key < results[j].key })
+	data := make([][]string, 0, len(results))
+	for _, each := range results {
+		data = append(data, []string{each.key, each.result, yesNo(each.verified), yesNo(each.purged)})
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Key", "Result", "Verified", "Source deleted"})
+	table.AppendBulk(data)
+	table.Render()
+	migrated, skipped, failed := countMigrated(results, *dryRun)
+	fmt.Printf("Migrated %d key(s) from [%s] to [%s], %d skipped, %d not verified\n", migrated, source.Label, target.Label, skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("migrate failed, %d key(s) not verified", failed)
+	}
+	return nil
+}
+
+func migrateKey(ctx context.Context,
+	sourceBackend backend.Backend, source *backend.Profile,
+	targetBackend backend.Backend, target *backend.Profile,
+	key string, exists, overwrite, force, dryRun, purgeSource bool) migrateResult {
+
+	result := migrateResult{key: key}
+	if exists && !overwrite {
+		result.result = "skipped, exists in target"
+		result.skipped = true
+		return result
+	}
+	if dryRun {
+		result.result = "would be copied"
+		if exists {
+			result.result = "would be overwritten"
+		}
+		return result
+	}
+	value, err := sourceBackend.Get(ctx, source, key)
+	if err != nil {
+		result.result = fmt.Sprintf("get failed: %v", err)
+		return result
+	}
+	registerSecret(string(value))
+	if exists && !force && isUnchanged(ctx, targetBackend, target, key, value) {
+		result.result = "unchanged"
+		result.verified = true
+	} else {
+		if err := targetBackend.Put(ctx, target, key, string(value), exists); err != nil {
+			result.result = "put failed: " + scrub(err.Error())
+			return result
+		}
+		result.result = "copied"
+		result.verified, _ = targetBackend.CheckExists(ctx, target, key)
+	}
+	if purgeSource && result.verified {
+		if err := sourceBackend.Delete(ctx, source, key); err != nil {
+			result.result = fmt.Sprintf("%s, delete from source failed: %v", result.result, err)
+		} else {
+			result.purged = true
+		}
+	}
+	return result
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+func TestMigrateIntoTargetWithExistingKey(t *testing.T) {
+	ctx := context.Background()
+	source := &backend.Profile{Label: "source"}
+	target := &backend.Profile{Label: "target"}
+	sourceBackend, _ := newTestStore(t)
+	targetBackend, _ := newTestStore(t)
+	sourceBackend.Put(ctx, source, "a", "new", false)
+	sourceBackend.Put(ctx, source, "b", "value", false)
+	targetBackend.Put(ctx, target, "a", "old", false)
+
+	existing, err := existingKeys(ctx, targetBackend, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []migrateResult
+	for _, key := range []string{"a", "b"} {
+		results = append(results, migrateKey(ctx, sourceBackend, source, targetBackend, target, key, existing[key], false, false, false, false))
+	}
+	if !results[0].skipped || results[0].verified {
+		t.Errorf("expected existing key to be skipped and not verified, got %+v", results[0])
+	}
+	if !results[1].verified {
+		t.Errorf("expected new key to be verified, got %+v", results[1])
+	}
+	migrated, skipped, failed := countMigrated(results, false)
+	if migrated != 1 || skipped != 1 || failed != 0 {
+		t.Errorf("got migrated %d skipped %d failed %d want 1 1 0", migrated, skipped, failed)
+	}
+	if value, _ := targetBackend.Get(ctx, target, "a"); string(value) != "old" {
+		t.Errorf("existing key changed to %s", value)
+	}
+}
+
+func TestMigrateEnforcesTargetPolicy(t *testing.T) {
+	ctx := context.Background()
+	source := &backend.Profile{Label: "source"}
+	target := &backend.Profile{Label: "target", Policy: &backend.Policy{MinLength: 8}}
+	sourceBackend, _ := newTestStore(t)
+	store, _ := newTestStore(t)
+	targetBackend, err := decorateBackend(store, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceBackend.Put(ctx, source, "a", "short", false)
+
+	result := migrateKey(ctx, sourceBackend, source, targetBackend, target, "a", false, false, false, false, false)
+	if result.verified {
+		t.Errorf("expected violating value to be refused, got %+v", result)
+	}
+	if exists, _ := store.CheckExists(ctx, target, "a"); exists {
+		t.Error("violating value was stored")
+	}
+}
@@ -2,14 +2,15 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"time"
 
 	"github.com/emicklei/tre"
 	"github.com/kramphub/kiya/backend"
 )
 
-// commandMove transfers a secret from a source to a target profile.
+// commandMove transfers a secret from a source to a target profile, each using its own backend.
 func commandMove(
 	ctx context.Context,
 	b backend.Backend,
@@ -17,16 +18,21 @@ func commandMove(
 	sourceKey string,
 	target *backend.Profile,
 	targetKey string,
-) {
-
-	if promptForYes(fmt.Sprintf("Are you sure you want to move [%s] from [%s] (y/N)", sourceKey, target.Label)) {
-		if err := move(ctx, b, source, sourceKey, target, targetKey); err != nil {
-			log.Fatal(err)
+) error {
+	tb, err := backends.get(ctx, target)
+	if err != nil {
+		return tre.New(err, "move failed", "profile", target.Label)
+	}
+	fmt.Println(describeTransfer("move", sourceKey, source, target, true))
+	if promptForYes("Are you sure (y/N)? ") {
+		if err := move(ctx, b, source, sourceKey, tb, target, targetKey); err != nil {
+			return err
 		}
 		fmt.Printf("Successfully moved [%s] to [%s]\n", sourceKey, target.Label)
 	} else {
-		log.Fatalln("delete aborted")
+		return errors.New("move aborted")
 	}
+	return nil
 }
 
 func move(
@@ -34,6 +40,7 @@ func move(
 	b backend.Backend,
 	source *backend.Profile,
 	sourceKey string,
+	tb backend.Backend,
 	target *backend.Profile,
 	targetKey string) error {
 
@@ -42,12 +49,38 @@ func move(
 	if err != nil {
 		return tre.New(err, "get source key failed", "key", sourceKey)
 	}
+	if err := target.Policy.Validate(string(sourceValue)); err != nil {
+		return tre.New(err, "value rejected by the policy of the target", "profile", target.Label, "key", targetKey)
+	}
+
+	// carry over the metadata of the source key, best effort
+	if k, err := findKey(ctx, b, source, sourceKey); err == nil {
+		ctx = backend.WithInfo(ctx, movedInfo(k, source.Label))
+		if len(k.ContentType) > 0 {
+			ctx = backend.WithContentType(ctx, k.ContentType)
+		}
+	}
 
-	exists, _ := b.CheckExists(ctx, target, targetKey)
-	if err := b.Put(ctx, target, targetKey, string(sourceValue), exists); err != nil {
+	exists, _ := tb.CheckExists(ctx, target, targetKey)
+	if err := tb.Put(ctx, target, targetKey, string(sourceValue), exists); err != nil {
 		return tre.New(err, "save key failed", targetKey)
 	}
 	// delete key from source
 	err = b.Delete(ctx, source, sourceKey)
 	return tre.New(err, "could not delete key", targetKey)
 }
+
+// movedInfo returns the info for a moved key that describes its origin, including its original creation time and owner.
+func movedInfo(k backend.Key, sourceLabel string) string {
+	info := fmt.Sprintf("moved from [%s] %s", sourceLabel, k.Name)
+	if !k.CreatedAt.IsZero() {
+		info += ", created " + k.CreatedAt.Format(time.RFC3339)
+	}
+	if len(k.Owner) > 0 && k.Owner != "<Unknown>" {
+		info += " by " + k.Owner
+	}
+	if len(k.Info) > 0 {
+		info += " - " + k.Info
+	}
+	return info
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+func TestMoveCarriesMetadata(t *testing.T) {
+	ctx := context.Background()
+	b, _ := newTestStore(t)
+	source := &backend.Profile{Label: "source"}
+	target := &backend.Profile{Label: "target"}
+	b.Put(backend.WithContentType(ctx, "application/json"), source, "a", "{}", false)
+
+	if err := move(ctx, b, source, "a", b, target, "b"); err != nil {
+		t.Fatal(err)
+	}
+	keys, _ := b.List(ctx, target)
+	if len(keys) != 1 {
+		t.Fatalf("expected 1 key, got %v", keys)
+	}
+	if got := keys[0]; !strings.HasPrefix(got.Info, "moved from [source] a, created ") || got.ContentType != "application/json" {
+		t.Errorf("unexpected metadata %#v", got)
+	}
+}
+
+func TestDescribeTransfer(t *testing.T) {
+	source := &backend.Profile{Label: "prod", Backend: "gsm", ProjectID: "infra"}
+	target := &backend.Profile{Label: "dev", Backend: "file", Location: "~/.secrets"}
+	got := describeTransfer("move", "prod/db/password", source, target, true)
+	want := "MOVE prod/db/password from [prod] gsm(proj=infra) to [dev] file(~/.secrets): delete source? yes"
+	if got != want {
+		t.Errorf("got [%s] want [%s]", got, want)
+	}
+	if got := describeBackend(&backend.Profile{ProjectID: "p"}); got != "kms(proj=p)" {
+		t.Errorf("got [%s]", got)
+	}
+}
+
+func TestMoveEnforcesTargetPolicy(t *testing.T) {
+	ctx := context.Background()
+	b, _ := newTestStore(t)
+	source := &backend.Profile{Label: "source"}
+	target := &backend.Profile{Label: "target", Policy: &backend.Policy{MustBeJSON: true}}
+	b.Put(ctx, source, "a", "not json", false)
+
+	if err := move(ctx, b, source, "a", b, target, "b"); err == nil {
+		t.Fatal("expected the value to be rejected")
+	}
+	if exists, _ := b.CheckExists(ctx, source, "a"); !exists {
+		t.Error("expected the source key to be kept")
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kramphub/kiya/backend"
+	"github.com/olekukonko/tablewriter"
+)
+
+// pruneCandidate is a key selected for deletion with the reason why.
+type pruneCandidate struct {
+	key    backend.Key
+	reason string
+}
+
+// selectPruneCandidates returns the keys stored longer than olderThan ago, if positive, or, if expired is set,
+// that have expired. Keys that start with a protected prefix are never selected.
+func selectPruneCandidates(keys []backend.Key, olderThan time.Duration, expired bool, protected []string, now time.Time) []pruneCandidate {
+	candidates := []pruneCandidate{}
+	for _, each := range keys {
+		if hasAnyPrefix(each.Name, protected) {
+			continue
+		}
+		if expired && !each.ExpiresAt.IsZero() && each.ExpiresAt.Before(now) {
+			candidates = append(candidates, pruneCandidate{key: each, reason: "expired " + each.ExpiresAt.Format(time.RFC822)})
+			continue
+		}
+		if olderThan > 0 && !each.CreatedAt.IsZero() && now.Sub(each.CreatedAt) > olderThan {
+			candidates = append(candidates, pruneCandidate{key: each, reason: "older than " + olderThan.String()})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].key.Name < candidates[j].key.Name })
+	return candidates
+}
+
+func hasAnyPrefix(name string, prefixes []string) bool {
+	for _, each := range prefixes {
+		if strings.HasPrefix(name, each) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePruneArgs returns the optional filter of prune and whether its -yes flag is set, which may precede or follow the filter.
+func parsePruneArgs(args []string) (filter string, yes bool, err error) {
+	flags := flag.NewFlagSet("prune", flag.ContinueOnError)
+	flags.BoolVar(&yes, "yes", false, "delete the selected keys without asking for confirmation")
+	positional := []string{}
+	for {
+		if err := flags.Parse(args); err != nil {
+			return "", false, err
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(positional) > 1 {
+		return "", false, fmt.Errorf("prune accepts at most one filter, got %s", strings.Join(positional, " "))
+	}
+	if len(positional) == 1 {
+		filter = positional[0]
+	}
+	return filter, yes, nil
+}
+
+// commandPrune deletes the keys matching the filter that are too old or have expired,
+// after confirmation unless yes is true. Unlike other commands, -quiet does not skip the confirmation.
+// kiya [profile] prune [-yes] [|filter-term]
+func commandPrune(ctx context.Context, b backend.Backend, target *backend.Profile, filter string, olderThan time.Duration, expired bool, protected []string, dryRun, yes bool) error {
+	if olderThan <= 0 && !expired {
+		return errors.New("nothing to prune, use -older-than and/or -expired")
+	}
+	keys, err := commandList(ctx, b, target, filter)
+	if err != nil {
+		return err
+	}
+	if olderThan > 0 {
+		// a listed key can have the creation time of its secret, a rotated key is as old as its latest version
+		keys, err = storedKeys(ctx, b, target, keys)
+		if err != nil {
+			return err
+		}
+	}
+	candidates := selectPruneCandidates(keys, olderThan, expired, protected, time.Now())
+	if len(candidates) == 0 {
+		fmt.Printf("No keys to prune in [%s]\n", target.Label)
+		return nil
+	}
+	writePruneTable(os.Stdout, candidates)
+	if dryRun {
+		fmt.Printf("Would delete %d key(s) from [%s]\n", len(candidates), target.Label)
+		return nil
+	}
+	if !yes && !askYes(fmt.Sprintf("Are you sure to delete %d key(s) from [%s] (y/N)? ", len(candidates), target.Label)) {
+		return errors.New("prune aborted")
+	}
+	failed := 0
+	for _, each := range candidates {
+		if err := b.Delete(ctx, target, each.key.Name); err != nil {
+			fmt.Printf("failed to delete [%s] from [%s] because [%v]\n", each.key.Name, target.Label, scrub(err.Error()))
+			failed++
+		}
+	}
+	fmt.Printf("Deleted %d key(s) from [%s]\n", len(candidates)-failed, target.Label)
+	if failed > 0 {
+		return fmt.Errorf("failed to delete %d key(s)", failed)
+	}
+	return nil
+}
+
+func writePruneTable(w io.Writer, candidates []pruneCandidate) {
+	data := make([][]string, 0, len(candidates))
+	for _, each := range candidates {
+		data = append(data, []string{each.key.Name, each.key.CreatedAt.Format(time.RFC822), each.reason})
+	}
+	table := tablewriter.NewWriter(w)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Key", "Created", "Reason"})
+	table.AppendBulk(data)
+	table.Render()
+}
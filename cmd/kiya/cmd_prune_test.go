@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+func TestSelectPruneCandidates(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	keys := []backend.Key{
+		{Name: "old", CreatedAt: now.AddDate(-2, 0, 0)},
+		{Name: "new", CreatedAt: now.AddDate(0, -1, 0)},
+		{Name: "expired", CreatedAt: now.AddDate(0, -1, 0), ExpiresAt: now.AddDate(0, 0, -1)},
+		{Name: "valid", CreatedAt: now.AddDate(0, -1, 0), ExpiresAt: now.AddDate(0, 0, 1)},
+		{Name: "keep/old", CreatedAt: now.AddDate(-2, 0, 0)},
+		{Name: "unknown"},
+	}
+	names := func(candidates []pruneCandidate) (list []string) {
+		for _, each := range candidates {
+			list = append(list, each.key.Name)
+		}
+		return
+	}
+	year := 365 * 24 * time.Hour
+	if got := names(selectPruneCandidates(keys, year, false, []string{"keep/"}, now)); len(got) != 1 || got[0] != "old" {
+		t.Errorf("older than: got %v", got)
+	}
+	if got := names(selectPruneCandidates(keys, 0, true, nil, now)); len(got) != 1 || got[0] != "expired" {
+		t.Errorf("expired: got %v", got)
+	}
+	if got := names(selectPruneCandidates(keys, year, true, nil, now)); len(got) != 3 {
+		t.Errorf("both: got %v", got)
+	}
+}
+
+func TestPruneUsesLatestEnabledVersion(t *testing.T) {
+	now := time.Now()
+	// listed with the creation time of the secret, two years ago
+	keys := []backend.Key{{Name: "rotated", CreatedAt: now.AddDate(-2, 0, 0)}}
+	year := 365 * 24 * time.Hour
+	stored, err := storedKeys(context.Background(), rotated{now: now}, &backend.Profile{}, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := selectPruneCandidates(stored, year, false, nil, now); len(got) != 0 {
+		t.Errorf("expected rotated key to be kept, got %v", got)
+	}
+	unversioned := struct{ backend.Backend }{}
+	stored, err = storedKeys(context.Background(), unversioned, &backend.Profile{}, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := selectPruneCandidates(stored, year, false, nil, now); len(got) != 1 {
+		t.Errorf("expected listed key to be pruned, got %v", got)
+	}
+}
+
+func TestParsePruneArgs(t *testing.T) {
+	for _, each := range []struct {
+		args   []string
+		filter string
+		yes    bool
+	}{
+		{nil, "", false},
+		{[]string{"db/"}, "db/", false},
+		{[]string{"-yes", "db/"}, "db/", true},
+		{[]string{"db/", "--yes"}, "db/", true},
+	} {
+		filter, yes, err := parsePruneArgs(each.args)
+		if err != nil || filter != each.filter || yes != each.yes {
+			t.Errorf("%v: got %q %v %v", each.args, filter, yes, err)
+		}
+	}
+	for _, each := range [][]string{{"a", "b"}, {"-unknown"}} {
+		if _, _, err := parsePruneArgs(each); err == nil {
+			t.Errorf("%v: expected error", each)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// jsonFields returns the top-level fields of a JSON object as values to store.
+// String fields are stored as is, all other fields as their JSON encoding.
+func jsonFields(r io.Reader) (map[string]string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&fields); err != nil {
+		return nil, fmt.Errorf("stdin is not a JSON object, %w", err)
+	}
+	values := make(map[string]string, len(fields))
+	for name, raw := range fields {
+		var text string
+		if err := json.Unmarshal(raw, &text); err == nil {
+			values[name] = text
+			continue
+		}
+		values[name] = string(raw)
+	}
+	return values, nil
+}
+
+// commandPutJSON stores each top-level field of the JSON object as a key, optionally prefixed.
+// Existing keys are skipped unless overwrite is set.
+// kiya [profile] put -stdin-json [|key-prefix]
+func commandPutJSON(ctx context.Context, b backend.Backend, target *backend.Profile, r io.Reader, prefix string, overwrite, dryRun bool, w io.Writer) error {
+	fields, err := jsonFields(r)
+	if err != nil {
+		return err
+	}
+	existing, err := existingKeys(ctx, b, target)
+	if err != nil {
+		return err
+	}
+	if len(prefix) > 0 && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	names := make([]string, 0, len(fields))
+	for each := range fields {
+		names = append(names, each)
+	}
+	sort.Strings(names)
+
+	values := map[string]string{}
+	for _, each := range names {
+		key := prefix + each
+		registerSecret(fields[each])
+		if !existing[key] || overwrite {
+			if err := target.Policy.Validate(fields[each]); err != nil {
+				// nothing is stored such that the object is either stored completely or not at all
+				return fmt.Errorf("%s rejected by the policy of [%s], %w", key, target.Label, err)
+			}
+		}
+		switch {
+		case existing[key] && !overwrite:
+			fmt.Fprintf(w, "%s: skipped, exists\n", key)
+			continue
+		case dryRun && existing[key]:
+			fmt.Fprintf(w, "%s: would be overwritten\n", key)
+		case dryRun:
+			fmt.Fprintf(w, "%s: would be created\n", key)
+		case existing[key]:
+			fmt.Fprintf(w, "%s: overwritten\n", key)
+		default:
+			fmt.Fprintf(w, "%s: created\n", key)
+		}
+		values[key] = fields[each]
+	}
+	if dryRun || len(values) == 0 {
+		return nil
+	}
+	return backend.PutBatch(ctx, b, target, values, overwrite)
+}
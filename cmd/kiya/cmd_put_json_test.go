@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+func TestJSONFields(t *testing.T) {
+	values, err := jsonFields(strings.NewReader(`{"host":"db","port":5432,"tags":{"a":true}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"host": "db", "port": "5432", "tags": `{"a":true}`} {
+		if got := values[key]; got != want {
+			t.Errorf("%s: got [%s] want [%s]", key, got, want)
+		}
+	}
+	if _, err := jsonFields(strings.NewReader(`[1,2]`)); err == nil {
+		t.Error("expected error for non-object")
+	}
+}
+
+func TestCommandPutJSONSkipsExisting(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "app/host", "old", false)
+
+	if err := commandPutJSON(ctx, b, target, strings.NewReader(`{"host":"new","user":"admin"}`), "app", false, false, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := b.Get(ctx, target, "app/host"); string(value) != "old" {
+		t.Errorf("expected existing key to be kept, got %s", value)
+	}
+	if value, _ := b.Get(ctx, target, "app/user"); string(value) != "admin" {
+		t.Errorf("expected new key, got %s", value)
+	}
+}
+
+func TestCommandPutJSONEnforcesPolicy(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	target.Policy = &backend.Policy{MinLength: 4}
+
+	err := commandPutJSON(ctx, b, target, strings.NewReader(`{"password":"long enough","pin":"123"}`), "app", false, false, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "app/pin") {
+		t.Fatalf("expected app/pin to be rejected, got %v", err)
+	}
+	if exists, _ := b.CheckExists(ctx, target, "app/password"); exists {
+		t.Error("expected nothing to be stored if a value is rejected")
+	}
+	if err := commandPutJSON(ctx, b, target, strings.NewReader(`{"password":"long enough"}`), "app", false, false, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"os"
+	"strings"
 
 	"github.com/kramphub/kiya/backend"
+	"golang.org/x/term"
 )
 
 // commandPutPasteGenerate ...
@@ -15,18 +18,68 @@ func commandPutPasteGenerate(
 	target *backend.Profile,
 	command, key, value string,
 	mustPrompt bool,
-) {
+) error {
+	registerSecret(value)
+	if err := target.Policy.Validate(value); err != nil {
+		return fmt.Errorf("%s rejected, [%s] violates the policy of [%s]: %v", command, key, target.Label, err)
+	}
 
 	overwrite := false
 	if exists, _ := b.CheckExists(ctx, target, key); exists {
-		if mustPrompt && !promptForYes(fmt.Sprintf("Are you sure to overwrite [%s] from [%s] (y/N)? ", key, target.Label)) {
-			log.Fatalln(command + " aborted")
-			return
+		if mustPrompt && !*oOverwrite {
+			// do not block on a prompt that cannot be answered
+			if !*oQuiet && !term.IsTerminal(int(os.Stdin.Fd())) {
+				return fmt.Errorf("%s aborted, [%s] already exists in [%s], use --overwrite to replace it", command, key, target.Label)
+			}
+			if *oShowDiff && !*oQuiet {
+				if current, err := b.Get(ctx, target, key); err == nil {
+					for _, each := range redactedDiff(string(current), value) {
+						fmt.Println(each)
+					}
+				}
+			}
+			if !promptForYes(fmt.Sprintf("Are you sure to overwrite [%s] from [%s] (y/N)? ", key, target.Label)) {
+				return errors.New(command + " aborted")
+			}
 		}
 		overwrite = true
 	}
 
 	if err := b.Put(ctx, target, key, value, overwrite); err != nil {
-		log.Fatal(err)
+		return deniedOr(err, "writing", key, target, err)
+	}
+	return nil
+}
+
+// redactedDiff describes the changed lines between the current and new value without revealing them.
+func redactedDiff(current, value string) (lines []string) {
+	if current == value {
+		return []string{"value is unchanged"}
+	}
+	currentLines, newLines := strings.Split(current, "\n"), strings.Split(value, "\n")
+	if len(currentLines) == 1 && len(newLines) == 1 {
+		return []string{fmt.Sprintf("- %s", redact(current)), fmt.Sprintf("+ %s", redact(value))}
+	}
+	for i := 0; i < len(currentLines) || i < len(newLines); i++ {
+		if i < len(currentLines) && i < len(newLines) && currentLines[i] == newLines[i] {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("line %d:", i+1))
+		if i < len(currentLines) {
+			lines = append(lines, fmt.Sprintf("- %s", redact(currentLines[i])))
+		}
+		if i < len(newLines) {
+			lines = append(lines, fmt.Sprintf("+ %s", redact(newLines[i])))
+		}
+	}
+	return
+}
+
+// redact returns the length and, for longer values, the first and last character of a value.
+func redact(value string) string {
+	runes := []rune(value)
+	if len(runes) < 8 {
+		return fmt.Sprintf("*** (%d chars)", len(runes))
 	}
+	return fmt.Sprintf("%c***%c (%d chars)", runes[0], runes[len(runes)-1], len(runes))
 }
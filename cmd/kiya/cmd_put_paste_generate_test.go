@@ -0,0 +1,24 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedactedDiff(t *testing.T) {
+	for _, each := range []struct {
+		current, value string
+		want           []string
+	}{
+		{"same", "same", []string{"value is unchanged"}},
+		{"short", "mySecretPassword", []string{"- *** (5 chars)", "+ m***d (16 chars)"}},
+		{"user=admin\npassword=old", "user=admin\npassword=new\nport=5432", []string{
+			"line 2:", "- p***d (12 chars)", "+ p***w (12 chars)",
+			"line 3:", "+ p***2 (9 chars)",
+		}},
+	} {
+		if got := redactedDiff(each.current, each.value); !reflect.DeepEqual(got, each.want) {
+			t.Errorf("redactedDiff(%q, %q) got %q want %q", each.current, each.value, got, each.want)
+		}
+	}
+}
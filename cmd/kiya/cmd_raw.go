@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// rawFileStore returns the FileStore of the backend or an error if the profile uses another backend.
+func rawFileStore(b backend.Backend, target *backend.Profile) (*backend.FileStore, error) {
+	store, ok := backend.Unwrap(b).(*backend.FileStore)
+	if !ok {
+		return nil, fmt.Errorf("raw export and import are only supported by the file backend, not by [%s] of [%s]", target.Backend, target.Label)
+	}
+	return store, nil
+}
+
+// commandExportRaw writes the encrypted store as base64; no value is decrypted.
+// kiya [file-profile] export-raw
+func commandExportRaw(b backend.Backend, target *backend.Profile, w io.Writer) error {
+	store, err := rawFileStore(b, target)
+	if err != nil {
+		return err
+	}
+	data, err := store.ExportRaw()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, base64.StdEncoding.EncodeToString(data))
+	return err
+}
+
+// commandImportRaw replaces the store with a base64 encoded store written by export-raw.
+// kiya [file-profile] import-raw
+func commandImportRaw(b backend.Backend, target *backend.Profile, r io.Reader, overwrite bool) error {
+	store, err := rawFileStore(b, target)
+	if err != nil {
+		return err
+	}
+	encoded, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	data, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(encoded)))
+	if err != nil {
+		return fmt.Errorf("input is not base64 encoded, %w", err)
+	}
+	return store.ImportRaw(data, overwrite)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+func TestExportImportRawRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source, target := newTestStore(t)
+	target.Backend = "file"
+	source.Put(ctx, target, "a", "secret", false)
+
+	out := new(bytes.Buffer)
+	if err := commandExportRaw(source, target, out); err != nil {
+		t.Fatal(err)
+	}
+	imported := backend.NewFileStore(filepath.Join(t.TempDir(), "target"), "test")
+	if err := commandImportRaw(imported, target, out, false); err != nil {
+		t.Fatal(err)
+	}
+	imported.SetParameter("masterPassword", []byte("test"))
+	if value, err := imported.Get(ctx, target, "a"); err != nil || string(value) != "secret" {
+		t.Errorf("got %s %v", value, err)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// commandRename changes the name of a key within a profile, keeping its value, info and content type.
+// It fails if the new name already exists.
+// kiya [profile] rename [old-key] [new-key]
+func commandRename(ctx context.Context, b backend.Backend, target *backend.Profile, oldKey, newKey string) error {
+	if len(oldKey) == 0 || len(newKey) == 0 {
+		return errors.New("expected old and new key, use kiya [profile] rename [old-key] [new-key]")
+	}
+	if oldKey == newKey {
+		return fmt.Errorf("old and new key are the same [%s]", oldKey)
+	}
+	exists, err := b.CheckExists(ctx, target, newKey)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%s %w in [%s]", newKey, backend.ErrKeyExists, target.Label)
+	}
+	if !promptForYes(fmt.Sprintf("Are you sure to rename [%s] to [%s] in [%s] (y/N)? ", oldKey, newKey, target.Label)) {
+		return errors.New("rename aborted")
+	}
+	if err := rename(ctx, b, target, oldKey, newKey); err != nil {
+		return err
+	}
+	fmt.Printf("Successfully renamed [%s] to [%s] in [%s]\n", oldKey, newKey, target.Label)
+	return nil
+}
+
+// rename stores the value of the old key under the new key and then deletes the old key.
+// No backend can rename natively, so the new key exists before the old one is deleted.
+// The value is moved as stored, keeping the encoding it was stored with regardless of -encode.
+func rename(ctx context.Context, b backend.Backend, target *backend.Profile, oldKey, newKey string) error {
+	ctx = backend.WithRawValue(ctx)
+	value, err := b.Get(ctx, target, oldKey)
+	if err != nil {
+		return err
+	}
+	defer backend.Zero(value)
+	registerSecret(string(value))
+
+	// keep the metadata of the old key, best effort
+	if k, err := findKey(ctx, b, target, oldKey); err == nil {
+		if len(k.Info) > 0 {
+			ctx = backend.WithInfo(ctx, k.Info)
+		}
+		if len(k.ContentType) > 0 {
+			ctx = backend.WithContentType(ctx, k.ContentType)
+		}
+	}
+	if err := b.Put(ctx, target, newKey, string(value), false); err != nil {
+		return fmt.Errorf("unable to store [%s]: %w", newKey, err)
+	}
+	if err := b.Delete(ctx, target, oldKey); err != nil {
+		return fmt.Errorf("stored [%s] but unable to delete [%s], both exist now: %w", newKey, oldKey, err)
+	}
+	return nil
+}
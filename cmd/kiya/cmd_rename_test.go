@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+func TestRenameKeepsValueAndContentType(t *testing.T) {
+	defer func(quiet bool) { *oQuiet = quiet }(*oQuiet)
+	*oQuiet = true
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	if err := b.Put(backend.WithContentType(ctx, "application/json"), target, "old", `{"a":1}`, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := commandRename(ctx, b, target, "old", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := b.CheckExists(ctx, target, "old"); exists {
+		t.Error("old key still exists")
+	}
+	if value, err := b.Get(ctx, target, "new"); err != nil || string(value) != `{"a":1}` {
+		t.Errorf("got %s %v", value, err)
+	}
+	if k, err := findKey(ctx, b, target, "new"); err != nil || k.ContentType != "application/json" {
+		t.Errorf("got %+v %v", k, err)
+	}
+}
+
+func TestRenameFailsIfNewKeyExists(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "old", "first", false)
+	b.Put(ctx, target, "new", "second", false)
+
+	if err := commandRename(ctx, b, target, "old", "new"); !errors.Is(err, backend.ErrKeyExists) {
+		t.Fatalf("expected ErrKeyExists, got %v", err)
+	}
+	if value, _ := b.Get(ctx, target, "new"); string(value) != "second" {
+		t.Errorf("new key was overwritten, got %s", value)
+	}
+}
+
+func TestRenameKeepsStoredEncoding(t *testing.T) {
+	ctx := context.Background()
+	store, target := newTestStore(t)
+	gzipped, _ := backend.NewValueEncodingBackend(store, backend.EncodingGzip)
+	if err := gzipped.Put(ctx, target, "old", "value", false); err != nil {
+		t.Fatal(err)
+	}
+
+	plain, _ := backend.NewValueEncodingBackend(store, backend.EncodingNone)
+	if err := rename(ctx, plain, target, "old", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := store.Get(ctx, target, "new"); !strings.HasPrefix(string(stored), "kiya-encoding:gzip;") {
+		t.Errorf("expected the value to stay gzip encoded, got %s", stored)
+	}
+	if value, _ := plain.Get(ctx, target, "new"); string(value) != "value" {
+		t.Errorf("got %s want value", value)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/emicklei/tre"
+	"github.com/kramphub/kiya"
+	"github.com/kramphub/kiya/backend"
+	"gopkg.in/yaml.v3"
+)
+
+// renderManifest lists the templates to render in one pass.
+type renderManifest struct {
+	Outputs []renderOutput `json:"outputs" yaml:"outputs"`
+}
+
+// renderOutput describes a single template, where to write its result and which profile provides its secrets.
+type renderOutput struct {
+	Template string `json:"template" yaml:"template"`
+	Dest     string `json:"dest" yaml:"dest"`
+	// Profile is optional, the profile of the command is used if empty
+	Profile string `json:"profile" yaml:"profile"`
+}
+
+// loadRenderManifest reads a JSON or YAML (.yaml, .yml) manifest.
+func loadRenderManifest(filename string) (manifest renderManifest, err error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &manifest)
+	default:
+		err = json.Unmarshal(data, &manifest)
+	}
+	return
+}
+
+// commandRender renders all outputs of a manifest. Template and destination paths are relative to the manifest.
+// The backend of each profile is taken from the backends of this invocation, created when first needed.
+func commandRender(ctx context.Context, target *backend.Profile, manifestFilename string, vars map[string]string) error {
+	manifest, err := loadRenderManifest(manifestFilename)
+	if err != nil {
+		return tre.New(err, "render failed", "manifest", manifestFilename)
+	}
+	dir := filepath.Dir(manifestFilename)
+
+	for _, each := range manifest.Outputs {
+		profileName := each.Profile
+		if len(profileName) == 0 {
+			profileName = target.Label
+		}
+		profile, ok := kiya.Profiles[profileName]
+		if !ok {
+			return fmt.Errorf("no such profile [%s] in manifest [%s] please check your .kiya file", profileName, manifestFilename)
+		}
+		pb, err := backends.get(ctx, &profile)
+		if err != nil {
+			return tre.New(err, "render failed", "profile", profileName)
+		}
+		templateFilename := relativeTo(dir, each.Template)
+		dest := relativeTo(dir, each.Dest)
+		if err := renderTemplateFile(ctx, pb, &profile, templateFilename, dest, vars); err != nil {
+			return tre.New(err, "render failed", "template", templateFilename, "dest", dest)
+		}
+		fmt.Printf("Rendered [%s] to [%s] using [%s]\n", templateFilename, dest, profileName)
+	}
+	return nil
+}
+
+// renderTemplateFile executes a template file and writes the result to the destination file, using the permission of -file-mode.
+// Nothing is written if the template fails.
+func renderTemplateFile(ctx context.Context, b backend.Backend, target *backend.Profile, templateFilename, dest string, vars map[string]string) error {
+	processor, err := template.New("base").Funcs(templateFuncMap(ctx, b, target)).ParseFiles(templateFilename)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err := processor.ExecuteTemplate(&out, filepath.Base(templateFilename), templateData{Vars: vars}); err != nil {
+		return err
+	}
+	return writeSecretFile(dest, out.Bytes())
+}
+
+// relativeTo returns the path joined with dir unless the path is absolute.
+func relativeTo(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
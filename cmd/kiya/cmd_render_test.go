@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderTemplateFileWritesSecretFile(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "app.tmpl")
+	if err := os.WriteFile(tmpl, []byte("name={{.Vars.name}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(dir, "app.properties")
+	if err := os.WriteFile(dest, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b, target := newTestStore(t)
+	if err := renderTemplateFile(context.Background(), b, target, tmpl, dest, map[string]string{"name": "kiya"}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != 0600 {
+		t.Errorf("got permission %o want 600", got)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "name=kiya" {
+		t.Errorf("got %s", data)
+	}
+}
+
+func TestRenderTemplateFileFailureKeepsDest(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "app.tmpl")
+	if err := os.WriteFile(tmpl, []byte("partial {{.Missing}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(dir, "app.properties")
+	b, target := newTestStore(t)
+	if err := renderTemplateFile(context.Background(), b, target, tmpl, dest, nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("expected no file, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// replaceIn returns the value with at most count occurrences of old replaced by new, all if count is negative,
+// and the number of replacements. With regex, old is a regular expression and new may refer to its groups, e.g. $1.
+func replaceIn(value, old, new string, count int, regex bool) (string, int, error) {
+	if !regex {
+		if len(old) == 0 {
+			return "", 0, fmt.Errorf("cannot replace an empty string")
+		}
+		n := strings.Count(value, old)
+		if count >= 0 && count < n {
+			n = count
+		}
+		return strings.Replace(value, old, new, n), n, nil
+	}
+	pattern, err := regexp.Compile(old)
+	if err != nil {
+		return "", 0, err
+	}
+	matches := pattern.FindAllStringSubmatchIndex(value, count)
+	var result []byte
+	last := 0
+	for _, each := range matches {
+		result = append(result, value[last:each[0]]...)
+		result = pattern.ExpandString(result, new, value, each)
+		last = each[1]
+	}
+	result = append(result, value[last:]...)
+	return string(result), len(matches), nil
+}
+
+// commandReplaceIn replaces occurrences of old by new in the value of a key and stores the result.
+// It fails if nothing matches, unless allowNoMatch is set.
+// kiya [profile] replace-in [key] [old] [new]
+func commandReplaceIn(ctx context.Context, b backend.Backend, target *backend.Profile, key, old, new string, count int, regex, allowNoMatch bool) error {
+	registerSecret(old)
+	registerSecret(new)
+	// read and write the value as stored, such that it keeps its encoding regardless of -encode
+	ctx = backend.WithRawValue(ctx)
+	stored, err := b.Get(ctx, target, key)
+	if err != nil {
+		return err
+	}
+	encoding := backend.EncodingOf(stored)
+	data, err := backend.DecodeValue(stored)
+	backend.Zero(stored)
+	if err != nil {
+		return err
+	}
+	value := string(data)
+	backend.Zero(data)
+	registerSecret(value)
+	replaced, n, err := replaceIn(value, old, new, count, regex)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if allowNoMatch {
+			fmt.Printf("No occurrences of %s in [%s] of [%s], nothing changed\n", redact(old), key, target.Label)
+			return nil
+		}
+		return fmt.Errorf("no occurrences of %s in [%s] of [%s]", redact(old), key, target.Label)
+	}
+	if err := target.Policy.Validate(replaced); err != nil {
+		return fmt.Errorf("replaced value of [%s] violates the policy of [%s]: %w", key, target.Label, err)
+	}
+	encoded, err := backend.EncodeValue(replaced, encoding)
+	if err != nil {
+		return err
+	}
+	if err := b.Put(ctx, target, key, encoded, true); err != nil {
+		return err
+	}
+	fmt.Printf("Replaced %d occurrence(s) of %s by %s in [%s] of [%s]\n", n, redact(old), redact(new), key, target.Label)
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+func TestReplaceIn(t *testing.T) {
+	for _, each := range []struct {
+		value, old, new string
+		count           int
+		regex           bool
+		want            string
+		wantCount       int
+	}{
+		{"user:old@host/old", "old", "new", -1, false, "user:new@host/new", 2},
+		{"user:old@host/old", "old", "new", 1, false, "user:new@host/old", 1},
+		{"user:old@host", "missing", "new", -1, false, "user:old@host", 0},
+		{"user:s3cret@host", `:([^@]+)@`, ":rotated@", -1, true, "user:rotated@host", 1},
+		{"a1 b2 c3", `([a-z])(\d)`, "${2}$1", 2, true, "1a 2b c3", 2},
+	} {
+		got, n, err := replaceIn(each.value, each.old, each.new, each.count, each.regex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != each.want || n != each.wantCount {
+			t.Errorf("replaceIn(%q, %q, %q) got [%s] %d want [%s] %d", each.value, each.old, each.new, got, n, each.want, each.wantCount)
+		}
+	}
+	if _, _, err := replaceIn("value", "", "x", -1, false); err == nil {
+		t.Error("expected error for empty old")
+	}
+}
+
+func TestReplaceInKeepsStoredEncoding(t *testing.T) {
+	ctx := context.Background()
+	store, target := newTestStore(t)
+	gzipped, _ := backend.NewValueEncodingBackend(store, backend.EncodingGzip)
+	if err := gzipped.Put(ctx, target, "a", "user:old@host", false); err != nil {
+		t.Fatal(err)
+	}
+
+	plain, _ := backend.NewValueEncodingBackend(store, backend.EncodingNone)
+	if err := commandReplaceIn(ctx, plain, target, "a", "old", "new", -1, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := store.Get(ctx, target, "a"); !strings.HasPrefix(string(stored), "kiya-encoding:gzip;") {
+		t.Errorf("expected the value to stay gzip encoded, got %s", stored)
+	}
+	if value, _ := plain.Get(ctx, target, "a"); string(value) != "user:new@host" {
+		t.Errorf("got %s want user:new@host", value)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// Supported strategies for keys of a backup that already exist in the profile.
+const (
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	conflictRename    = "rename"
+	conflictFail      = "fail"
+)
+
+// restoreAction describes how a single key of a backup is restored.
+type restoreAction struct {
+	key       string // key in the backup
+	targetKey string // key in the profile, differs from key if renamed
+	result    string
+	skip      bool
+	overwrite bool
+	unchanged bool // skipped because the stored value is identical
+}
+
+// restoreItems puts all items in the profile, resolving keys that already exist using the conflict strategy.
+// All conflicts are resolved before anything is stored such that the fail strategy leaves the profile untouched.
+// Unless forced, an existing key is not overwritten with an identical value.
+// It returns an error if any key failed to restore.
+func restoreItems(ctx context.Context, b backend.Backend, target *backend.Profile, items map[string][]byte, onConflict string, force bool, concurrency int) error {
+	keys := make([]string, 0, len(items))
+	for k, v := range items {
+		keys = append(keys, k)
+		registerSecret(string(v))
+	}
+	sort.Strings(keys)
+
+	// a single listing instead of checking each key
+	existing, err := existingKeys(ctx, b, target)
+	if err != nil {
+		return fmt.Errorf("restore aborted, cannot list existing keys - %s", err.Error())
+	}
+	actions := make([]restoreAction, len(keys))
+	conflicts := []string{}
+	for i := range keys {
+		actions[i] = restoreAction{key: keys[i], targetKey: keys[i], result: "created"}
+		if existing[keys[i]] {
+			conflicts = append(conflicts, keys[i])
+			switch onConflict {
+			case conflictOverwrite:
+				actions[i].overwrite = true
+				actions[i].result = "overwritten"
+			case conflictSkip:
+				actions[i].skip = true
+				actions[i].result = "skipped, exists"
+			}
+		}
+	}
+	if len(conflicts) > 0 && onConflict == conflictFail {
+		sort.Strings(conflicts)
+		return fmt.Errorf("restore aborted, no keys were stored, key '%s' already exists", conflicts[0])
+	}
+	if onConflict == conflictRename {
+		taken := map[string]bool{}
+		for _, each := range keys {
+			taken[each] = true
+		}
+		for each := range existing {
+			taken[each] = true
+		}
+		for i := range actions {
+			if !contains(conflicts, actions[i].key) {
+				continue
+			}
+			actions[i].targetKey = renamedKey(actions[i].key, taken)
+			actions[i].result = "renamed to " + actions[i].targetKey
+		}
+	}
+	if !force {
+		// writing identical values only costs requests and creates versions
+		forEachConcurrently(len(actions), concurrency, func(i int) {
+			if !actions[i].overwrite {
+				return
+			}
+			if isUnchanged(ctx, b, target, actions[i].key, items[actions[i].key]) {
+				actions[i].skip = true
+				actions[i].unchanged = true
+				actions[i].result = "unchanged"
+			}
+		})
+	}
+
+	// the policy of the profile applies to restored values as to any other put
+	for i, each := range actions {
+		if each.skip {
+			continue
+		}
+		if err := target.Policy.Validate(string(items[each.key])); err != nil {
+			actions[i].skip = true
+			actions[i].result = fmt.Sprintf("failed - rejected by the policy of [%s], %v", target.Label, err)
+		}
+	}
+
+	if backend.SupportsBatch(b) {
+		values := map[string]string{}
+		for _, each := range actions {
+			if !each.skip {
+				values[each.targetKey] = string(items[each.key])
+			}
+		}
+		// conflicts are resolved so remaining existing keys must be overwritten
+		if err := backend.PutBatch(ctx, b, target, values, onConflict == conflictOverwrite); err != nil {
+			return fmt.Errorf("restore failed, no keys were stored - %s", err.Error())
+		}
+	} else {
+		forEachConcurrently(len(actions), concurrency, func(i int) {
+			each := actions[i]
+			if each.skip {
+				return
+			}
+			if err := b.Put(ctx, target, each.targetKey, string(items[each.key]), each.overwrite); err != nil {
+				actions[i].result = "failed - " + err.Error()
+			}
+		})
+	}
+
+	counts := map[string]int{}
+	for _, each := range actions {
+		fmt.Printf("%s: %s\n", each.key, each.result)
+		counts[restoreOutcome(each)]++
+	}
+	fmt.Printf("Restored %d key(s): %d created, %d overwritten, %d renamed, %d unchanged, %d skipped, %d failed\n",
+		len(actions), counts["created"], counts["overwritten"], counts["renamed"], counts["unchanged"], counts["skipped"], counts["failed"])
+	if counts["failed"] > 0 {
+		return fmt.Errorf("restore failed, %d key(s) failed to restore", counts["failed"])
+	}
+	return nil
+}
+
+// restoreOutcome returns the category of the result of an action.
+func restoreOutcome(action restoreAction) string {
+	switch {
+	case action.unchanged:
+		return "unchanged"
+	case strings.HasPrefix(action.result, "failed"):
+		return "failed"
+	case action.skip:
+		return "skipped"
+	case action.targetKey != action.key:
+		return "renamed"
+	case action.overwrite:
+		return "overwritten"
+	default:
+		return "created"
+	}
+}
+
+// renamedKey returns the key with the first numeric suffix that is not taken, neither by the backup nor by the profile.
+func renamedKey(key string, taken map[string]bool) string {
+	for i := 1; ; i++ {
+		candidate := key + "_" + strconv.Itoa(i)
+		if taken[candidate] {
+			continue
+		}
+		taken[candidate] = true
+		return candidate
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, each := range list {
+		if each == value {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidConflictStrategy returns whether strategy is one of the supported conflict strategies.
+func isValidConflictStrategy(strategy string) bool {
+	switch strategy {
+	case conflictSkip, conflictOverwrite, conflictRename, conflictFail:
+		return true
+	}
+	return false
+}
+
+// isUnchanged returns whether the key is stored with exactly the value.
+func isUnchanged(ctx context.Context, b backend.Backend, target *backend.Profile, key string, value []byte) bool {
+	current, err := b.Get(ctx, target, key)
+	return err == nil && bytes.Equal(current, value)
+}
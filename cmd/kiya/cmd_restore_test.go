@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+func TestRenamedKeySkipsTakenKeys(t *testing.T) {
+	taken := map[string]bool{"a": true, "a_1": true}
+	if got, want := renamedKey("a", taken), "a_2"; got != want {
+		t.Errorf("got [%s] want [%s]", got, want)
+	}
+	if got, want := renamedKey("a", taken), "a_3"; got != want {
+		t.Errorf("got [%s] want [%s]", got, want)
+	}
+}
+
+func TestIsUnchanged(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "a", "1", false)
+
+	if !isUnchanged(ctx, b, target, "a", []byte("1")) {
+		t.Error("expected unchanged")
+	}
+	if isUnchanged(ctx, b, target, "a", []byte("2")) {
+		t.Error("expected changed")
+	}
+	if isUnchanged(ctx, b, target, "missing", []byte("1")) {
+		t.Error("expected missing key to be changed")
+	}
+}
+
+// withoutBatch hides the BatchBackend of a store such that each item is put separately.
+type withoutBatch struct {
+	backend.Backend
+}
+
+func TestRestoreItemsOnConflict(t *testing.T) {
+	for _, each := range []struct {
+		onConflict string
+		want       map[string]string
+		wantErr    string
+	}{
+		{onConflict: conflictSkip, want: map[string]string{"a": "old", "b": "same", "c": "new"}},
+		{onConflict: conflictOverwrite, want: map[string]string{"a": "new", "b": "same", "c": "new"}},
+		{onConflict: conflictRename, want: map[string]string{"a": "old", "a_1": "new", "b": "same", "b_1": "same", "c": "new"}},
+		{onConflict: conflictFail, want: map[string]string{"a": "old", "b": "same"}, wantErr: "key 'a' already exists"},
+	} {
+		for _, batch := range []bool{true, false} {
+			ctx := context.Background()
+			store, target := newTestStore(t)
+			var b backend.Backend = store
+			if !batch {
+				b = withoutBatch{store}
+			}
+			b.Put(ctx, target, "a", "old", false)
+			b.Put(ctx, target, "b", "same", false)
+
+			items := map[string][]byte{"a": []byte("new"), "b": []byte("same"), "c": []byte("new")}
+			err := restoreItems(ctx, b, target, items, each.onConflict, false, 2)
+			if len(each.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), each.wantErr) {
+					t.Errorf("%s (batch %v): unexpected error %v", each.onConflict, batch, err)
+				}
+			} else if err != nil {
+				t.Errorf("%s (batch %v): unexpected error %v", each.onConflict, batch, err)
+			}
+			keys, _ := b.List(ctx, target)
+			if len(keys) != len(each.want) {
+				t.Errorf("%s (batch %v): got %d keys want %d", each.onConflict, batch, len(keys), len(each.want))
+			}
+			for key, want := range each.want {
+				if got, _ := b.Get(ctx, target, key); string(got) != want {
+					t.Errorf("%s (batch %v): got [%s] for %s want [%s]", each.onConflict, batch, got, key, want)
+				}
+			}
+		}
+	}
+}
+
+func TestRestoreItemsFailsIfAnyKeyFailed(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	target.Policy = &backend.Policy{MinLength: 4}
+	items := map[string][]byte{"a": []byte("long enough"), "b": []byte("no")}
+	err := restoreItems(ctx, b, target, items, conflictSkip, false, 1)
+	if err == nil || !strings.Contains(err.Error(), "1 key(s) failed") {
+		t.Errorf("unexpected error %v", err)
+	}
+	if got, _ := b.Get(ctx, target, "a"); string(got) != "long enough" {
+		t.Errorf("got [%s] want [long enough]", got)
+	}
+}
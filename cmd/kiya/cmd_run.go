@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// runStopTimeout is how long a command may take to stop after the restart signal before it is killed.
+const runStopTimeout = 10 * time.Second
+
+// parseRunArgs returns the optional filter and the command that follow run, separated by --.
+func parseRunArgs(args []string) (filter string, command []string, err error) {
+	for i, each := range args {
+		if each != "--" {
+			continue
+		}
+		if i > 1 {
+			return "", nil, fmt.Errorf("expected at most one filter before --, got %s", strings.Join(args[:i], " "))
+		}
+		if i == 1 {
+			filter = args[0]
+		}
+		command = args[i+1:]
+		if len(command) == 0 {
+			return "", nil, errors.New("missing command after --")
+		}
+		return filter, command, nil
+	}
+	return "", nil, errors.New("missing -- before the command, use kiya [profile] run [|filter] -- [command]")
+}
+
+// parseSignal returns the signal for a name such as SIGTERM or HUP.
+func parseSignal(name string) (os.Signal, error) {
+	switch strings.TrimPrefix(strings.ToUpper(name), "SIG") {
+	case "TERM":
+		return syscall.SIGTERM, nil
+	case "HUP":
+		return syscall.SIGHUP, nil
+	case "INT":
+		return syscall.SIGINT, nil
+	}
+	return nil, fmt.Errorf("unknown signal [%s], use SIGTERM, SIGHUP or SIGINT", name)
+}
+
+// sameEnv returns whether both lists have the same variables and values.
+func sameEnv(a, b []envVariable) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// commandRun runs a command with the keys matching the filter as environment variables and returns its exit code.
+// If interval is positive then the keys are polled each interval; on a change the command is stopped
+// using the restart signal and started again with the refreshed environment.
+// kiya [profile] run [|filter-term] -- [command] [|args]
+func commandRun(ctx context.Context, b backend.Backend, target *backend.Profile, filter string, command []string, interval time.Duration, restartSignal os.Signal, concurrency int) (int, error) {
+	variables, err := fetchEnv(ctx, b, target, filter, concurrency)
+	if err != nil {
+		return 1, err
+	}
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupts)
+	var ticks <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+	for {
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		cmd.Env = os.Environ()
+		for _, each := range variables {
+			cmd.Env = append(cmd.Env, each.name+"="+each.value)
+		}
+		if err := cmd.Start(); err != nil {
+			return 1, err
+		}
+		exited := make(chan error, 1)
+		go func() { exited <- cmd.Wait() }()
+	running:
+		for {
+			select {
+			case err := <-exited:
+				return exitCode(err)
+			case sig := <-interrupts:
+				forwardSignal(cmd, sig)
+			case <-ticks:
+				fresh, err := fetchEnv(ctx, b, target, filter, concurrency)
+				if err != nil {
+					log.Printf("[WARN] failed to check secrets, %s", err.Error())
+					continue
+				}
+				if sameEnv(variables, fresh) {
+					continue
+				}
+				log.Printf("secrets of [%s] changed, restarting %s", target.Label, command[0])
+				variables = fresh
+				if err := cmd.Process.Signal(restartSignal); err != nil && !errors.Is(err, os.ErrProcessDone) {
+					log.Printf("[WARN] failed to stop %s, killing it, %s", command[0], err.Error())
+					cmd.Process.Kill()
+				}
+				if code, interrupted, err := awaitStop(cmd, exited, interrupts); interrupted {
+					return code, err
+				}
+				break running
+			}
+		}
+	}
+}
+
+// awaitStop waits until the command, signalled to stop for a restart, has exited and kills it after runStopTimeout.
+// Interrupts are forwarded to the command; after one it must not be restarted, so its exit code is returned.
+func awaitStop(cmd *exec.Cmd, exited <-chan error, interrupts <-chan os.Signal) (code int, interrupted bool, err error) {
+	timeout := time.After(runStopTimeout)
+	for {
+		select {
+		case err := <-exited:
+			if !interrupted {
+				return 0, false, nil
+			}
+			code, err := exitCode(err)
+			return code, true, err
+		case sig := <-interrupts:
+			interrupted = true
+			forwardSignal(cmd, sig)
+		case <-timeout:
+			cmd.Process.Kill()
+		}
+	}
+}
+
+// forwardSignal sends the signal to the command, unless it already exited.
+func forwardSignal(cmd *exec.Cmd, sig os.Signal) {
+	if err := cmd.Process.Signal(sig); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		log.Printf("[WARN] failed to forward %v to the command, %s", sig, err.Error())
+	}
+}
+
+// exitCode returns the exit code of a finished command, or an error if it did not run to completion.
+func exitCode(err error) (int, error) {
+	if err == nil {
+		return 0, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	return 1, err
+}
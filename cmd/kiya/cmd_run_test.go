@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseRunArgs(t *testing.T) {
+	filter, command, err := parseRunArgs([]string{"db/", "--", "env", "-i"})
+	if err != nil || filter != "db/" || strings.Join(command, " ") != "env -i" {
+		t.Errorf("got %q %q %v", filter, command, err)
+	}
+	if filter, command, err := parseRunArgs([]string{"--", "env"}); err != nil || filter != "" || len(command) != 1 {
+		t.Errorf("got %q %q %v", filter, command, err)
+	}
+	for _, each := range [][]string{{"env"}, {"db/", "--"}, {"a", "b", "--", "env"}} {
+		if _, _, err := parseRunArgs(each); err == nil {
+			t.Errorf("expected error for %v", each)
+		}
+	}
+}
+
+func TestParseSignal(t *testing.T) {
+	if sig, err := parseSignal("hup"); err != nil || sig != syscall.SIGHUP {
+		t.Errorf("got %v %v", sig, err)
+	}
+	if _, err := parseSignal("SIGKILL"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestSameEnv(t *testing.T) {
+	a := []envVariable{{name: "A", value: "1"}, {name: "B", value: "2"}}
+	if !sameEnv(a, []envVariable{{name: "A", value: "1"}, {name: "B", value: "2"}}) {
+		t.Error("expected same")
+	}
+	if sameEnv(a, []envVariable{{name: "A", value: "1"}, {name: "B", value: "3"}}) || sameEnv(a, a[:1]) {
+		t.Error("expected different")
+	}
+}
+
+func TestRunRestartsOnChangedValue(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh available")
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "greeting", "hello", false)
+
+	// the first run waits to be restarted, the second one exits
+	runs := filepath.Join(dir, "runs")
+	script := `echo $GREETING >> ` + runs + `; [ "$GREETING" = bye ] && exit 3; exec sleep 10`
+	done := make(chan int, 1)
+	go func() {
+		code, err := commandRun(ctx, b, target, "", []string{"sh", "-c", script}, 50*time.Millisecond, syscall.SIGTERM, 1)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- code
+	}()
+	waitForFile(t, runs, "hello\n")
+	if err := b.Put(ctx, target, "greeting", "bye", true); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case code := <-done:
+		if code != 3 {
+			t.Errorf("got exit code %d want 3", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("command was not restarted")
+	}
+	if data, _ := os.ReadFile(runs); string(data) != "hello\nbye\n" {
+		t.Errorf("got runs %q", data)
+	}
+}
+
+func waitForFile(t *testing.T, name, content string) {
+	for i := 0; i < 100; i++ {
+		if data, _ := os.ReadFile(name); string(data) == content {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("%s does not contain %q", name, content)
+}
+
+func TestAwaitStopForwardsInterrupt(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Skip("no sleep available")
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	interrupts := make(chan os.Signal, 1)
+	interrupts <- os.Interrupt
+
+	_, interrupted, err := awaitStop(cmd, exited, interrupts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !interrupted {
+		t.Error("expected the command not to be restarted after an interrupt")
+	}
+}
@@ -1,21 +1,72 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
-	"flag"
-	"log"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"text/template"
 
 	"github.com/emicklei/tre"
+	"github.com/kramphub/kiya"
 	"github.com/kramphub/kiya/backend"
 )
 
-func commandTemplate(ctx context.Context, b backend.Backend, target *backend.Profile, outputFilename string) {
-	funcMap := template.FuncMap{
-		"kiya": templateFunction(ctx, b, target),
+// templateData is the data available to a template.
+type templateData struct {
+	// Vars are the non-secret values given on the command line
+	Vars map[string]string
+}
+
+// commandTemplate renders a template file, or the template read from stdin if the filename is empty or -,
+// and writes the result to the output file or else to stdout.
+// kiya [profile] template [|template-filename|-]
+func commandTemplate(ctx context.Context, b backend.Backend, target *backend.Profile, filename, outputFilename string, vars map[string]string) error {
+	var out bytes.Buffer
+	if err := executeTemplate(ctx, b, target, filename, os.Stdin, vars, &out); err != nil {
+		wd, _ := os.Getwd()
+		return tre.New(err, "templating failed", "filename", filename, "current workdirectory", wd)
+	}
+	if len(outputFilename) > 0 {
+		if err := writeSecretFile(outputFilename, out.Bytes()); err != nil {
+			return fmt.Errorf("unable to write output, %w", err)
+		}
+		return nil
+	}
+	os.Stdout.Write(out.Bytes())
+	return nil
+}
+
+// executeTemplate parses the template file, or reads the template from stdin if the filename is empty or -, and executes it.
+func executeTemplate(ctx context.Context, b backend.Backend, target *backend.Profile, filename string, stdin io.Reader, vars map[string]string, w io.Writer) error {
+	processor := template.New("base").Funcs(templateFuncMap(ctx, b, target))
+	templateName := "base"
+	if len(filename) > 0 && filename != "-" {
+		if _, err := processor.ParseFiles(filename); err != nil {
+			return err
+		}
+		templateName = filepath.Base(filename)
+	} else {
+		content, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		if _, err := processor.Parse(string(content)); err != nil {
+			return err
+		}
+	}
+	return processor.ExecuteTemplate(w, templateName, templateData{Vars: vars})
+}
+
+// templateFuncMap returns the functions available to a template.
+func templateFuncMap(ctx context.Context, b backend.Backend, target *backend.Profile) template.FuncMap {
+	return template.FuncMap{
+		"kiya":       templateFunction(ctx, b, target),
+		"secretFrom": secretFromFunction(ctx),
 		"base64": func(value string) string {
 			return base64.StdEncoding.EncodeToString([]byte(value))
 		},
@@ -23,46 +74,64 @@ func commandTemplate(ctx context.Context, b backend.Backend, target *backend.Pro
 			return os.Getenv(value)
 		},
 	}
-	processor := template.New("base").Funcs(funcMap)
-	templateName := "base"
+}
 
-	filename := flag.Arg(2)
-	if len(filename) > 0 {
-		t, err := processor.ParseFiles(filename)
+func templateFunction(ctx context.Context, b backend.Backend, target *backend.Profile) func(string) (string, error) {
+	return func(key string) (string, error) {
+		value, err := b.Get(ctx, target, key)
 		if err != nil {
-			wd, _ := os.Getwd()
-			log.Fatal(tre.New(err, "templating failed", "filename", filename, "current workdirectory", wd))
+			return "", deniedOr(err, "reading", key, target, tre.New(err, "templating failed", "key", key))
 		}
-		processor = t
-		templateName = filepath.Base(filename)
-	} else {
-		templateContent := readFromStdIn()
-		t, err := processor.Parse(templateContent)
+		registerSecret(string(value))
+		return string(value), nil
+	}
+}
+
+// secretFromFunction returns a template function that fetches a key from the named profile.
+// The backend of that profile is created on first use and shared with other references.
+func secretFromFunction(ctx context.Context) func(string, string) (string, error) {
+	return func(profileName, key string) (string, error) {
+		p, ok := kiya.Profiles[profileName]
+		if !ok {
+			return "", fmt.Errorf("no such profile [%s] for secret [%s]", profileName, key)
+		}
+		b, err := backends.get(ctx, &p)
 		if err != nil {
-			log.Fatal("templating failed", err)
+			return "", fmt.Errorf("unable to use profile [%s] for secret [%s]: %w", profileName, key, err)
 		}
-		processor = t
-	}
-	writer := os.Stdout
-	// change writer is output was specified
-	if len(outputFilename) > 0 {
-		out, err := os.Create(outputFilename)
+		value, err := b.Get(ctx, &p, key)
 		if err != nil {
-			log.Fatal("unable to create output", err)
+			if errors.Is(err, backend.ErrKeyNotFound) {
+				return "", fmt.Errorf("secret [%s] does not exist in [%s]", key, profileName)
+			}
+			return "", fmt.Errorf("unable to get secret [%s] from [%s]: %w", key, profileName, err)
 		}
-		writer = out
+		registerSecret(string(value))
+		return string(value), nil
 	}
-	defer writer.Close()
-	processor.ExecuteTemplate(writer, templateName, "")
 }
 
-func templateFunction(ctx context.Context, b backend.Backend, target *backend.Profile) func(string) string {
-	return func(key string) string {
+// renderValueTemplate returns the value composed by the template in which {{secret "key"}} is replaced by the value of that key in the profile.
+func renderValueTemplate(ctx context.Context, b backend.Backend, target *backend.Profile, text string) (string, error) {
+	funcs := templateFuncMap(ctx, b, target)
+	funcs["secret"] = func(key string) (string, error) {
 		value, err := b.Get(ctx, target, key)
 		if err != nil {
-			log.Fatal(tre.New(err, "templating failed", "key", key))
-			return ""
+			if errors.Is(err, backend.ErrKeyNotFound) {
+				return "", fmt.Errorf("secret [%s] does not exist in [%s]", key, target.Label)
+			}
+			return "", err
 		}
-		return string(value)
+		registerSecret(string(value))
+		return string(value), nil
+	}
+	t, err := template.New("value").Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, nil); err != nil {
+		return "", err
 	}
+	return buf.String(), nil
 }
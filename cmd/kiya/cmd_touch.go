@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/emicklei/tre"
+	"github.com/kramphub/kiya/backend"
+)
+
+// commandTouch stores the current value of a key again such that its creation time is updated.
+// Emitted events report the operation as a touch instead of a put.
+func commandTouch(ctx context.Context, b backend.Backend, target *backend.Profile, key string) error {
+	if err := touch(ctx, b, target, key); err != nil {
+		return tre.New(err, "touch failed", "key", key)
+	}
+	fmt.Printf("Successfully touched [%s] in [%s]\n", key, target.Label)
+	return nil
+}
+
+// touch writes back the value as stored, keeping the encoding it was stored with regardless of -encode.
+func touch(ctx context.Context, b backend.Backend, target *backend.Profile, key string) error {
+	ctx = backend.WithRawValue(ctx)
+	value, err := b.Get(ctx, target, key)
+	if err != nil {
+		return err
+	}
+	return b.Put(backend.WithOperation(ctx, "touch"), target, key, string(value), true)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+func TestTouchKeepsStoredEncoding(t *testing.T) {
+	ctx := context.Background()
+	store, target := newTestStore(t)
+	gzipped, _ := backend.NewValueEncodingBackend(store, backend.EncodingGzip)
+	if err := gzipped.Put(ctx, target, "a", "value", false); err != nil {
+		t.Fatal(err)
+	}
+
+	plain, _ := backend.NewValueEncodingBackend(store, backend.EncodingNone)
+	if err := touch(ctx, plain, target, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := store.Get(ctx, target, "a"); !strings.HasPrefix(string(stored), "kiya-encoding:gzip;") {
+		t.Errorf("expected the value to stay gzip encoded, got %s", stored)
+	}
+	if value, _ := plain.Get(ctx, target, "a"); string(value) != "value" {
+		t.Errorf("got %s want value", value)
+	}
+}
+
+func TestTouchKeepsMetadata(t *testing.T) {
+	ctx := context.Background()
+	store, target := newTestStore(t)
+	if err := store.Put(backend.WithInfo(backend.WithContentType(ctx, "application/json"), "rotated yearly"), target, "a", `{"a":1}`, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := touch(ctx, store, target, "a"); err != nil {
+		t.Fatal(err)
+	}
+	k, err := findKey(ctx, store, target, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.Info != "rotated yearly" || k.ContentType != "application/json" {
+		t.Errorf("expected metadata to survive touch, got %v", k)
+	}
+}
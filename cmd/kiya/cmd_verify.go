@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"io"
+	"os"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// commandVerify returns whether the stored value of the key equals the candidate.
+// Values are compared in constant time and never printed.
+func commandVerify(ctx context.Context, b backend.Backend, target *backend.Profile, key string, candidate []byte) (bool, error) {
+	stored, err := b.Get(ctx, target, key)
+	if err != nil {
+		return false, err
+	}
+	defer backend.Zero(stored)
+	registerSecret(string(stored))
+	registerSecret(string(candidate))
+	return subtle.ConstantTimeCompare(stored, candidate) == 1, nil
+}
+
+// readCandidate returns the value to verify, read from the file or else from stdin. Both are read the same way
+// as a value to put, such that a single trailing newline, e.g. written by echo, is not part of the value.
+func readCandidate(fromFile string, stdin io.Reader) ([]byte, error) {
+	if len(fromFile) == 0 {
+		value, err := readValue(stdin)
+		return []byte(value), err
+	}
+	f, err := os.Open(fromFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	value, err := readValue(f)
+	return []byte(value), err
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommandVerify(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "key", "value", false)
+
+	if ok, err := commandVerify(ctx, b, target, "key", []byte("value")); err != nil || !ok {
+		t.Errorf("expected match, got %v %v", ok, err)
+	}
+	if ok, _ := commandVerify(ctx, b, target, "key", []byte("other")); ok {
+		t.Error("expected mismatch")
+	}
+	if _, err := commandVerify(ctx, b, target, "missing", []byte("value")); err == nil {
+		t.Error("expected error for missing key")
+	}
+}
+
+func TestReadCandidateFromFileAndStdinAlike(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "candidate")
+	// as written by echo s3cret > candidate
+	if err := os.WriteFile(path, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fromFile, err := readCandidate(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromStdin, _ := readCandidate("", strings.NewReader("s3cret\n"))
+	if string(fromFile) != "s3cret" || string(fromStdin) != "s3cret" {
+		t.Errorf("got [%s] and [%s] want s3cret", fromFile, fromStdin)
+	}
+	if _, err := readCandidate(filepath.Join(t.TempDir(), "missing"), nil); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
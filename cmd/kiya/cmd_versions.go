@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kramphub/kiya/backend"
+	"github.com/olekukonko/tablewriter"
+)
+
+// errVersioningNotSupported is reported for backends that keep no version history.
+var errVersioningNotSupported = errors.New("versioning not supported")
+
+// commandVersions writes a table of the versions of the secret, the latest first, or if version is not empty the value of that version.
+// kiya [profile] versions [key] [|version]
+func commandVersions(ctx context.Context, b backend.Backend, target *backend.Profile, key, version string, w io.Writer) error {
+	if len(version) > 0 {
+		value, err := backend.GetVersion(ctx, b, target, key, version)
+		if err != nil {
+			return versionsError(err, target)
+		}
+		return writeValue(w, value, false)
+	}
+	versions, err := backend.ListVersions(ctx, b, target, key)
+	if err != nil {
+		return versionsError(err, target)
+	}
+	data := [][]string{}
+	for _, each := range versions {
+		data = append(data, []string{each.ID, each.CreatedAt.Format(time.RFC3339), each.State})
+	}
+	table := tablewriter.NewWriter(w)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Version", "Created", "State"})
+	table.AppendBulk(data)
+	table.Render()
+	return nil
+}
+
+func versionsError(err error, target *backend.Profile) error {
+	if errors.Is(err, backend.ErrNotSupported) {
+		return fmt.Errorf("%w by backend [%s] of [%s]", errVersioningNotSupported, target.Backend, target.Label)
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// twoVersions is a backend whose every key has the versions 2 and 1.
+type twoVersions struct {
+	backend.Backend
+}
+
+func (twoVersions) DeleteVersion(ctx context.Context, p *backend.Profile, key, version string) error {
+	return nil
+}
+
+func (twoVersions) ListVersions(ctx context.Context, p *backend.Profile, key string) ([]backend.Version, error) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	return []backend.Version{{ID: "2", CreatedAt: created, State: "ENABLED"}, {ID: "1", CreatedAt: created, State: "DISABLED"}}, nil
+}
+
+func (twoVersions) GetVersion(ctx context.Context, p *backend.Profile, key, version string) ([]byte, error) {
+	return []byte(key + "@" + version), nil
+}
+
+func TestVersions(t *testing.T) {
+	target := &backend.Profile{Label: "test", Backend: "gsm"}
+	out := new(bytes.Buffer)
+	if err := commandVersions(context.Background(), twoVersions{}, target, "a", "", out); err != nil {
+		t.Fatal(err)
+	}
+	for _, each := range []string{"VERSION", "2024-01-02T03:04:05Z", "DISABLED"} {
+		if !strings.Contains(out.String(), each) {
+			t.Errorf("missing %s in %s", each, out)
+		}
+	}
+	out.Reset()
+	if err := commandVersions(context.Background(), twoVersions{}, target, "a", "1", out); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "a@1\n"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestVersionsNotSupported(t *testing.T) {
+	b, target := newTestStore(t)
+	target.Backend = "file"
+	err := commandVersions(context.Background(), b, target, "a", "", new(bytes.Buffer))
+	if !errors.Is(err, errVersioningNotSupported) {
+		t.Errorf("expected versioning not supported, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/emicklei/tre"
+	"github.com/kramphub/kiya/backend"
+	"github.com/olekukonko/tablewriter"
+)
+
+// commandWhoCan writes a table of the members, and their roles, that have access to the secret.
+// kiya [profile] who-can [key]
+func commandWhoCan(ctx context.Context, b backend.Backend, target *backend.Profile, key string, w io.Writer) error {
+	bindings, err := backend.WhoCan(ctx, b, target, key)
+	if err != nil {
+		if errors.Is(err, backend.ErrNotSupported) {
+			return fmt.Errorf("who-can is not supported by backend [%s] of [%s]", target.Backend, target.Label)
+		}
+		return tre.New(err, "who-can failed", "key", key)
+	}
+	data := [][]string{}
+	for _, each := range bindings {
+		for _, member := range each.Members {
+			data = append(data, []string{member, each.Role})
+		}
+	}
+	sort.Slice(data, func(i, j int) bool {
+		if data[i][0] == data[j][0] {
+			return data[i][1] < data[j][1]
+		}
+		return data[i][0] < data[j][0]
+	})
+	table := tablewriter.NewWriter(w)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Member", "Role"})
+	table.AppendBulk(data)
+	table.Render()
+	return nil
+}
@@ -12,7 +12,6 @@ import (
 	"encoding/pem"
 	"fmt"
 	"io"
-	"os"
 )
 
 // generateSecret returns generated secret as base64 string.
@@ -144,6 +143,5 @@ func exportPublicKeyFromPEMString(pemStr []byte) *rsa.PublicKey {
 
 // saveKeyToFile saves key to file.
 func saveKeyToFile(keyPem, filename string) error {
-	pemBytes := []byte(keyPem)
-	return os.WriteFile(filename, pemBytes, 0400)
+	return writeSecretFile(filename, []byte(keyPem))
 }
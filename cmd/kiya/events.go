@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// openEventsWriter returns the stream for mutation events; stdout, stderr or a file to append to.
+func openEventsWriter(destination string) (io.WriteCloser, error) {
+	switch destination {
+	case "stdout", "-":
+		return nopCloser{os.Stdout}, nil
+	case "stderr":
+		return nopCloser{os.Stderr}, nil
+	default:
+		return os.OpenFile(destination, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	}
+}
+
+// currentActor returns the name of the user that runs kiya.
+func currentActor() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// writerCache shares the event streams, by destination, between the backends of all profiles of an invocation.
+type writerCache struct {
+	mutex   sync.Mutex
+	writers map[string]io.WriteCloser
+}
+
+var eventWriters = &writerCache{writers: map[string]io.WriteCloser{}}
+
+// get returns the stream for the destination, opening it on first use.
+func (c *writerCache) get(destination string, open func(string) (io.WriteCloser, error)) (io.WriteCloser, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if w, ok := c.writers[destination]; ok {
+		return w, nil
+	}
+	w, err := open(destination)
+	if err != nil {
+		return nil, err
+	}
+	c.writers[destination] = w
+	return w, nil
+}
+
+// closeAll closes all streams and returns an error describing those that failed.
+func (c *writerCache) closeAll() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var failures []string
+	for destination, each := range c.writers {
+		if err := each.Close(); err != nil {
+			failures = append(failures, fmt.Sprintf("[%s] %s", destination, err.Error()))
+		}
+	}
+	c.writers = map[string]io.WriteCloser{}
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return errors.New(strings.Join(failures, ", "))
+	}
+	return nil
+}
@@ -1,18 +1,136 @@
 package main
 
-import "flag"
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+)
 
 var (
 	oConfigFilename = flag.String("c", "", "location of the configuration file. If empty then expect .kiya in $HOME.")
 	oAuthLocation   = flag.String("a", "", "location of the JSON key credentials file. If empty then use the Google Application Defaults.")
 	oVersion        = flag.Bool("version", false, "show the version of the tool")
-	oOutputFilename = flag.String("o", "", "if not empty then write the secret to a file else write to stdout (get)")
+	oOutputFilename = flag.String("o", "", "if not empty then write the secret or output to a file else write to stdout (get, template, export)")
 	oQuiet          = flag.Bool("quiet", false, "don't prompt for confirmation on destructive actions")
+	oCombinedKey    = flag.Bool("combined-key", false, "read profile and key from a single [profile/key] argument, e.g. kiya get prod/db/password")
+	oDefault        = flag.String("default", "", "if set then write this value when the key does not exist (get)")
+	oField          = flag.String("field", "", "if not empty then copy this top-level field of a JSON value (copy)")
+	oFormat         = flag.String("format", "", "if not empty then copy the result of this Go template with .Keys, .Values and .Value (copy)")
+	oExportFormat   = flag.String("export-format", "ndjson", "output format: ndjson, env, json or yaml (export)")
+	oImportFormat   = flag.String("import-format", "", "input format: env or json, by default from the file extension (import)")
+	oJoin           = flag.String("join", ":", "separator used to join the values of multiple keys (copy)")
+	oOutput         = flag.String("output", "table", "format of a listing: table, markdown, e.g. for a GitHub Actions step summary, or json with the full info of each key (list) or an array of key and value (get-many)")
+	oMatch          = flag.String("match", "substring", "how a filter matches key names: substring, exact, prefix or glob")
+	oMetricsAddr    = flag.String("metrics-addr", "", "if not empty then serve Prometheus metrics of backend operations on this address, e.g. :9090")
+	oEncode         = flag.String("encode", "none", "store the value encoded as none, base64 or gzip; reversed automatically when read (put, paste, generate)")
+	oKeyVersion     = flag.String("key-version", "", "if not empty then only destroy this version of the key, for versioned backends such as gsm (delete)")
+	oReveal         = flag.Bool("reveal", false, "on a terminal, show the value until a key is pressed and then clear it from the screen (get, copy)")
+	oShowDiff       = flag.Bool("show-diff", false, "show a redacted diff between the current and new value before confirming an overwrite (put, paste, generate)")
+	oOverwrite      = flag.Bool("overwrite", false, "overwrite existing keys without prompting for confirmation (put, paste, generate)")
+	oEmitEvents     = flag.String("emit-events", "", "if not empty then write a JSON event line for each put and delete to stdout, stderr or the named file")
+	oConcurrency    = flag.Int("concurrency", runtime.GOMAXPROCS(0), "maximum number of concurrent backend operations (backup, restore, get-many)")
+	oMinEntropy     = flag.Float64("min-entropy", 0, "if positive then refuse a generated secret with a lower estimated entropy in bits, e.g. 80 (generate)")
+	oForce          = flag.Bool("force", false, "generate a secret even if its estimated entropy is below -min-entropy (generate); write values even if unchanged (restore, migrate)")
+	oReadOnly       = flag.Bool("read-only", false, "refuse all commands that change secrets, also enabled by KIYA_READ_ONLY=true")
+	oExport         = flag.Bool("export", false, "write each line as export KEY='VALUE' such that the output can be sourced by a shell (env)")
+	oValueTemplate  = flag.String("template", "", "if not empty then put the result of this Go template in which {{secret \"key\"}} is the value of another key, e.g. a connection string (put)")
+	oPretty         = flag.Bool("pretty", false, "write JSON output indented instead of compact on a single line; also indents a value that is a JSON object or array (get)")
+	oMaxColWidth    = flag.Int("max-col-width", 80, "maximum number of characters of the info in a table, longer content is truncated with an ellipsis unless -wrap is set; 0 means no maximum (list)")
+	oWrap           = flag.Bool("wrap", false, "wrap long table cells over multiple lines instead of truncating them (list)")
+	oFromFile       = flag.String("from-file", "", "if not empty then read the value to compare from this file instead of stdin (verify)")
+	oStdinJSON      = flag.Bool("stdin-json", false, "read a JSON object from stdin and store each top-level field as its own key, optionally under the key as prefix (put)")
+	oDryRun         = flag.Bool("dry-run", false, "only report what would be stored (put -stdin-json) or deleted (prune)")
+	oStripPrefix    = flag.String("strip-prefix", "", "if not empty then show key names without this prefix, e.g. prod/db/ (list)")
+	oNoDecrypt      = flag.Bool("no-decrypt", false, "get the encrypted form of SSM SecureString values, e.g. to migrate them; can only be stored using the same KMS key (ssm)")
+	oFileMode       = flag.String("file-mode", "0600", "octal permission of written files with secrets, restricted by the umask (get -o, template -o, render, keygen, backup)")
+	oIncludeValues  = flag.Bool("include-values", false, "also write the value of each key, required for the env, json and yaml formats (export)")
+	oTimeout        = flag.Duration("timeout", 0, "if positive then cancel backend operations that have not completed within this duration after the start, e.g. 5m")
+	oWatchInterval  = flag.Duration("watch-interval", 0, "if positive then check the secrets each interval and restart the command when a value changes, e.g. 30s (run)")
+	oRestartSignal  = flag.String("restart-signal", "SIGTERM", "signal that stops the command before it is restarted: SIGTERM, SIGHUP or SIGINT (run)")
+	oCount          = flag.Int("count", -1, "maximum number of occurrences to replace, all if negative (replace-in)")
+	oRegex          = flag.Bool("regex", false, "treat the text to replace as a regular expression; the replacement can refer to groups, e.g. $1 (replace-in)")
+	oAllowNoMatch   = flag.Bool("allow-no-match", false, "do not fail if there is nothing to replace (replace-in)")
+	oMaxAge         = flag.String("max-age", "", "if not empty then fail, without writing the value, if the value was stored longer ago than this, e.g. 90d or 36h (get)")
+	oUnambiguous    = flag.Bool("unambiguous", false, "exclude characters that are easily confused, such as 0 and O or 1, l and I (generate)")
+	oK8sSecret      = flag.String("k8s-secret", "", "if not empty then write a Kubernetes Secret manifest with this name, and optionally /field, with each key as key=field (get)")
+	oNamespace      = flag.String("namespace", "", "namespace of the Kubernetes Secret manifest (get -k8s-secret)")
+	oFailOnEmpty    = flag.Bool("fail-on-empty", false, "exit with an error if the value of an existing key is empty or only whitespace (get)")
+	oOlderThan      = flag.String("older-than", "", "if not empty then select keys created longer ago than this, e.g. 1y or 90d (prune)")
+	oExpired        = flag.Bool("expired", false, "select keys that have expired, for backends with expiry such as gsm and akv (prune)")
+	oSelect         = flag.Bool("select", false, "list the keys matching the optional filter and pick the one to copy (copy)")
+	oFirst          = flag.Bool("first", false, "with -select, copy the only matching key without prompting and fail if several keys match (copy)")
+	oMaxAttempts    = flag.Int("max-attempts", 0, "if positive then the maximum number of attempts of each backend request, overrides retry.maxAttempts of the profile")
+	oRetryBaseDelay = flag.String("retry-base-delay", "", "if not empty then the delay before the first retry, e.g. 200ms, overrides retry.baseDelay of the profile")
+	oRetryMaxDelay  = flag.String("retry-max-delay", "", "if not empty then the maximum delay between retries, e.g. 5s, overrides retry.maxDelay of the profile")
+	oRetryJitter    = flag.Float64("retry-jitter", -1, "if not negative then the fraction by which each retry delay is randomly shortened, overrides retry.jitter of the profile")
+	oVerbose        = flag.Bool("verbose", false, "print whether the key exists (exists)")
+	oRaw            = flag.Bool("raw", false, "write the value byte-for-byte, without a trailing newline and without -pretty (get)")
+	oContentType    = flag.String("content-type", "", "if not empty then store this content type, e.g. application/json, with the secret (put, paste, generate)")
+
+	// Configuration flags
+	oSetProfile      = flag.String("set-profile", "", "if not empty then use this JSON profile, or JSON file, as profile named _, e.g. '{\"backend\":\"gsm\",\"projectID\":\"my-project\"}'")
+	oProfileFileGlob = flag.String("profile-file-glob", "", "if not empty then load and merge all configuration files matching this glob or in this directory instead of -c, e.g. ~/.config/kiya")
+
+	// Clipboard flags
+	oClipboardCmd      = flag.String("clipboard-cmd", "", "command that reads a value from stdin to put on the clipboard, e.g. wl-copy. Overrides $KIYA_CLIPBOARD_CMD")
+	oOSC52             = flag.Bool("osc52", false, "copy using the OSC52 terminal escape sequence, enabled automatically over SSH if no clipboard is available")
+	oClipboardPasteCmd = flag.String("clipboard-paste-cmd", "", "command that writes the clipboard to stdout, e.g. wl-paste. Overrides $KIYA_CLIPBOARD_PASTE_CMD")
 
 	// Backup flags
 	oEncryptBackup          = flag.Bool("encrypt-backup", false, "if true, the backup will be encrypted")
 	oBackupKeyStore         = flag.String("backup-key-store", "file", "storage type for public key, 'store' or 'file'")
 	oBackupKey              = flag.String("backup-key", "./kiya_backupkey_rsa", "key to encrypt/decrypt the backup")
 	oBackupPath             = flag.String("backup-path", "./kiya_backup", "backup file path")
-	oBackupRestoreOverwrite = flag.Bool("backup-restore-overwrite", false, "if true, the restore will overwrite existing secrets")
+	oBackupRestoreOverwrite = flag.Bool("backup-restore-overwrite", false, "if true, the restore will overwrite existing secrets, same as -on-conflict overwrite")
+	oRestoreOnConflict      = flag.String("on-conflict", "skip", "how restore handles existing keys: skip, overwrite, rename (append a numeric suffix) or fail (restore nothing)")
 )
+
+var (
+	// oVars holds the template variables given by repeatable -set key=value flags
+	oVars = keyValues{}
+	// oBackupExcludes holds the patterns given by repeatable -exclude flags
+	oBackupExcludes stringList
+	// oProtect holds the prefixes of keys given by repeatable -protect flags
+	oProtect stringList
+)
+
+func init() {
+	flag.Var(oVars, "set", "key=value pair available as {{.Vars.key}} in a template, can be repeated (template)")
+	flag.Var(&oProtect, "protect", "prefix of keys that are never deleted, can be repeated (prune)")
+	flag.Var(&oBackupExcludes, "exclude", "glob pattern, or prefix ending with /, of keys to skip, can be repeated (backup)")
+}
+
+// keyValues is a flag.Value that collects key=value pairs.
+type keyValues map[string]string
+
+func (k keyValues) String() string {
+	pairs := make([]string, 0, len(k))
+	for key, value := range k {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (k keyValues) Set(pair string) error {
+	key, value, ok := strings.Cut(pair, "=")
+	if !ok || len(key) == 0 {
+		return fmt.Errorf("expected key=value, got [%s]", pair)
+	}
+	k[key] = value
+	return nil
+}
+
+// stringList is a flag.Value that collects repeated values.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// ageUnits are the units of an age in addition to those of a Go duration.
+var ageUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+	"y": 365 * 24 * time.Hour,
+}
+
+// parseMaxAge returns the duration of a Go duration such as 36h or of a number of days, weeks or years such as 90d, 2w or 1y.
+func parseMaxAge(s string) (time.Duration, error) {
+	for suffix, unit := range ageUnits {
+		if number := strings.TrimSuffix(s, suffix); number != s {
+			n, err := strconv.Atoi(number)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid age [%s], use a number of days, weeks or years such as 90d, 2w or 1y or a duration such as 36h", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid age [%s], use a number of days, weeks or years such as 90d, 2w or 1y or a duration such as 36h", s)
+	}
+	return d, nil
+}
+
+// findKey returns the metadata of the key from the listing of the profile.
+func findKey(ctx context.Context, b backend.Backend, target *backend.Profile, key string) (backend.Key, error) {
+	keys, err := b.List(ctx, target)
+	if err != nil {
+		return backend.Key{}, err
+	}
+	for _, each := range keys {
+		if each.Name == key {
+			return each, nil
+		}
+	}
+	return backend.Key{}, fmt.Errorf("%s %w", key, backend.ErrKeyNotFound)
+}
+
+// storedKey returns the key with, as its creation time, when its current value was stored. For backends that keep versions
+// this is the creation time of the latest enabled version, such that a rotated secret is fresh; otherwise it is the
+// creation time of the key from the listing of the profile.
+func storedKey(ctx context.Context, b backend.Backend, target *backend.Profile, key string) (backend.Key, error) {
+	versions, err := backend.ListVersions(ctx, b, target, key)
+	if errors.Is(err, backend.ErrNotSupported) {
+		return findKey(ctx, b, target, key)
+	}
+	if err != nil {
+		return backend.Key{}, err
+	}
+	return backend.Key{Name: key, CreatedAt: latestEnabled(versions)}, nil
+}
+
+// storedKeys returns the listed keys with, as their creation time, when their current value was stored; see storedKey.
+// Keys of backends that do not keep versions are returned as listed.
+func storedKeys(ctx context.Context, b backend.Backend, target *backend.Profile, keys []backend.Key) ([]backend.Key, error) {
+	stored := make([]backend.Key, 0, len(keys))
+	for _, each := range keys {
+		versions, err := backend.ListVersions(ctx, b, target, each.Name)
+		if errors.Is(err, backend.ErrNotSupported) {
+			return keys, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		each.CreatedAt = latestEnabled(versions)
+		stored = append(stored, each)
+	}
+	return stored, nil
+}
+
+// latestEnabled returns the creation time of the latest enabled version, zero if there is none.
+func latestEnabled(versions []backend.Version) (created time.Time) {
+	for _, each := range versions {
+		if (each.State == "" || each.State == "ENABLED") && each.CreatedAt.After(created) {
+			created = each.CreatedAt
+		}
+	}
+	return created
+}
+
+// checkFreshness returns an error if the key was created longer than maxAge ago or if its creation time is unknown.
+func checkFreshness(k backend.Key, maxAge time.Duration, now time.Time) error {
+	if k.CreatedAt.IsZero() {
+		return fmt.Errorf("freshness of [%s] cannot be determined, its backend does not provide a creation time", k.Name)
+	}
+	if age := now.Sub(k.CreatedAt); age > maxAge {
+		return fmt.Errorf("[%s] is older than %s, created %s", k.Name, maxAge, k.CreatedAt.Format(time.RFC3339))
+	}
+	return nil
+}
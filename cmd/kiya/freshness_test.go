@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+func TestParseMaxAge(t *testing.T) {
+	if d, err := parseMaxAge("90d"); err != nil || d != 90*24*time.Hour {
+		t.Errorf("got %v %v", d, err)
+	}
+	if d, err := parseMaxAge("1y"); err != nil || d != 365*24*time.Hour {
+		t.Errorf("got %v %v", d, err)
+	}
+	if d, err := parseMaxAge("36h"); err != nil || d != 36*time.Hour {
+		t.Errorf("got %v %v", d, err)
+	}
+	for _, each := range []string{"", "d", "-1d", "soon", "0s"} {
+		if _, err := parseMaxAge(each); err == nil {
+			t.Errorf("expected error for [%s]", each)
+		}
+	}
+}
+
+func TestCheckFreshness(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	if err := checkFreshness(backend.Key{Name: "a", CreatedAt: now.AddDate(0, 0, -10)}, 30*24*time.Hour, now); err != nil {
+		t.Error(err)
+	}
+	if err := checkFreshness(backend.Key{Name: "a", CreatedAt: now.AddDate(0, 0, -31)}, 30*24*time.Hour, now); err == nil {
+		t.Error("expected error for stale key")
+	}
+	if err := checkFreshness(backend.Key{Name: "a"}, time.Hour, now); err == nil {
+		t.Error("expected error for unknown creation time")
+	}
+}
+
+// rotated is a backend whose every key was created two years ago and rotated since.
+type rotated struct {
+	twoVersions
+	now time.Time
+}
+
+func (r rotated) ListVersions(ctx context.Context, p *backend.Profile, key string) ([]backend.Version, error) {
+	return []backend.Version{
+		{ID: "3", CreatedAt: r.now.AddDate(0, 0, -1), State: "DISABLED"},
+		{ID: "2", CreatedAt: r.now.AddDate(0, 0, -2), State: "ENABLED"},
+		{ID: "1", CreatedAt: r.now.AddDate(-2, 0, 0), State: "ENABLED"},
+	}, nil
+}
+
+func TestStoredKeyUsesLatestEnabledVersion(t *testing.T) {
+	now := time.Now()
+	k, err := storedKey(context.Background(), rotated{now: now}, &backend.Profile{}, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := now.AddDate(0, 0, -2); !k.CreatedAt.Equal(want) {
+		t.Errorf("got %v want %v", k.CreatedAt, want)
+	}
+	if err := checkFreshness(k, 90*24*time.Hour, now); err != nil {
+		t.Errorf("expected rotated key to be fresh, got %v", err)
+	}
+}
+
+func TestStoredKeyWithoutVersions(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	b.Put(ctx, target, "a", "value", false)
+	k, err := storedKey(ctx, b, target, "a")
+	if err != nil || k.Name != "a" {
+		t.Errorf("got %v %v", k, err)
+	}
+	if _, err := storedKey(ctx, b, target, "missing"); err == nil {
+		t.Error("expected error for missing key")
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// newTestStore returns a FileStore in a temporary directory, with master password "test", and a profile to use it with.
+func newTestStore(t *testing.T) (*backend.FileStore, *backend.Profile) {
+	t.Helper()
+	b := backend.NewFileStore(filepath.Join(t.TempDir(), "store"), "test")
+	b.SetParameter("masterPassword", []byte("test"))
+	return b, &backend.Profile{Label: "test"}
+}
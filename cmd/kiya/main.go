@@ -2,23 +2,28 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/keyvault/azsecrets"
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	cloudstore "cloud.google.com/go/storage"
-	"github.com/atotto/clipboard"
 	"github.com/emicklei/tre"
 	"github.com/kramphub/kiya"
 	"github.com/kramphub/kiya/backend"
 	"golang.org/x/net/context"
+	"golang.org/x/term"
 	"google.golang.org/api/cloudkms/v1"
 	"google.golang.org/api/option"
 )
@@ -31,36 +36,125 @@ const (
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// run executes the command given by the arguments and returns the exit code of the process.
+// Backends are closed after the command completed; see exitCodeAfterClose.
+func run() (code int) {
 	ctx := context.Background()
+	// never leak secret values in error output
+	log.SetOutput(scrubWriter{os.Stderr})
 
 	flag.Parse()
 	if *oVersion {
 		fmt.Println("kiya version", version)
-		os.Exit(0)
+		return 0
+	}
+	if len(*oProfileFileGlob) > 0 && len(*oSetProfile) > 0 {
+		kiya.AddAdHocProfileGlob(*oSetProfile, *oProfileFileGlob)
+	} else if len(*oProfileFileGlob) > 0 {
+		kiya.LoadConfigurationGlob(*oProfileFileGlob)
+	} else if len(*oSetProfile) > 0 {
+		kiya.AddAdHocProfile(*oSetProfile, *oConfigFilename)
+	} else {
+		kiya.LoadConfiguration(*oConfigFilename)
+	}
+	args, err := resolveCombinedKey(flag.Args(), kiya.Profiles, *oCombinedKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	args = normalizeKeyArgs(args, kiya.Profiles)
+	// parse again such that all commands see the resolved arguments
+	flag.CommandLine.Parse(args)
+	if !isValidMatchMode(*oMatch) {
+		log.Fatalf("invalid match mode [%s], use substring, exact, prefix or glob", *oMatch)
+	}
+	if *oOutput != outputTable && *oOutput != outputMarkdown && *oOutput != outputJSON {
+		log.Fatalf("invalid output [%s], use table, markdown or json", *oOutput)
+	}
+	if _, err := parseFileMode(*oFileMode); err != nil {
+		log.Fatal(err)
+	}
+	concurrency := *oConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if *oTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *oTimeout)
+		defer cancel()
 	}
-	kiya.LoadConfiguration(*oConfigFilename)
 	if len(flag.Args()) < 2 {
 		fmt.Println("kiya [flags] [profile] [get|put|delete|list|template|copy|paste|move|generate] [|parent/key] [|value] [|template-filename] [|secret-length]")
 		fmt.Println("    if value, template-filename or secret length is needed, but missing, it is read from stdin")
 		flag.PrintDefaults()
-		os.Exit(0)
+		return 0
 	}
 
+	// also if the command failed, such that master passwords are wiped from memory
+	defer func() {
+		code = exitCodeAfterClose(code, backends.closeAll())
+		if err := eventWriters.closeAll(); err != nil {
+			log.Printf("[WARN] failed to close the events output, %s", err.Error())
+		}
+	}()
+	return commandExitCode(runCommand(ctx, concurrency))
+}
+
+// runCommand executes the command of the arguments and returns its exit code or the error why it failed.
+func runCommand(ctx context.Context, concurrency int) (int, error) {
 	profileName := flag.Arg(0)
+	if _, ok := kiya.Profiles[profileName]; !ok && profileName == "migrate" {
+		if !isDryRun(flag.Args()[1:]) {
+			if err := guardReadOnly("migrate"); err != nil {
+				return 0, err
+			}
+		}
+		return 0, commandMigrate(ctx, flag.Args()[1:], concurrency)
+	}
+	if _, ok := kiya.Profiles[profileName]; !ok && profileName == "locate" {
+		return 0, commandLocate(ctx, flag.Args()[1:])
+	}
+	if _, ok := kiya.Profiles[profileName]; !ok && profileName == "config" {
+		return 0, commandConfig(flag.Args()[1:])
+	}
 	target, ok := kiya.Profiles[profileName]
 	if !ok {
-		log.Fatalf("no such profile [%s] please check your .kiya file", profileName)
+		return 0, fmt.Errorf("no such profile [%s] please check your .kiya file", profileName)
+	}
+	// before any backend call such that no backend can bypass it
+	if flag.Arg(1) != "prune" || !*oDryRun {
+		if err := guardReadOnly(flag.Arg(1)); err != nil {
+			return 0, err
+		}
 	}
 
 	b, err := getBackend(ctx, &target)
 	if err != nil {
-		log.Fatalf("failed to intialize the secret provider backend, %s", err.Error())
+		return 0, fmt.Errorf("failed to intialize the secret provider backend, %s", err.Error())
 	}
-	defer func() {
-		if err := b.Close(); err != nil {
-			log.Fatalf("failed to close the secret provider backend, %s", err.Error())
+	b, err = decorateBackend(b, &target)
+	if err != nil {
+		return 0, err
+	}
+	if len(*oMetricsAddr) > 0 {
+		metrics := backend.NewMetricsBackend(b)
+		startMetricsServer(*oMetricsAddr, metrics)
+		b = metrics
+	}
+	if *oNoDecrypt {
+		if target.Backend != "ssm" {
+			return 0, fmt.Errorf("-no-decrypt is only supported by the ssm backend, not by [%s] of [%s]", target.Backend, target.Label)
 		}
-	}()
+		b.SetParameter("noDecrypt", true)
+	}
+	// commands using other profiles share the backends
+	backends.add(target.Label, b)
+
+	if len(*oContentType) > 0 {
+		ctx = backend.WithContentType(ctx, *oContentType)
+	}
 
 	// what command?
 	switch flag.Arg(1) {
@@ -69,32 +163,60 @@ func main() {
 		key := flag.Arg(2)
 		value := flag.Arg(3)
 
-		if shouldPromptForPassword(b) {
-			pass := promptForPassword()
-			b.SetParameter("masterPassword", pass)
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
 		}
 
-		if len(value) != 0 {
-			commandPutPasteGenerate(ctx, b, &target, "put", key, value, doPrompt)
+		if *oStdinJSON {
+			if err := commandPutJSON(ctx, b, &target, os.Stdin, key, *oOverwrite, *oDryRun, os.Stdout); err != nil {
+				return 0, tre.New(err, "put failed")
+			}
+		} else if len(*oValueTemplate) > 0 {
+			composed, err := renderValueTemplate(ctx, b, &target, *oValueTemplate)
+			if err != nil {
+				return 0, tre.New(err, "put failed", "key", key)
+			}
+			return 0, commandPutPasteGenerate(ctx, b, &target, "put", key, composed, doPrompt)
+		} else if len(value) != 0 {
+			return 0, commandPutPasteGenerate(ctx, b, &target, "put", key, value, doPrompt)
 		} else {
-			value = readFromStdIn()
-			commandPutPasteGenerate(ctx, b, &target, "put", key, value, doNotPrompt)
+			value, err := readFromStdIn()
+			if err != nil {
+				return 0, err
+			}
+			return 0, commandPutPasteGenerate(ctx, b, &target, "put", key, value, doNotPrompt)
+		}
+
+	case "create":
+		// kiya [profile] create [key] [|value]
+		key := flag.Arg(2)
+		value := flag.Arg(3)
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
+		}
+		if len(value) == 0 {
+			value, err = readFromStdIn()
+			if err != nil {
+				return 0, err
+			}
+		}
+		if err := commandCreate(ctx, b, &target, key, value); err != nil {
+			return 0, tre.New(err, "create failed", "key", key)
 		}
 
 	case "paste":
 		key := flag.Arg(2)
-		value, err := clipboard.ReadAll()
+		value, err := readClipboard()
 
 		if err != nil {
-			log.Fatal(tre.New(err, "clipboard read failed", "key", key))
+			return 0, tre.New(err, "clipboard read failed", "key", key)
 		}
 
-		if shouldPromptForPassword(b) {
-			pass := promptForPassword()
-			b.SetParameter("masterPassword", pass)
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
 		}
 
-		commandPutPasteGenerate(ctx, b, &target, "paste", key, value, doPrompt)
+		return 0, commandPutPasteGenerate(ctx, b, &target, "paste", key, value, doPrompt)
 
 	case "generate":
 		key := flag.Arg(2)
@@ -102,106 +224,326 @@ func main() {
 
 		var length string
 		var mustPrompt bool
+		secretRunes := target.SecretRunes
 		if len(value) != 0 {
 			length = value
 			mustPrompt = true
+		} else if term.IsTerminal(int(os.Stdin.Fd())) {
+			n, runes, err := promptForGenerate(os.Stdin, os.Stdout, target.SecretRunes)
+			if err != nil {
+				return 0, tre.New(err, "generate failed", "key", key)
+			}
+			length = strconv.Itoa(n)
+			secretRunes = runes
+			mustPrompt = true
 		} else {
-			length = readFromStdIn()
+			length, err = readFromStdIn()
+			if err != nil {
+				return 0, err
+			}
 			mustPrompt = false
 		}
 
 		secretLength, err := strconv.Atoi(length)
 		if err != nil {
-			log.Fatal(tre.New(err, "generate failed", "key", key, "err", err))
+			return 0, tre.New(err, "generate failed", "key", key, "err", err)
+		}
+		if *oUnambiguous {
+			before := kiya.SecretEntropy(secretLength, secretRunes)
+			secretRunes = kiya.UnambiguousRunes(secretRunes)
+			if len(secretRunes) == 0 {
+				return 0, errors.New("generate aborted, no characters left after excluding ambiguous ones")
+			}
+			fmt.Fprintf(os.Stderr, "Excluding ambiguous characters reduces the estimated entropy from %.0f bits\n", before)
+		}
+		entropy := kiya.SecretEntropy(secretLength, secretRunes)
+		fmt.Fprintf(os.Stderr, "Estimated entropy of generated secret: %.0f bits\n", entropy)
+		if entropy < *oMinEntropy {
+			if !*oForce {
+				return 0, fmt.Errorf("generate aborted, entropy of %.0f bits is below the minimum of %.0f bits, use a longer length or --force", entropy, *oMinEntropy)
+			}
+			log.Printf("[WARN] entropy of %.0f bits is below the minimum of %.0f bits", entropy, *oMinEntropy)
+		} else if entropy < recommendedEntropy {
+			log.Printf("[WARN] entropy of %.0f bits is below the recommended %d bits", entropy, recommendedEntropy)
 		}
-		secret, err := kiya.GenerateSecret(secretLength, target.SecretRunes)
+		secret, err := kiya.GenerateSecret(secretLength, secretRunes)
 		if err != nil {
-			log.Fatal(tre.New(err, "generate failed", "key", key, "err", err))
+			return 0, tre.New(err, "generate failed", "key", key, "err", err)
 		}
 
-		if shouldPromptForPassword(b) {
-			pass := promptForPassword()
-			b.SetParameter("masterPassword", pass)
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
 		}
 
-		commandPutPasteGenerate(ctx, b, &target, "generate", key, secret, mustPrompt)
+		if err := commandPutPasteGenerate(ctx, b, &target, "generate", key, secret, mustPrompt); err != nil {
+			return 0, err
+		}
 
 		// make it available on the clipboard, ignore error
-		err = clipboard.WriteAll(secret)
+		err = writeClipboard(secret)
 		if err != nil {
 			log.Printf("[WARN] cannot copy public key to clipboard, %s", err.Error())
 		}
 
 	case "copy":
-		key := flag.Arg(2)
+		// kiya [profile] copy [|key] [|key...]
+		// kiya [profile] copy --select [--first] [|filter]
+		keys, err := parseCopyArgs(flag.Args()[2:])
+		if err != nil {
+			return 0, tre.New(err, "copy failed")
+		}
+		if *oSelect {
+			if len(keys) > 1 {
+				return 0, errors.New("copy --select accepts at most one filter")
+			}
+			filter := ""
+			if len(keys) == 1 {
+				filter = keys[0]
+			}
+			key, err := selectMatchingKey(ctx, b, &target, filter, *oFirst)
+			if err != nil {
+				return 0, tre.New(err, "copy failed")
+			}
+			keys = []string{key}
+		}
+		if len(keys) == 0 {
+			key, err := keyOrSelect(ctx, b, &target, "")
+			if err != nil {
+				return 0, tre.New(err, "copy failed")
+			}
+			keys = []string{key}
+		}
 
-		if shouldPromptForPassword(b) {
-			pass := promptForPassword()
-			b.SetParameter("masterPassword", pass)
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
 		}
 
-		value, err := b.Get(ctx, &target, key)
+		value, err := composeValue(ctx, b, &target, keys, copyFormat{
+			Field:     *oField,
+			Template:  *oFormat,
+			Separator: *oJoin,
+		})
 		if err != nil {
-			log.Fatal(tre.New(err, "get failed", "key", key, "err", err))
+			return 0, deniedOr(err, "reading", strings.Join(keys, ", "), &target, tre.New(err, "copy failed", "keys", keys))
+		}
+		registerSecret(value)
+		if err := writeClipboard(value); err != nil {
+			return 0, tre.New(err, "copy failed", "keys", keys, "err", err)
 		}
-		if err := clipboard.WriteAll(string(value)); err != nil {
-			log.Fatal(tre.New(err, "copy failed", "key", key, "err", err))
+		if *oReveal && canReveal() {
+			if err := revealOnce(value); err != nil {
+				return 0, tre.New(err, "reveal failed", "keys", keys)
+			}
 		}
 
 	case "get":
-		key := flag.Arg(2)
+		if len(*oK8sSecret) > 0 {
+			if err := setMasterPassword(b); err != nil {
+				return 0, err
+			}
+			if err := commandK8sSecret(ctx, b, &target, *oK8sSecret, *oNamespace, flag.Args()[2:], os.Stdout); err != nil {
+				return 0, tre.New(err, "get failed")
+			}
+			return 0, nil
+		}
+		key, err := keyOrSelect(ctx, b, &target, flag.Arg(2))
+		if err != nil {
+			return 0, tre.New(err, "get failed")
+		}
 
-		if shouldPromptForPassword(b) {
-			pass := promptForPassword()
-			b.SetParameter("masterPassword", pass)
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
+		}
+
+		if len(*oMaxAge) > 0 {
+			maxAge, err := parseMaxAge(*oMaxAge)
+			if err != nil {
+				return 0, err
+			}
+			k, err := storedKey(ctx, b, &target, key)
+			if err != nil {
+				return 0, tre.New(err, "get failed", "key", key)
+			}
+			if err := checkFreshness(k, maxAge, time.Now()); err != nil {
+				return 0, err
+			}
 		}
 
+		usedDefault := false
 		bytes, err := b.Get(ctx, &target, key)
 		if err != nil {
-			log.Fatal(tre.New(err, "get failed", "key", key, "err", err))
+			if !errors.Is(err, backend.ErrKeyNotFound) || !isFlagPassed("default") {
+				return 0, deniedOr(err, "reading", key, &target, tre.New(err, "get failed", "key", key, "err", err))
+			}
+			bytes = []byte(*oDefault)
+			usedDefault = true
+		}
+		registerSecret(string(bytes))
+		defer backend.Zero(bytes)
+		if *oFailOnEmpty && isBlank(bytes) {
+			if usedDefault {
+				return 0, fmt.Errorf("get failed, [%s] does not exist in [%s] and the default value is empty", key, target.Label)
+			}
+			return 0, fmt.Errorf("get failed, [%s] exists in [%s] but its value is empty", key, target.Label)
 		}
 
 		if len(*oOutputFilename) > 0 {
-			if err := os.WriteFile(*oOutputFilename, bytes, os.ModePerm); err != nil {
-				log.Fatal(tre.New(err, "get failed", "key", key, "err", err))
+			if err := writeSecretFile(*oOutputFilename, bytes); err != nil {
+				return 0, tre.New(err, "get failed", "key", key, "err", err)
+			}
+			return 0, nil
+		}
+
+		if *oPretty && !*oRaw {
+			bytes = prettyJSON(bytes)
+		}
+		if *oReveal && canReveal() {
+			if err := revealOnce(string(bytes)); err != nil {
+				return 0, tre.New(err, "reveal failed", "key", key)
 			}
-			return
+			return 0, nil
+		}
+		if err := writeValue(os.Stdout, bytes, *oRaw); err != nil {
+			return 0, tre.New(err, "get failed", "key", key)
+		}
+
+	case "get-many":
+		// kiya [profile] get-many [key] [key...]
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
+		}
+		if err := commandGetMany(ctx, b, &target, flag.Args()[2:], concurrency, *oOutput, os.Stdout); err != nil {
+			return 0, tre.New(err, "get-many failed")
+		}
+
+	case "verify":
+		// kiya [profile] verify [key]
+		key := flag.Arg(2)
+		candidate, err := readCandidate(*oFromFile, os.Stdin)
+		if err != nil {
+			return 0, tre.New(err, "verify failed", "key", key, "from-file", *oFromFile)
+		}
+
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
 		}
 
-		fmt.Println(string(bytes))
+		match, err := commandVerify(ctx, b, &target, key, candidate)
+		backend.Zero(candidate)
+		if err != nil {
+			return 0, tre.New(err, "verify failed", "key", key)
+		}
+		if !match {
+			fmt.Println("mismatch")
+			return 1, nil
+		}
+		fmt.Println("match")
+
+	case "who-can":
+		// kiya [profile] who-can [key]
+		return 0, commandWhoCan(ctx, b, &target, flag.Arg(2), os.Stdout)
+
+	case "versions":
+		// kiya [profile] versions [key] [|version]
+		key := flag.Arg(2)
+		if err := commandVersions(ctx, b, &target, key, flag.Arg(3), os.Stdout); err != nil {
+			if errors.Is(err, errVersioningNotSupported) {
+				return 0, err
+			}
+			return 0, deniedOr(err, "reading", key, &target, tre.New(err, "versions failed", "key", key))
+		}
 
 	case "delete":
 		key := flag.Arg(2)
-		commandDelete(ctx, b, &target, key)
+		return 0, commandDelete(ctx, b, &target, key)
+	case "env":
+		// kiya [profile] env [|filter-term]
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
+		}
+		return 0, commandEnv(ctx, b, &target, flag.Arg(2), *oExport, concurrency, os.Stdout)
+	case "run":
+		// kiya [profile] run [|filter-term] -- [command] [|args]
+		filter, command, err := parseRunArgs(flag.Args()[2:])
+		if err != nil {
+			return 0, err
+		}
+		restartSignal, err := parseSignal(*oRestartSignal)
+		if err != nil {
+			return 0, err
+		}
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
+		}
+		code, err := commandRun(ctx, b, &target, filter, command, *oWatchInterval, restartSignal, concurrency)
+		if err != nil {
+			return 0, deniedOr(err, "reading", "", &target, tre.New(err, "run failed", "command", command[0]))
+		}
+		return code, nil
+	case "export":
+		// kiya [profile] export [|filter-term]
+		// kiya -include-values -export-format env|json|yaml [-o filename] [profile] export [|filter-term]
+		format := *oExportFormat
+		if *oIncludeValues {
+			if err := setMasterPassword(b); err != nil {
+				return 0, err
+			}
+		}
+		// values written to a file must get the permission of -file-mode
+		var out io.Writer = os.Stdout
+		buf := new(bytes.Buffer)
+		if len(*oOutputFilename) > 0 {
+			out = buf
+		}
+		if err := commandExport(ctx, b, &target, flag.Arg(2), format, *oIncludeValues, concurrency, out); err != nil {
+			return 0, deniedOr(err, "reading", "", &target, tre.New(err, "export failed"))
+		}
+		if len(*oOutputFilename) > 0 {
+			defer backend.Zero(buf.Bytes())
+			if err := writeSecretFile(*oOutputFilename, buf.Bytes()); err != nil {
+				return 0, tre.New(err, "export failed", "output", *oOutputFilename)
+			}
+		}
 	case "list":
 		// kiya [profile] list [|filter-term]
 		filter := flag.Arg(2)
 
-		keys := commandList(ctx, b, &target, filter)
-		writeTable(keys, &target, filter)
+		keys, err := commandList(ctx, b, &target, filter)
+		if err != nil {
+			return 0, err
+		}
+		return 0, writeTable(keys, &target, filter, *oOutput)
 	case "template":
-		commandTemplate(ctx, b, &target, *oOutputFilename)
+		return 0, commandTemplate(ctx, b, &target, flag.Arg(2), *oOutputFilename, oVars)
+	case "render":
+		// kiya [profile] render [manifest-filename]
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
+		}
+		return 0, commandRender(ctx, &target, flag.Arg(2), oVars)
 	case "move":
 		// kiya [source] move [source-key] [target] [|target-key]
 		sourceProfile := kiya.Profiles[flag.Arg(0)]
 		sourceKey := flag.Arg(2)
-		targetProfile := kiya.Profiles[flag.Arg(3)]
+		targetProfile, ok := kiya.Profiles[flag.Arg(3)]
+		if !ok {
+			return 0, fmt.Errorf("no such profile [%s] to move to please check your .kiya file", flag.Arg(3))
+		}
 		targetKey := sourceKey
 		if len(flag.Args()) == 5 {
 			targetKey = flag.Arg(4)
 		}
 
-		if shouldPromptForPassword(b) {
-			pass := promptForPassword()
-			b.SetParameter("masterPassword", pass)
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
 		}
-		commandMove(ctx, b, &sourceProfile, sourceKey, &targetProfile, targetKey)
+		return 0, commandMove(ctx, b, &sourceProfile, sourceKey, &targetProfile, targetKey)
 
 	case "backup":
 		filter := flag.Arg(2)
 
 		if *oBackupPath == "" {
-			log.Fatalln("--path not specified")
+			return 0, errors.New("--path not specified")
 		}
 
 		fmt.Printf("Backup profile '%s', filter: '%s' to %s\n", profileName, filter, *oBackupPath)
@@ -209,58 +551,56 @@ func main() {
 			fmt.Printf("Backup will be encrypted. Public key path: '%s', public key location: '%s'\n", *oBackupKey, *oBackupKeyStore)
 		}
 
-		if shouldPromptForPassword(b) {
-			pass := promptForPassword()
-			b.SetParameter("masterPassword", pass)
-		}
-
-		backup, err := commandBackup(ctx, b, target, filter)
-		if err != nil {
-			log.Fatalln(err.Error())
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
 		}
 
-		file, err := os.Create(*oBackupPath)
+		backup, err := commandBackup(ctx, b, target, filter, append(target.BackupExcludes, oBackupExcludes...), concurrency)
 		if err != nil {
-			log.Fatalf("create file '%s' failed, %s", *oBackupPath, err.Error())
+			return 0, err
 		}
 
 		if *oEncryptBackup {
 			pub, err := getPublicKey(ctx, b, target, *oBackupKeyStore, *oBackupKey)
 			if err != nil {
-				log.Fatalf("[FATAL] get public key failed, %s", err.Error())
+				return 0, fmt.Errorf("get public key failed, %s", err.Error())
 			}
 
 			backup.Secret = generateSecret()
 
-			buf, err := encrypt(backup.Data, backup.SecretAsBytes())
+			secret, err := backup.SecretAsBytes()
 			if err != nil {
-				log.Fatalf("[FATAL] encrypt items failed, %s", err.Error())
+				return 0, err
+			}
+			buf, err := encrypt(backup.Data, secret)
+			if err != nil {
+				return 0, fmt.Errorf("encrypt items failed, %s", err.Error())
 			}
 
 			backup.Data = buf
 			encryptedSecret, err := encryptSecret(backup.Secret, pub)
 			if err != nil {
-				log.Fatalf("[FATAL] encrypt secret failed, %s", err.Error())
+				return 0, fmt.Errorf("encrypt secret failed, %s", err.Error())
 			}
 			backup.Encrypted = true
 			backup.Secret = encryptedSecret
 		}
 
-		_, err = file.Write([]byte(backup.String()))
-
-		if err != nil {
-			log.Fatalf("save file '%s' failed, %s", *oBackupPath, err.Error())
+		if err := writeSecretFile(*oBackupPath, []byte(backup.String())); err != nil {
+			return 0, fmt.Errorf("save file '%s' failed, %s", *oBackupPath, err.Error())
 		}
 	case "restore":
 		fmt.Printf("Restore profile '%s' from %s\n", profileName, *oBackupPath)
 
 		buf, err := os.ReadFile(*oBackupPath)
 		if err != nil {
-			log.Fatalf("read '%s' failed, %s", *oBackupPath, err.Error())
+			return 0, fmt.Errorf("read '%s' failed, %s", *oBackupPath, err.Error())
 		}
 
 		backup := Backup{}
-		backup.FromString(string(buf))
+		if err := backup.FromString(string(buf)); err != nil {
+			return 0, err
+		}
 		var items map[string][]byte
 
 		fmt.Printf("Backend '%s', restoring keys...\n", target.Backend)
@@ -270,47 +610,186 @@ func main() {
 
 			buf, err := os.ReadFile(*oBackupKey)
 			if err != nil {
-				log.Fatalf("[FATAL] read private key '%s' failed, %s", *oBackupKey, err.Error())
+				return 0, fmt.Errorf("read private key '%s' failed, %s", *oBackupKey, err.Error())
 			}
 
 			privKey := exportPrivateKeyFromPEMString(buf)
 			if err != nil {
-				log.Fatalf("[FATAL] export private key '%s' failed, %s", *oBackupKey, err.Error())
+				return 0, fmt.Errorf("export private key '%s' failed, %s", *oBackupKey, err.Error())
 			}
 
 			secret, err := decryptSecret(backup.Secret, privKey)
 			if err != nil {
-				log.Fatalf("[FATAL] cannot decrypt secret, %s", err.Error())
+				return 0, fmt.Errorf("cannot decrypt secret, %s", err.Error())
 			}
 
 			buf, err = decrypt(backup.Data, secret)
 			if err != nil {
-				log.Fatalf("[FATAL] decrypt items failed, %s", err.Error())
+				return 0, fmt.Errorf("decrypt items failed, %s", err.Error())
 			}
 
 			fmt.Println("Backup decrypted, decode from JSON")
-			items = decodeJson[map[string][]byte](buf)
+			items, err = decodeJson[map[string][]byte](buf)
 		} else {
-			items = decodeJson[map[string][]byte](backup.Data)
+			items, err = decodeJson[map[string][]byte](backup.Data)
+		}
+		if err != nil {
+			return 0, err
 		}
 
 		fmt.Printf("\rBackend '%s', restoring %d key(s)\n", target.Backend, len(items))
 
 		if items == nil {
-			log.Fatalln("no items found")
+			return 0, errors.New("no items found")
+		}
+
+		onConflict := *oRestoreOnConflict
+		if !isFlagPassed("on-conflict") && *oBackupRestoreOverwrite {
+			onConflict = conflictOverwrite
+		}
+		if !isValidConflictStrategy(onConflict) {
+			return 0, fmt.Errorf("invalid conflict strategy [%s], use skip, overwrite, rename or fail", onConflict)
+		}
+		return 0, restoreItems(ctx, b, &target, items, onConflict, *oForce, concurrency)
+
+	case "exists":
+		// kiya [profile] exists [key]
+		if len(flag.Arg(2)) == 0 {
+			return 0, errors.New("missing key, use kiya [profile] exists [key]")
+		}
+		code, err := commandExists(ctx, b, &target, flag.Arg(2), *oVerbose, os.Stdout)
+		if err != nil {
+			if denied := deniedOr(err, "reading", flag.Arg(2), &target, nil); denied != nil {
+				return 0, denied
+			}
+			// distinct from the exit code of an absent key
+			log.Print(tre.New(err, "exists failed", "key", flag.Arg(2)))
+			return 2, nil
+		}
+		return code, nil
+
+	case "rename":
+		// kiya [profile] rename [old-key] [new-key]
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
+		}
+		if err := commandRename(ctx, b, &target, flag.Arg(2), flag.Arg(3)); err != nil {
+			return 0, tre.New(err, "rename failed", "key", flag.Arg(2))
+		}
+
+	case "touch":
+		// kiya [profile] touch [key]
+		key := flag.Arg(2)
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
+		}
+		return 0, commandTouch(ctx, b, &target, key)
+
+	case "replace-in":
+		// kiya [profile] replace-in [key] [old] [new]
+		key := flag.Arg(2)
+		if len(flag.Args()) != 5 {
+			return 0, errors.New("expected key, text to replace and replacement, use kiya [profile] replace-in [key] [old] [new]")
+		}
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
+		}
+		if err := commandReplaceIn(ctx, b, &target, key, flag.Arg(3), flag.Arg(4), *oCount, *oRegex, *oAllowNoMatch); err != nil {
+			return 0, tre.New(err, "replace-in failed", "key", key)
+		}
+
+	case "export-raw":
+		// kiya [file-profile] export-raw
+		if err := commandExportRaw(b, &target, os.Stdout); err != nil {
+			return 0, tre.New(err, "export-raw failed")
+		}
+
+	case "import-raw":
+		// kiya [file-profile] import-raw < exported
+		if err := commandImportRaw(b, &target, os.Stdin, *oOverwrite); err != nil {
+			return 0, tre.New(err, "import-raw failed")
+		}
+		fmt.Printf("Successfully imported the store of [%s]\n", target.Label)
+
+	case "import":
+		// kiya [profile] import [|file]
+		// kiya -import-format env|json [profile] import [|file]
+		filename := flag.Arg(2)
+		format, err := importFormat(*oImportFormat, filename)
+		if err != nil {
+			return 0, err
+		}
+		input := io.Reader(os.Stdin)
+		if len(filename) > 0 && filename != "-" {
+			f, err := os.Open(filename)
+			if err != nil {
+				return 0, tre.New(err, "import failed", "file", filename)
+			}
+			defer f.Close()
+			input = f
+		}
+		pairs, err := parseImport(input, format)
+		if err != nil {
+			return 0, tre.New(err, "import failed", "format", format)
+		}
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
+		}
+		summary, err := commandImport(ctx, b, &target, pairs, *oOverwrite, os.Stderr)
+		if err != nil {
+			return 0, deniedOr(err, "listing", "", &target, tre.New(err, "import failed"))
+		}
+		fmt.Printf("Imported into [%s]: %s\n", target.Label, summary)
+		if summary.Failed > 0 {
+			return 1, nil
 		}
 
-		for k, v := range items {
-			err := b.Put(ctx, &target, k, string(v), *oBackupRestoreOverwrite)
+	case "prune":
+		// kiya [profile] prune [-yes] [|filter-term]
+		filter, yes, err := parsePruneArgs(flag.Args()[2:])
+		if err != nil {
+			return 0, tre.New(err, "prune failed")
+		}
+		var olderThan time.Duration
+		if len(*oOlderThan) > 0 {
+			olderThan, err = parseMaxAge(*oOlderThan)
 			if err != nil {
-				log.Printf("[ERROR] put key '%s' failed - %s", k, err.Error())
+				return 0, err
 			}
 		}
+		if err := commandPrune(ctx, b, &target, filter, olderThan, *oExpired, oProtect, *oDryRun, yes); err != nil {
+			return 0, tre.New(err, "prune failed")
+		}
+
+	case "fsck":
+		// kiya [profile] fsck
+		if err := setMasterPassword(b); err != nil {
+			return 0, err
+		}
+		ok, err := commandFsck(ctx, b, &target, concurrency)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			return 1, nil
+		}
+
+	case "recover":
+		// kiya [profile] recover
+		fs, ok := backend.Unwrap(b).(*backend.FileStore)
+		if !ok {
+			return 0, fmt.Errorf("recover is only supported for the file backend, profile [%s] uses [%s]", profileName, target.Backend)
+		}
+		result, err := fs.Recover()
+		if err != nil {
+			return 0, tre.New(err, "recover failed", "profile", profileName)
+		}
+		fmt.Printf("Recovered [%s]: %s\n", profileName, result)
 
 	case "keygen":
 		priv, pub, err := generateKeyPair()
 		if err != nil {
-			log.Fatal(err)
+			return 0, err
 		}
 
 		path := flag.Arg(2)
@@ -323,28 +802,104 @@ func main() {
 
 		err = saveKeyToFile(pubKeyStr, fmt.Sprintf("%s_pub", path))
 		if err != nil {
-			log.Fatal(err)
+			return 0, err
 		}
 
 		err = saveKeyToFile(privKeyStr, path)
 		if err != nil {
-			log.Fatal(err)
+			return 0, err
 		}
 
 		fmt.Printf("Key '%s', '%s_pub' saved\n", path, path)
-		if err := clipboard.WriteAll(pubKeyStr); err != nil {
-			log.Fatal(tre.New(err, "copy failed", err))
+		if err := writeClipboard(pubKeyStr); err != nil {
+			return 0, tre.New(err, "copy failed", err)
 		}
 		fmt.Println("Public key copied to clipboard")
 
 	default:
-		keys := commandList(ctx, b, &target, flag.Arg(1))
-		writeTable(keys, &target, flag.Arg(1))
+		keys, err := commandList(ctx, b, &target, flag.Arg(1))
+		if err != nil {
+			return 0, err
+		}
+		return 0, writeTable(keys, &target, flag.Arg(1), *oOutput)
+	}
+	return 0, nil
+}
+
+// exitCodeAfterClose returns the exit code of the process given the exit code of the command and the result of closing its backends.
+// The outcome of the command comes first: a close failure is then only reported as a warning.
+func exitCodeAfterClose(code int, closeErr error) int {
+	if closeErr == nil {
+		return code
+	}
+	if code != 0 {
+		log.Printf("[WARN] failed to close the secret provider backend, %s", closeErr.Error())
+		return code
+	}
+	log.Printf("failed to close the secret provider backend, %s", closeErr.Error())
+	return 1
+}
+
+// decorateBackend wraps a backend with the decorators configured by the profile and flags.
+func decorateBackend(b backend.Backend, p *backend.Profile) (backend.Backend, error) {
+	encoding, err := backend.NewValueEncodingBackend(b, *oEncode)
+	if err != nil {
+		return nil, err
+	}
+	b = backend.NewKeyEncodingBackend(encoding, p.KeySeparator)
+	// each retry is also rate limited
+	b = backend.NewRateLimitedBackend(b, p.RateLimit)
+	retrying, err := backend.NewRetryingBackend(b, retryPolicy(p))
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry settings for profile [%s]: %w", p.Label, err)
+	}
+	// also for commands that do not validate the values they store, such as migrate
+	b = backend.NewPolicyBackend(retrying, p.Policy)
+	// every profile used by a command reports its events, also the target of a move or migrate
+	if len(*oEmitEvents) > 0 {
+		events, err := eventWriters.get(*oEmitEvents, openEventsWriter)
+		if err != nil {
+			return nil, tre.New(err, "cannot open events output", "emit-events", *oEmitEvents)
+		}
+		b = backend.NewEventsBackend(b, events, currentActor())
+	}
+	if len(p.AuditSyslog) > 0 {
+		events, err := eventWriters.get(p.AuditSyslog, openSyslogWriter)
+		if err != nil {
+			log.Printf("[WARN] events of [%s] are not written to syslog, %s", p.Label, err.Error())
+		} else {
+			b = backend.NewEventsBackend(b, events, currentActor())
+		}
 	}
+	return b, nil
+}
+
+// retryPolicy returns the retry settings of the profile, overridden by those given as flags.
+func retryPolicy(p *backend.Profile) backend.Retry {
+	var policy backend.Retry
+	if p.Retry != nil {
+		policy = *p.Retry
+	}
+	if *oMaxAttempts > 0 {
+		policy.MaxAttempts = *oMaxAttempts
+	}
+	if len(*oRetryBaseDelay) > 0 {
+		policy.BaseDelay = *oRetryBaseDelay
+	}
+	if len(*oRetryMaxDelay) > 0 {
+		policy.MaxDelay = *oRetryMaxDelay
+	}
+	if *oRetryJitter >= 0 {
+		policy.Jitter = *oRetryJitter
+	}
+	return policy
 }
 
 // getBackend returns a backend based on the profile
 func getBackend(ctx context.Context, p *backend.Profile) (backend.Backend, error) {
+	if p.TLSSkipVerify {
+		log.Printf("[WARN] TLS certificate verification is disabled for profile [%s], connections can be intercepted", p.Label)
+	}
 	switch p.Backend {
 	case "ssm":
 		return backend.NewAWSParameterStore(ctx, p)
@@ -352,34 +907,59 @@ func getBackend(ctx context.Context, p *backend.Profile) (backend.Backend, error
 		// Create GSM client
 		gsmClient, err := secretmanager.NewClient(ctx)
 		if err != nil {
-			log.Fatalf("failed to setup client: %v", err)
+			return nil, fmt.Errorf("failed to setup client: %w", err)
 		}
 
 		return backend.NewGSM(gsmClient), nil
 	case "akv":
 		cred, err := azidentity.NewDefaultAzureCredential(nil)
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
+		}
+		httpClient, err := backend.NewHTTPClient(p)
+		if err != nil {
+			return nil, err
+		}
+		var options *azsecrets.ClientOptions
+		if httpClient != nil {
+			options = &azsecrets.ClientOptions{ClientOptions: azcore.ClientOptions{Transport: httpClient}}
 		}
-		client, err := azsecrets.NewClient(p.VaultUrl, cred, nil)
+		client, err := azsecrets.NewClient(p.VaultUrl, cred, options)
 		if err != nil {
-			log.Fatalf("failed to create client [%v]", err)
+			return nil, fmt.Errorf("failed to create client [%w]", err)
 		}
 		return backend.NewAKV(client), nil
 	case "file":
-		return backend.NewFileStore(p.Location, p.ProjectID), nil
+		store := backend.NewFileStoreForProfile(p.Location, p.ProjectID, p.Label)
+		if preferred := store.PreferredLocation(); len(preferred) > 0 {
+			log.Printf("[WARN] using legacy file store %s, move it to %s or set location in profile [%s]", store.Location(), preferred, p.Label)
+		}
+		if err := store.SetKDF(p.FileStoreKDF); err != nil {
+			return nil, err
+		}
+		return store, nil
+	case "etcd":
+		return backend.NewEtcdStore(p)
+	case "consul":
+		return backend.NewConsulStore(p)
+	case "k8s":
+		return backend.NewK8sStore(p)
+	case "keychain":
+		return backend.NewKeychainStore(p)
+	case "awskms":
+		return backend.NewAWSKMSStore(ctx, p)
 	case "kms":
 		fallthrough
 	default:
 		// Create the KMS client
 		kmsService, err := cloudkms.NewService(ctx, option.WithHTTPClient(kiya.NewAuthenticatedClient(*oAuthLocation)))
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 		// Create the Bucket client
 		storageService, err := cloudstore.NewClient(ctx)
 		if err != nil {
-			log.Fatalf("failed to create client [%v]", err)
+			return nil, fmt.Errorf("failed to create client [%w]", err)
 		}
 
 		return backend.NewKMS(kmsService, storageService), nil
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+func TestExitCodeAfterClose(t *testing.T) {
+	closeErr := errors.New("close failed")
+	for _, each := range []struct {
+		code     int
+		closeErr error
+		want     int
+	}{
+		{0, nil, 0},
+		{2, nil, 2},
+		{0, closeErr, 1},
+		{3, closeErr, 3},
+	} {
+		if got, want := exitCodeAfterClose(each.code, each.closeErr), each.want; got != want {
+			t.Errorf("exitCodeAfterClose(%d, %v) got [%d] want [%d]", each.code, each.closeErr, got, want)
+		}
+	}
+}
+
+func TestRetryPolicyFlagsOverrideProfile(t *testing.T) {
+	defer func() { *oMaxAttempts, *oRetryMaxDelay = 0, "" }()
+	p := &backend.Profile{Retry: &backend.Retry{MaxAttempts: 2, BaseDelay: "100ms", MaxDelay: "1s"}}
+	*oMaxAttempts, *oRetryMaxDelay = 5, "3s"
+	policy := retryPolicy(p)
+	if policy.MaxAttempts != 5 || policy.BaseDelay != "100ms" || policy.MaxDelay != "3s" {
+		t.Errorf("unexpected policy %+v", policy)
+	}
+	if p.Retry.MaxAttempts != 2 {
+		t.Error("profile was changed")
+	}
+}
+
+func TestDecorateBackendEmitsEventsForEveryProfile(t *testing.T) {
+	ctx := context.Background()
+	eventsFile := filepath.Join(t.TempDir(), "events")
+	defer func() { *oEmitEvents = "" }()
+	*oEmitEvents = eventsFile
+	for _, label := range []string{"dev", "prod"} {
+		store, _ := newTestStore(t)
+		p := &backend.Profile{Label: label}
+		b, err := decorateBackend(store, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Put(ctx, p, "a", "value", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := eventWriters.closeAll(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(eventsFile)
+	for _, each := range []string{`"profile":"dev"`, `"profile":"prod"`} {
+		if !strings.Contains(string(data), each) {
+			t.Errorf("missing %s in %s", each, data)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// startMetricsServer serves the metrics of the backend on /metrics in the background.
+func startMetricsServer(addr string, metrics *backend.MetricsBackend) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		metrics.WriteMetrics(w)
+	})
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("[ERROR] metrics server on %s failed, %s", addr, err.Error())
+		}
+	}()
+}
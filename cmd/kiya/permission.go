@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// exitPermissionDenied is the exit code if the credentials of a profile do not allow an operation (EX_NOPERM of sysexits.h).
+const exitPermissionDenied = 77
+
+// deniedOr returns an error with an actionable message if err is caused by missing permissions,
+// otherwise it returns other. Commands return it such that run exits with exitPermissionDenied.
+func deniedOr(err error, operation, key string, target *backend.Profile, other error) error {
+	if !errors.Is(err, backend.ErrPermissionDenied) {
+		return other
+	}
+	return permissionDeniedError{message: permissionDeniedMessage(operation, key, target)}
+}
+
+// permissionDeniedError is an ErrPermissionDenied with a message that tells what was denied.
+type permissionDeniedError struct {
+	message string
+}
+
+func (e permissionDeniedError) Error() string { return e.message }
+
+func (e permissionDeniedError) Unwrap() error { return backend.ErrPermissionDenied }
+
+// commandExitCode returns the exit code of the process for the exit code and error of a command, printing the error.
+// Missing permissions exit with exitPermissionDenied, other errors with 1.
+func commandExitCode(code int, err error) int {
+	if err == nil {
+		return code
+	}
+	log.Print(err)
+	if errors.Is(err, backend.ErrPermissionDenied) {
+		return exitPermissionDenied
+	}
+	return 1
+}
+
+// permissionDeniedMessage returns the message for an operation, e.g. reading, on a key or, if empty, on the profile.
+func permissionDeniedMessage(operation, key string, target *backend.Profile) string {
+	if len(key) == 0 {
+		return fmt.Sprintf("permission denied %s profile [%s]; check your credentials", operation, target.Label)
+	}
+	return fmt.Sprintf("permission denied %s [%s] on profile [%s]; check your credentials", operation, key, target.Label)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+func TestPermissionDeniedMessage(t *testing.T) {
+	target := &backend.Profile{Label: "prod"}
+	if got, want := permissionDeniedMessage("reading", "db/password", target), "permission denied reading [db/password] on profile [prod]; check your credentials"; got != want {
+		t.Errorf("got [%s] want [%s]", got, want)
+	}
+	if got, want := permissionDeniedMessage("listing", "", target), "permission denied listing profile [prod]; check your credentials"; got != want {
+		t.Errorf("got [%s] want [%s]", got, want)
+	}
+}
+
+func TestCommandExitCode(t *testing.T) {
+	target := &backend.Profile{Label: "prod"}
+	denied := fmt.Errorf("get failed, %w", backend.ErrPermissionDenied)
+	for _, each := range []struct {
+		code int
+		err  error
+		want int
+	}{
+		{0, nil, 0},
+		{2, nil, 2},
+		{0, errors.New("get failed"), 1},
+		{0, deniedOr(denied, "reading", "db", target, denied), exitPermissionDenied},
+		{0, deniedOr(errors.New("other"), "reading", "db", target, errors.New("other")), 1},
+	} {
+		if got := commandExitCode(each.code, each.err); got != each.want {
+			t.Errorf("commandExitCode(%d, %v) got %d want %d", each.code, each.err, got, each.want)
+		}
+	}
+	if got := deniedOr(denied, "reading", "db", target, nil).Error(); got != permissionDeniedMessage("reading", "db", target) {
+		t.Errorf("got [%s]", got)
+	}
+}
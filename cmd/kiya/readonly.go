@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// mutatingCommands are the commands that change secrets and are refused in read-only mode.
+var mutatingCommands = map[string]bool{
+	"put":        true,
+	"create":     true,
+	"paste":      true,
+	"generate":   true,
+	"delete":     true,
+	"move":       true,
+	"restore":    true,
+	"import":     true,
+	"import-raw": true,
+	"prune":      true,
+	"rename":     true,
+	"replace-in": true,
+	"touch":      true,
+	"recover":    true,
+	"keygen":     true,
+	"migrate":    true,
+}
+
+// isReadOnly returns true if the --read-only flag or the KIYA_READ_ONLY environment variable is set.
+func isReadOnly() bool {
+	if *oReadOnly {
+		return true
+	}
+	readOnly, _ := strconv.ParseBool(os.Getenv("KIYA_READ_ONLY"))
+	return readOnly
+}
+
+// guardReadOnly stops the program if the command would change secrets in read-only mode.
+func guardReadOnly(command string) error {
+	if mutatingCommands[command] && isReadOnly() {
+		return fmt.Errorf("%s refused, kiya runs in read-only mode (--read-only or KIYA_READ_ONLY)", command)
+	}
+	return nil
+}
+
+// isDryRun returns true if the arguments contain the -dry-run flag, which changes nothing.
+func isDryRun(args []string) bool {
+	for _, each := range args {
+		switch strings.TrimPrefix(each, "-") {
+		case "-dry-run", "-dry-run=true", "dry-run", "dry-run=true":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+package main
+
+import "testing"
+
+func TestIsReadOnlyFromEnvironment(t *testing.T) {
+	t.Setenv("KIYA_READ_ONLY", "true")
+	if !isReadOnly() {
+		t.Error("expected read-only mode")
+	}
+	t.Setenv("KIYA_READ_ONLY", "")
+	if isReadOnly() {
+		t.Error("expected no read-only mode")
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// canReveal returns true if both stdin and stdout are a terminal.
+func canReveal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// revealOnce prints the value, waits for a keypress and then clears the printed lines and, where supported, the scrollback.
+func revealOnce(value string) error {
+	fd := int(os.Stdin.Fd())
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 {
+		width = 80
+	}
+	const message = "Press any key to hide the secret"
+	fmt.Println(value)
+	fmt.Print(message)
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 1)
+	_, readErr := os.Stdin.Read(key)
+	term.Restore(fd, state)
+
+	// the cursor is on the message line, move up over all (wrapped) lines of the value
+	rows := 0
+	for _, each := range strings.Split(value, "\n") {
+		rows += 1 + (utf8.RuneCountInString(each)-1)/width
+	}
+	if rows > 0 {
+		fmt.Printf("\x1b[%dA", rows)
+	}
+	// clear to end of screen and clear the scrollback
+	fmt.Print("\r\x1b[J\x1b[3J")
+	return readErr
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// minScrubLength is the minimum length of a secret value that is scrubbed; shorter values would redact ordinary words.
+const minScrubLength = 4
+
+const redacted = "[REDACTED]"
+
+// knownSecrets holds a keyed hash of each secret value handled by this invocation, by the length of the value.
+// Values are not kept such that they can be wiped from memory, e.g. by backend.Zero, after use.
+var knownSecrets = struct {
+	sync.Mutex
+	key     []byte
+	hashes  map[int]map[[sha256.Size]byte]bool
+	lengths []int // longest first
+}{}
+
+// registerSecret makes the value be redacted from all error output.
+func registerSecret(value string) {
+	if len(value) < minScrubLength {
+		return
+	}
+	knownSecrets.Lock()
+	defer knownSecrets.Unlock()
+	if knownSecrets.key == nil {
+		knownSecrets.key = make([]byte, sha256.Size)
+		rand.Read(knownSecrets.key)
+		knownSecrets.hashes = map[int]map[[sha256.Size]byte]bool{}
+	}
+	set, ok := knownSecrets.hashes[len(value)]
+	if !ok {
+		set = map[[sha256.Size]byte]bool{}
+		knownSecrets.hashes[len(value)] = set
+		knownSecrets.lengths = append(knownSecrets.lengths, len(value))
+		sort.Sort(sort.Reverse(sort.IntSlice(knownSecrets.lengths)))
+	}
+	set[secretHash(knownSecrets.key, value)] = true
+}
+
+// secretHash returns the HMAC-SHA256 of the value using the key of this invocation.
+func secretHash(key []byte, value string) (sum [sha256.Size]byte) {
+	mac := hmac.New(sha256.New, key)
+	io.WriteString(mac, value)
+	copy(sum[:], mac.Sum(nil))
+	return
+}
+
+// scrub returns the message with all known secret values redacted.
+// At each position, the longest known secret that matches is replaced.
+func scrub(message string) string {
+	knownSecrets.Lock()
+	defer knownSecrets.Unlock()
+	if len(knownSecrets.lengths) == 0 {
+		return message
+	}
+	var out strings.Builder
+	for i := 0; i < len(message); {
+		matched := 0
+		for _, length := range knownSecrets.lengths {
+			if i+length <= len(message) && knownSecrets.hashes[length][secretHash(knownSecrets.key, message[i:i+length])] {
+				matched = length
+				break
+			}
+		}
+		if matched > 0 {
+			out.WriteString(redacted)
+			i += matched
+			continue
+		}
+		out.WriteByte(message[i])
+		i++
+	}
+	return out.String()
+}
+
+// scrubWriter redacts known secret values from everything written to it.
+type scrubWriter struct {
+	out io.Writer
+}
+
+func (s scrubWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(s.out, scrub(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
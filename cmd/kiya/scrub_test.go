@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestScrubWriter(t *testing.T) {
+	// other tests register secrets too
+	knownSecrets.hashes, knownSecrets.lengths = map[int]map[[sha256.Size]byte]bool{}, nil
+	registerSecret("mySecretPassword")
+	registerSecret("abc") // too short to scrub
+	var buf bytes.Buffer
+	scrubWriter{&buf}.Write([]byte("put failed: invalid value mySecretPassword for abc"))
+	if got, want := buf.String(), "put failed: invalid value [REDACTED] for abc"; got != want {
+		t.Errorf("got [%s] want [%s]", got, want)
+	}
+}
+
+func TestScrubPrefersLongestSecret(t *testing.T) {
+	registerSecret("password")
+	registerSecret("password123")
+	if got, want := scrub("a password123 and a password"), "a [REDACTED] and a [REDACTED]"; got != want {
+		t.Errorf("got [%s] want [%s]", got, want)
+	}
+}
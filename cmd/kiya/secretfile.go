@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// defaultFileMode is the permission of files with secrets unless overridden by -file-mode.
+const defaultFileMode os.FileMode = 0600
+
+// parseFileMode returns the permission of an octal mode such as 0600; it must not be empty.
+func parseFileMode(mode string) (os.FileMode, error) {
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || perm == 0 || perm > 0777 {
+		return 0, fmt.Errorf("invalid file mode [%s], use an octal permission such as 0600", mode)
+	}
+	return os.FileMode(perm), nil
+}
+
+// writeSecretFile writes data to a file that holds secrets, using the permission of -file-mode.
+// A new file gets this permission restricted by the umask of the process; an existing file that
+// allows more than this permission is restricted to it, it is never made more permissive.
+func writeSecretFile(path string, data []byte) error {
+	perm, err := parseFileMode(*oFileMode)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if info, err := file.Stat(); err == nil && info.Mode().Perm()&^perm != 0 {
+		if err := file.Chmod(info.Mode().Perm() & perm); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseFileMode(t *testing.T) {
+	if perm, err := parseFileMode("0640"); err != nil || perm != 0640 {
+		t.Errorf("got %v %v", perm, err)
+	}
+	for _, each := range []string{"", "rw", "0", "1777", "0800"} {
+		if _, err := parseFileMode(each); err == nil {
+			t.Errorf("expected error for [%s]", each)
+		}
+	}
+}
+
+func TestWriteSecretFileRestrictsExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSecretFile(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != 0600 {
+		t.Errorf("got permission %o want 600", got)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("got %s", data)
+	}
+}
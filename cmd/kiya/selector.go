@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kramphub/kiya/backend"
+	"golang.org/x/term"
+)
+
+// maxSelectorRows is the maximum number of matching keys shown by the selector.
+const maxSelectorRows = 10
+
+var errSelectionAborted = errors.New("selection aborted")
+
+// keyOrSelect returns the key if not empty, otherwise it lets the user pick one from the profile interactively.
+func keyOrSelect(ctx context.Context, b backend.Backend, target *backend.Profile, key string) (string, error) {
+	if len(key) > 0 {
+		return key, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", errors.New("missing key, please provide an explicit key")
+	}
+	keys, err := commandList(ctx, b, target, "")
+	if err != nil {
+		return "", err
+	}
+	if len(keys) == 0 {
+		return "", fmt.Errorf("no keys found in [%s]", target.Label)
+	}
+	return selectKey(keys)
+}
+
+// selectMatchingKey returns the key matching the filter. If several keys match, the user picks one on a terminal
+// unless first is set; otherwise it is an error.
+func selectMatchingKey(ctx context.Context, b backend.Backend, target *backend.Profile, filter string, first bool) (string, error) {
+	keys, err := commandList(ctx, b, target, filter)
+	if err != nil {
+		return "", err
+	}
+	if len(keys) == 0 {
+		return "", fmt.Errorf("no keys matching [%s] found in [%s]", filter, target.Label)
+	}
+	if len(keys) == 1 || first || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return singleMatch(keys, filter)
+	}
+	return selectKey(keys)
+}
+
+// singleMatch returns the name of the only key or an error listing all keys if the filter is ambiguous.
+func singleMatch(keys []backend.Key, filter string) (string, error) {
+	if len(keys) == 1 {
+		return keys[0].Name, nil
+	}
+	names := make([]string, 0, len(keys))
+	for _, each := range keys {
+		names = append(names, each.Name)
+	}
+	return "", fmt.Errorf("%d keys match [%s], use a more specific filter: %s", len(keys), filter, strings.Join(names, ", "))
+}
+
+// selectKey presents a fuzzy-filter selector on the terminal and returns the name of the chosen key.
+func selectKey(keys []backend.Key) (string, error) {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(fd, state)
+
+	s := &selector{keys: keys, out: os.Stderr}
+	s.render()
+	defer s.clear()
+
+	buf := make([]byte, 8)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return "", err
+		}
+		input := buf[:n]
+		switch {
+		case n == 1 && (input[0] == '\r' || input[0] == '\n'):
+			if name, ok := s.selected(); ok {
+				return name, nil
+			}
+		case n == 1 && (input[0] == 3 || input[0] == 27): // ctrl-c, escape
+			return "", errSelectionAborted
+		case n == 1 && (input[0] == 127 || input[0] == 8): // backspace
+			if len(s.query) > 0 {
+				runes := []rune(s.query)
+				s.query = string(runes[:len(runes)-1])
+				s.cursor = 0
+			}
+		case n == 3 && input[0] == 27 && input[1] == '[' && input[2] == 'A': // arrow up
+			if s.cursor > 0 {
+				s.cursor--
+			}
+		case n == 3 && input[0] == 27 && input[1] == '[' && input[2] == 'B': // arrow down
+			if s.cursor < len(s.matches())-1 && s.cursor < maxSelectorRows-1 {
+				s.cursor++
+			}
+		default:
+			if input[0] >= 32 && input[0] != 127 {
+				s.query += string(input)
+				s.cursor = 0
+			}
+		}
+		s.render()
+	}
+}
+
+type selector struct {
+	keys     []backend.Key
+	query    string
+	cursor   int
+	rendered int // number of lines written by the last render
+	out      io.Writer
+}
+
+// matches returns the names of all keys that fuzzy match the current query.
+func (s *selector) matches() (names []string) {
+	for _, each := range s.keys {
+		if fuzzyMatch(each.Name, s.query) {
+			names = append(names, each.Name)
+		}
+	}
+	return
+}
+
+func (s *selector) selected() (string, bool) {
+	names := s.matches()
+	if s.cursor >= len(names) {
+		return "", false
+	}
+	return names[s.cursor], true
+}
+
+func (s *selector) clear() {
+	if s.rendered > 0 {
+		fmt.Fprintf(s.out, "\x1b[%dA", s.rendered)
+	}
+	fmt.Fprint(s.out, "\r\x1b[J")
+}
+
+func (s *selector) render() {
+	s.clear()
+	names := s.matches()
+	fmt.Fprintf(s.out, "> %s (%d/%d)", s.query, len(names), len(s.keys))
+	s.rendered = 0
+	for i, each := range names {
+		if i == maxSelectorRows {
+			break
+		}
+		marker := " "
+		if i == s.cursor {
+			marker = ">"
+		}
+		// raw mode requires explicit carriage returns
+		fmt.Fprintf(s.out, "\r\n%s %s", marker, each)
+		s.rendered++
+	}
+}
+
+// fuzzyMatch returns true if all runes of the filter appear in the key in the same order, ignoring case.
+func fuzzyMatch(key, filter string) bool {
+	key, filter = strings.ToLower(key), strings.ToLower(filter)
+	for _, each := range filter {
+		i := strings.IndexRune(key, each)
+		if i == -1 {
+			return false
+		}
+		key = key[i+len(string(each)):]
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	for _, each := range []struct {
+		key, filter string
+		want        bool
+	}{
+		{"concourse/cd-pipeline", "", true},
+		{"concourse/cd-pipeline", "ccp", true},
+		{"concourse/cd-pipeline", "CD-PIPE", true},
+		{"concourse/cd-pipeline", "pipec", false},
+		{"concourse/cd-pipeline", "x", false},
+	} {
+		if got := fuzzyMatch(each.key, each.filter); got != each.want {
+			t.Errorf("fuzzyMatch(%q, %q) got [%v] want [%v]", each.key, each.filter, got, each.want)
+		}
+	}
+}
+
+func TestSelectMatchingKeyWithoutTerminal(t *testing.T) {
+	ctx := context.Background()
+	b, target := newTestStore(t)
+	for _, each := range []string{"db/user", "db/password", "api/token"} {
+		if err := b.Put(ctx, target, each, "value", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if key, err := selectMatchingKey(ctx, b, target, "api", true); err != nil || key != "api/token" {
+		t.Errorf("got [%s] %v want [api/token]", key, err)
+	}
+	if _, err := selectMatchingKey(ctx, b, target, "db", true); err == nil {
+		t.Error("expected error for ambiguous filter")
+	}
+	if _, err := selectMatchingKey(ctx, b, target, "missing", true); err == nil {
+		t.Error("expected error if nothing matches")
+	}
+}
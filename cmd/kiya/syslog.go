@@ -0,0 +1,48 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+	"strings"
+)
+
+var syslogFacilities = map[string]syslog.Priority{
+	"kern": syslog.LOG_KERN, "user": syslog.LOG_USER, "mail": syslog.LOG_MAIL, "daemon": syslog.LOG_DAEMON,
+	"auth": syslog.LOG_AUTH, "syslog": syslog.LOG_SYSLOG, "authpriv": syslog.LOG_AUTHPRIV,
+	"local0": syslog.LOG_LOCAL0, "local1": syslog.LOG_LOCAL1, "local2": syslog.LOG_LOCAL2, "local3": syslog.LOG_LOCAL3,
+	"local4": syslog.LOG_LOCAL4, "local5": syslog.LOG_LOCAL5, "local6": syslog.LOG_LOCAL6, "local7": syslog.LOG_LOCAL7,
+}
+
+var syslogSeverities = map[string]syslog.Priority{
+	"emerg": syslog.LOG_EMERG, "alert": syslog.LOG_ALERT, "crit": syslog.LOG_CRIT, "err": syslog.LOG_ERR,
+	"warning": syslog.LOG_WARNING, "notice": syslog.LOG_NOTICE, "info": syslog.LOG_INFO, "debug": syslog.LOG_DEBUG,
+}
+
+// parseSyslogPriority returns the priority of facility.severity, e.g. local0.notice; the severity defaults to info.
+func parseSyslogPriority(spec string) (syslog.Priority, error) {
+	facilityName, severityName, ok := strings.Cut(strings.ToLower(spec), ".")
+	if !ok {
+		severityName = "info"
+	}
+	facility, ok := syslogFacilities[facilityName]
+	if !ok {
+		return 0, fmt.Errorf("unknown syslog facility [%s], e.g. use user, auth or local0", facilityName)
+	}
+	severity, ok := syslogSeverities[severityName]
+	if !ok {
+		return 0, fmt.Errorf("unknown syslog severity [%s], e.g. use info or notice", severityName)
+	}
+	return facility | severity, nil
+}
+
+// openSyslogWriter returns a writer that sends each event to the local syslog with the priority of the spec.
+func openSyslogWriter(spec string) (io.WriteCloser, error) {
+	priority, err := parseSyslogPriority(spec)
+	if err != nil {
+		return nil, err
+	}
+	return syslog.New(priority, "kiya")
+}
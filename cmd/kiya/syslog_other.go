@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package main
+
+import (
+	"errors"
+	"io"
+)
+
+// openSyslogWriter fails because syslog is not available on this platform.
+func openSyslogWriter(spec string) (io.WriteCloser, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
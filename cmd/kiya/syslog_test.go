@@ -0,0 +1,22 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"log/syslog"
+	"testing"
+)
+
+func TestParseSyslogPriority(t *testing.T) {
+	if got, err := parseSyslogPriority("local0.notice"); err != nil || got != syslog.LOG_LOCAL0|syslog.LOG_NOTICE {
+		t.Errorf("got %v %v", got, err)
+	}
+	if got, err := parseSyslogPriority("AUTH"); err != nil || got != syslog.LOG_AUTH|syslog.LOG_INFO {
+		t.Errorf("got %v %v", got, err)
+	}
+	for _, each := range []string{"", "local9", "user.loud"} {
+		if _, err := parseSyslogPriority(each); err == nil {
+			t.Errorf("expected error for [%s]", each)
+		}
+	}
+}
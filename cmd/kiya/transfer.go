@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kramphub/kiya/backend"
+)
+
+// describeBackend returns the backend type of the profile with where it stores secrets, e.g. gsm(proj=infra).
+func describeBackend(p *backend.Profile) string {
+	name := p.Backend
+	if len(name) == 0 {
+		name = "kms"
+	}
+	switch name {
+	case "file":
+		if len(p.Location) > 0 {
+			return fmt.Sprintf("file(%s)", p.Location)
+		}
+		return fmt.Sprintf("file(proj=%s)", p.ProjectID)
+	case "ssm":
+		return fmt.Sprintf("ssm(region=%s)", p.Location)
+	case "akv":
+		return fmt.Sprintf("akv(vault=%s)", p.VaultUrl)
+	case "etcd":
+		return fmt.Sprintf("etcd(endpoints=%s, prefix=%s)", strings.Join(p.Endpoints, ","), p.ProjectID)
+	case "awskms":
+		return fmt.Sprintf("awskms(bucket=%s, prefix=%s)", p.Bucket, p.ProjectID)
+	case "k8s":
+		return fmt.Sprintf("k8s(secret=%s/%s)", p.ProjectID, p.SecretName)
+	case "consul":
+		return fmt.Sprintf("consul(address=%s, prefix=%s)", strings.Join(p.Endpoints, ","), p.ProjectID)
+	}
+	return fmt.Sprintf("%s(proj=%s)", name, p.ProjectID)
+}
+
+// describeTransfer returns a summary of an operation that transfers secrets between profiles,
+// shown before it is confirmed or executed, e.g.
+// MOVE prod/db/password from [prod] gsm(proj=infra) to [dev] file(~/.secrets): delete source? yes
+func describeTransfer(operation, what string, source, target *backend.Profile, deleteSource bool) string {
+	return fmt.Sprintf("%s %s from [%s] %s to [%s] %s: delete source? %s",
+		strings.ToUpper(operation), what,
+		source.Label, describeBackend(source),
+		target.Label, describeBackend(target),
+		yesNo(deleteSource))
+}
@@ -2,29 +2,52 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/kramphub/kiya/backend"
 	"golang.org/x/term"
 )
 
-func readFromStdIn() string {
-	buffer, err := io.ReadAll(os.Stdin)
+func readFromStdIn() (string, error) {
+	return readValue(os.Stdin)
+}
+
+// readValue reads all input and removes a single trailing newline, if present.
+// Empty input results in an empty string.
+func readValue(r io.Reader) (string, error) {
+	buffer, err := io.ReadAll(r)
 	if err != nil {
-		log.Fatal("Error while reading from standard in", err)
+		return "", fmt.Errorf("error while reading from standard in, %w", err)
 	}
 
 	// remove newline added to std in from command execution
-	if buffer[len(buffer)-1] == '\n' {
+	if len(buffer) > 0 && buffer[len(buffer)-1] == '\n' {
 		buffer = buffer[:len(buffer)-1]
 	}
 
-	return string(buffer)
+	return string(buffer), nil
+}
+
+// writeValue writes the value followed by a newline or, if raw, exactly the bytes of the value.
+func writeValue(w io.Writer, value []byte, raw bool) error {
+	if _, err := w.Write(value); err != nil {
+		return err
+	}
+	if raw {
+		return nil
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
 }
 
 // PromptForYes prompts for a yes or no in a CMD environment.
@@ -34,57 +57,143 @@ func promptForYes(message string) bool {
 	if *oQuiet {
 		return true
 	}
+	return askYes(message)
+}
 
+// askYes prints the message and returns whether the answer read from stdin starts with y, regardless of -quiet.
+func askYes(message string) bool {
 	reader := bufio.NewReader(os.Stdin)
 	fmt.Print(message)
 	yn, _ := reader.ReadString('\n')
 	return strings.HasPrefix(yn, "Y") || strings.HasPrefix(yn, "y")
 }
 
+// isFlagPassed returns whether the flag was given on the command line, even if equal to its default.
+func isFlagPassed(name string) bool {
+	passed := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			passed = true
+		}
+	})
+	return passed
+}
+
 func shouldPromptForPassword(b backend.Backend) bool {
-	switch b.(type) {
+	switch inner := backend.Unwrap(b).(type) {
 	case *backend.FileStore:
 		return true
+	case *backend.EtcdStore:
+		return inner.Encrypts()
 	default:
 		return false
 	}
 }
 
-func promptForPassword() []byte {
+func promptForPassword() ([]byte, error) {
 	log.Print("[INFO]: Make sure you use a secure and strong master password.")
 
 	fmt.Println("Enter master password: ")
 	password, err := term.ReadPassword(int(os.Stdin.Fd()))
 
 	if err != nil {
-		log.Fatal("Error while reading password from standard in", err)
+		return nil, fmt.Errorf("error while reading password from standard in, %w", err)
 	}
 
 	if len(password) == 0 {
-		log.Fatal("Password should have at least one character.")
+		return nil, errors.New("password should have at least one character")
+	}
+	return password, nil
+}
+
+// setMasterPassword prompts for the master password and sets it if the backend needs one.
+func setMasterPassword(b backend.Backend) error {
+	if !shouldPromptForPassword(b) {
+		return nil
 	}
-	return password
+	pass, err := promptForPassword()
+	if err != nil {
+		return err
+	}
+	b.SetParameter("masterPassword", pass)
+	return nil
 }
 
 // encodeToJson encodes the given object to JSON.
-func encodeToJson(v interface{}) []byte {
+func encodeToJson(v interface{}) ([]byte, error) {
 	buf, err := json.Marshal(v)
-
 	if err != nil {
-		log.Fatalf("[FATAL] encode struct to JSON failed: %s", err.Error())
+		return nil, fmt.Errorf("encode struct to JSON failed, %w", err)
 	}
+	return buf, nil
+}
 
-	return buf
+// writeJSON writes the JSON of the object on a single line, or indented if the -pretty flag is set.
+// All JSON written for the user is written by this function such that its formatting is consistent.
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	if *oPretty {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
+
+// isBlank returns true if the value is empty or only whitespace.
+func isBlank(value []byte) bool {
+	return len(bytes.TrimSpace(value)) == 0
+}
+
+// prettyJSON returns the value indented if it is a JSON object or array, otherwise the value is returned unchanged.
+func prettyJSON(value []byte) []byte {
+	trimmed := bytes.TrimSpace(value)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') || !json.Valid(trimmed) {
+		return value
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, trimmed, "", "  "); err != nil {
+		return value
+	}
+	return buf.Bytes()
 }
 
 // decodeJson decodes the given JSON to the given object.
-func decodeJson[T interface{}](data []byte) T {
+func decodeJson[T interface{}](data []byte) (T, error) {
 	var obj T
-	err := json.Unmarshal(data, &obj)
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return obj, fmt.Errorf("decode JSON failed, %w", err)
+	}
+	return obj, nil
+}
 
-	if err != nil {
-		log.Fatalf("[FATAL] decode JSON failed: %s", err.Error())
+// forEachConcurrently calls fn for each index in [0,n) using at most concurrency goroutines.
+func forEachConcurrently(n, concurrency int, fn func(i int)) {
+	if concurrency < 1 {
+		concurrency = 1
 	}
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, concurrency)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
 
-	return obj
+// existingKeys returns the names of all keys in the profile using a single listing.
+// Bulk commands use it to decide between create and overwrite without checking each key.
+func existingKeys(ctx context.Context, b backend.Backend, target *backend.Profile) (map[string]bool, error) {
+	keys, err := b.List(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(keys))
+	for _, each := range keys {
+		existing[each.Name] = true
+	}
+	return existing, nil
 }
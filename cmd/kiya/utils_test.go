@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrettyJSON(t *testing.T) {
+	for _, each := range []struct{ value, want string }{
+		{`{"a":1}`, "{\n  \"a\": 1\n}"},
+		{`[1,2]`, "[\n  1,\n  2\n]"},
+		{`not json`, `not json`},
+		{`"a string"`, `"a string"`},
+		{`{"broken":`, `{"broken":`},
+	} {
+		if got := string(prettyJSON([]byte(each.value))); got != each.want {
+			t.Errorf("got [%s] want [%s]", got, each.want)
+		}
+	}
+}
+
+func TestIsBlank(t *testing.T) {
+	for _, each := range []struct {
+		value string
+		want  bool
+	}{
+		{"", true},
+		{" \n\t", true},
+		{"secret", false},
+		{" secret ", false},
+	} {
+		if got := isBlank([]byte(each.value)); got != each.want {
+			t.Errorf("isBlank(%q) got %v want %v", each.value, got, each.want)
+		}
+	}
+}
+
+func TestReadValue(t *testing.T) {
+	for _, each := range []struct{ input, want string }{
+		{"", ""},
+		{"\n", ""},
+		{"secret", "secret"},
+		{"secret\n", "secret"},
+		{"secret\n\n", "secret\n"},
+	} {
+		if got, _ := readValue(strings.NewReader(each.input)); got != each.want {
+			t.Errorf("readValue(%q) got %q want %q", each.input, got, each.want)
+		}
+	}
+}
+
+func TestWriteValue(t *testing.T) {
+	value := []byte("-----BEGIN KEY-----\x00\xff")
+	raw := new(bytes.Buffer)
+	if err := writeValue(raw, value, true); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(raw.Bytes(), value) {
+		t.Errorf("raw got %q want %q", raw.Bytes(), value)
+	}
+	line := new(bytes.Buffer)
+	writeValue(line, value, false)
+	if got, want := line.String(), string(value)+"\n"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
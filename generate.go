@@ -3,18 +3,36 @@ package kiya
 import (
 	"bytes"
 	"crypto/rand"
+	"math"
 	"math/big"
+	"strings"
 )
 
 // default set contains characters that do not required URL encoding
 // the kiya configuration can override this set per profile.
 const defaultSecreteCharSet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-~"
 
-// GenerateSecret composes a random secrets using runes from a give set.
-func GenerateSecret(length int, runes []rune) (string, error) {
+// ambiguousRunes are easily confused when a secret is read or transcribed by a human.
+const ambiguousRunes = "0Oo1lI|`'\""
+
+// UnambiguousRunes returns the runes, or the default set if empty, without those that are easily confused such as 0 and O.
+func UnambiguousRunes(runes []rune) []rune {
 	if len(runes) == 0 {
 		runes = []rune(defaultSecreteCharSet)
 	}
+	filtered := make([]rune, 0, len(runes))
+	for _, each := range runes {
+		if !strings.ContainsRune(ambiguousRunes, each) {
+			filtered = append(filtered, each)
+		}
+	}
+	return filtered
+}
+
+// GenerateSecret composes a random secrets using runes from a give set.
+// Duplicate runes are ignored such that each rune is equally likely, as SecretEntropy assumes.
+func GenerateSecret(length int, runes []rune) (string, error) {
+	runes = distinctRunes(runes)
 	var buffer bytes.Buffer
 	for i := 0; i < length; i++ {
 		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(runes))))
@@ -25,3 +43,25 @@ func GenerateSecret(length int, runes []rune) (string, error) {
 	}
 	return buffer.String(), nil
 }
+
+// SecretEntropy returns the estimated entropy in bits of a secret generated by GenerateSecret.
+// Only distinct runes contribute to the size of the alphabet.
+func SecretEntropy(length int, runes []rune) float64 {
+	return float64(length) * math.Log2(float64(len(distinctRunes(runes))))
+}
+
+// distinctRunes returns the runes, or the default set if empty, without duplicates and in their original order.
+func distinctRunes(runes []rune) []rune {
+	if len(runes) == 0 {
+		runes = []rune(defaultSecreteCharSet)
+	}
+	seen := map[rune]bool{}
+	distinct := make([]rune, 0, len(runes))
+	for _, each := range runes {
+		if !seen[each] {
+			seen[each] = true
+			distinct = append(distinct, each)
+		}
+	}
+	return distinct
+}
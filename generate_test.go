@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/url"
 	"regexp"
+	"strings"
 	"testing"
 	"unicode/utf8"
 )
@@ -63,3 +64,36 @@ func matchCharsRegex(chars string) (*regexp.Regexp, error) {
 	regex.WriteString("]*$")
 	return regexp.Compile(regex.String())
 }
+
+func TestSecretEntropy(t *testing.T) {
+	if got, want := SecretEntropy(10, []rune("ab")), 10.0; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := SecretEntropy(10, []rune("aab")), 10.0; got != want {
+		t.Errorf("duplicates: got %v want %v", got, want)
+	}
+	if got, want := SecretEntropy(20, nil), SecretEntropy(20, []rune(defaultSecreteCharSet)); got != want {
+		t.Errorf("default: got %v want %v", got, want)
+	}
+}
+
+func TestDistinctRunes(t *testing.T) {
+	if got, want := string(distinctRunes([]rune("abacb"))), "abc"; got != want {
+		t.Errorf("got [%s] want [%s]", got, want)
+	}
+	if got, want := string(distinctRunes(nil)), defaultSecreteCharSet; got != want {
+		t.Errorf("default: got [%s] want [%s]", got, want)
+	}
+}
+
+func TestUnambiguousRunes(t *testing.T) {
+	runes := string(UnambiguousRunes(nil))
+	for _, each := range "0Oo1lI" {
+		if strings.ContainsRune(runes, each) {
+			t.Errorf("ambiguous rune %c not excluded", each)
+		}
+	}
+	if got, want := string(UnambiguousRunes([]rune("a0b1"))), "ab"; got != want {
+		t.Errorf("got [%s] want [%s]", got, want)
+	}
+}
@@ -1,10 +1,15 @@
 package kiya
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"path"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/kramphub/kiya/backend"
 )
@@ -25,9 +30,29 @@ func load(configFile string) (profs map[string]backend.Profile, err error) {
 		each.Label = l
 		profs[l] = each
 	}
+	if err == nil {
+		err = validateProfiles(profs)
+	}
 	return
 }
 
+// validateProfiles returns an error for the first profile with invalid settings.
+func validateProfiles(profs map[string]backend.Profile) error {
+	labels := make([]string, 0, len(profs))
+	for each := range profs {
+		labels = append(labels, each)
+	}
+	sort.Strings(labels)
+	for _, each := range labels {
+		if retry := profs[each].Retry; retry != nil {
+			if err := retry.Validate(); err != nil {
+				return fmt.Errorf("profile [%s]: %w", each, err)
+			}
+		}
+	}
+	return nil
+}
+
 func configLocation(configFile string) string {
 	location := configFile
 	if len(location) == 0 {
@@ -44,3 +69,110 @@ func LoadConfiguration(configFile string) {
 	}
 	Profiles = profs
 }
+
+// loadAll loads and merges all configuration files matching the pattern.
+// If the pattern is a directory then all files in that directory are loaded.
+func loadAll(pattern string) (map[string]backend.Profile, error) {
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		pattern = filepath.Join(pattern, "*")
+	}
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no configuration files match %s", pattern)
+	}
+	sort.Strings(files)
+	all := map[string]backend.Profile{}
+	origins := map[string]string{}
+	for _, each := range files {
+		if info, err := os.Stat(each); err != nil || info.IsDir() {
+			continue
+		}
+		profs, err := load(each)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read/parse %s: %w", each, err)
+		}
+		for label, p := range profs {
+			if other, ok := origins[label]; ok {
+				return nil, fmt.Errorf("duplicate profile [%s] in %s and %s", label, other, each)
+			}
+			origins[label] = each
+			all[label] = p
+		}
+	}
+	return all, nil
+}
+
+// LoadConfigurationGlob loads all configuration files matching the glob pattern or in the directory.
+func LoadConfigurationGlob(pattern string) {
+	profs, err := loadAll(pattern)
+	if err != nil {
+		log.Fatal("unable to load kiya configuration files: ", err)
+	}
+	Profiles = profs
+}
+
+// AdHocProfileName is the reserved name of the profile given on the command line instead of in a configuration file.
+const AdHocProfileName = "_"
+
+// parseProfile returns the profile of a JSON object, or of the JSON file if the spec is not an object.
+func parseProfile(spec string) (p backend.Profile, err error) {
+	data := []byte(spec)
+	if trimmed := strings.TrimSpace(spec); !strings.HasPrefix(trimmed, "{") {
+		data, err = os.ReadFile(trimmed)
+		if err != nil {
+			return
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&p); err != nil {
+		return p, fmt.Errorf("invalid profile: %w", err)
+	}
+	p.Label = AdHocProfileName
+	err = validateProfiles(map[string]backend.Profile{AdHocProfileName: p})
+	return
+}
+
+// AddAdHocProfile registers the profile of a JSON object, or JSON file, as AdHocProfileName.
+// The configuration file is loaded if it exists such that other profiles remain available.
+func AddAdHocProfile(spec, configFile string) {
+	if _, err := os.Stat(configLocation(configFile)); err == nil {
+		LoadConfiguration(configFile)
+	}
+	addAdHocProfile(spec, configLocation(configFile))
+}
+
+// AddAdHocProfileGlob registers the profile as AddAdHocProfile does, next to the profiles of all configuration files
+// matching the pattern.
+func AddAdHocProfileGlob(spec, pattern string) {
+	LoadConfigurationGlob(pattern)
+	addAdHocProfile(spec, pattern)
+}
+
+// addAdHocProfile adds the parsed profile to the loaded Profiles, which were loaded from origin.
+func addAdHocProfile(spec, origin string) {
+	p, err := parseProfile(spec)
+	if err != nil {
+		log.Fatal("unable to parse ad-hoc profile: ", err)
+	}
+	profiles, err := withAdHocProfile(Profiles, p, origin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	Profiles = profiles
+}
+
+// withAdHocProfile returns the profiles including the ad-hoc profile or an error if a loaded profile uses its name.
+func withAdHocProfile(profiles map[string]backend.Profile, p backend.Profile, origin string) (map[string]backend.Profile, error) {
+	if profiles == nil {
+		profiles = map[string]backend.Profile{}
+	}
+	if _, ok := profiles[AdHocProfileName]; ok {
+		return nil, fmt.Errorf("profile [%s] is reserved for ad-hoc profiles, rename it in %s", AdHocProfileName, origin)
+	}
+	profiles[AdHocProfileName] = p
+	return profiles, nil
+}
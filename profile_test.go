@@ -0,0 +1,84 @@
+package kiya
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadAll(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "team-a.json"), []byte(`{"a":{"projectID":"pa"}}`), 0600)
+	os.WriteFile(filepath.Join(dir, "team-b.json"), []byte(`{"b":{"projectID":"pb"}}`), 0600)
+	profs, err := loadAll(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(profs), 2; got != want {
+		t.Fatalf("got %d want %d", got, want)
+	}
+	if got, want := profs["b"].Label, "b"; got != want {
+		t.Errorf("got [%s] want [%s]", got, want)
+	}
+
+	os.WriteFile(filepath.Join(dir, "team-c.json"), []byte(`{"a":{"projectID":"pc"}}`), 0600)
+	_, err = loadAll(filepath.Join(dir, "*.json"))
+	if err == nil || !strings.Contains(err.Error(), "duplicate profile [a]") {
+		t.Errorf("expected duplicate error, got %v", err)
+	}
+}
+
+func TestParseProfile(t *testing.T) {
+	p, err := parseProfile(`{"Backend":"gsm","projectID":"my-project"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Backend != "gsm" || p.ProjectID != "my-project" || p.Label != AdHocProfileName {
+		t.Errorf("unexpected profile %#v", p)
+	}
+	file := filepath.Join(t.TempDir(), "profile.json")
+	os.WriteFile(file, []byte(`{"backend":"file","location":"/tmp/store"}`), 0600)
+	if p, err := parseProfile(file); err != nil || p.Location != "/tmp/store" {
+		t.Errorf("got %#v %v", p, err)
+	}
+	if _, err := parseProfile(`{"backnd":"gsm"}`); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestWithAdHocProfile(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "team-a.json"), []byte(`{"a":{"projectID":"pa"}}`), 0600)
+	profs, err := loadAll(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adHoc, _ := parseProfile(`{"backend":"gsm","projectID":"adhoc"}`)
+	profs, err = withAdHocProfile(profs, adHoc, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profs["a"].ProjectID != "pa" || profs[AdHocProfileName].ProjectID != "adhoc" {
+		t.Errorf("unexpected profiles %#v", profs)
+	}
+	if _, err := withAdHocProfile(profs, adHoc, dir); err == nil || !strings.Contains(err.Error(), "reserved") {
+		t.Errorf("expected reserved name error, got %v", err)
+	}
+}
+
+func TestLoadValidatesRetry(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "kiya.json")
+	os.WriteFile(file, []byte(`{"a":{"retry":{"maxAttempts":3,"baseDelay":"2s","maxDelay":"1s"}}}`), 0600)
+	if _, err := load(file); err == nil || !strings.Contains(err.Error(), "profile [a]") {
+		t.Errorf("expected retry error for profile [a], got %v", err)
+	}
+	os.WriteFile(file, []byte(`{"a":{"retry":{"maxAttempts":3,"baseDelay":"100ms","maxDelay":"1s","jitter":0.2}}}`), 0600)
+	profs, err := load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := profs["a"].Retry.MaxAttempts, 3; got != want {
+		t.Errorf("got %d want %d", got, want)
+	}
+}